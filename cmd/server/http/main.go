@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 
+	purgejob "github.com/gmaschi/log-exp-eval/internal/jobs/purge"
 	expserver "github.com/gmaschi/log-exp-eval/internal/servers/expressions"
 	expstore "github.com/gmaschi/log-exp-eval/internal/services/datastore/postgresql/exp"
 	"github.com/gmaschi/log-exp-eval/internal/services/eval"
@@ -31,12 +33,22 @@ func main() {
 	}
 
 	store := expstore.NewStore(conn)
-	ev := eval.New()
+	ev := eval.New(eval.Config{
+		CacheSize:              config.EvalCacheSize,
+		MaxTruthTableVariables: config.TruthTableMaxVars,
+	})
 	server, err := expserver.New(config, store, ev)
 	if err != nil {
 		log.Printf("failed to initialize server: %v", err)
 	}
 
+	if config.PurgeRetention > 0 {
+		go purgejob.New(store, purgejob.Config{
+			Retention: config.PurgeRetention,
+			Interval:  config.PurgeInterval,
+		}).Run(context.Background())
+	}
+
 	err = server.Start(config.ServerAddress)
 	if err != nil {
 		log.Printf("server error: %v", err)
@@ -1,9 +1,18 @@
 package expcontroller
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,22 +26,134 @@ import (
 	ginmidctx "github.com/gmaschi/log-exp-eval/pkg/tools/middlewares/gin/context"
 	"github.com/gmaschi/log-exp-eval/pkg/tools/pagination"
 	"github.com/gmaschi/log-exp-eval/pkg/tools/parse"
-	"github.com/gmaschi/log-exp-eval/pkg/tools/str"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 )
 
-// Controller defines the expression controllers and its required fields.
-type Controller struct {
-	store     expstore.Store
-	evaluator eval.Evaluator
-}
+const (
+	mimeCSV    = "text/csv"
+	mimeNDJSON = "application/x-ndjson"
+
+	// explainQueryKey is the query parameter that requests the explanation of an evaluation.
+	explainQueryKey = "explain"
+	// strictQueryKey is the query parameter that rejects unknown variables in batch evaluations.
+	strictQueryKey = "strict"
+	// modeQueryKey is the query parameter that selects the logic of an evaluation.
+	modeQueryKey = "mode"
+	// versionQueryKey is the query parameter that selects the version of the expression to evaluate.
+	versionQueryKey = "version"
+	// cursorQueryKey is the query parameter that pages through expressions by cursor. It is sent empty for the
+	// first page.
+	cursorQueryKey = "cursor"
+
+	// orderDescending is the value of the order query parameter that lists expressions in descending order.
+	orderDescending = "desc"
+
+	// etagHeader carries the entity tag of the expression returned by a response.
+	etagHeader = "ETag"
+	// ifMatchHeader carries the entity tags an update expects the expression to still have.
+	ifMatchHeader = "If-Match"
+	// linkHeader carries the link to the next page of expressions listed by cursor.
+	linkHeader = "Link"
+
+	// modeBoolean evaluates with two-valued logic and requires every variable, which is the default.
+	modeBoolean = "boolean"
+	// modeKleene evaluates with the three-valued logic of Kleene, where variables without a value are unknown.
+	modeKleene = "kleene"
+
+	// DefaultMaxBatchSize is the maximum number of items of a batch evaluation when none is configured.
+	DefaultMaxBatchSize = 1000
+	// DefaultMaxBatchBytes is the maximum size of the body of a batch evaluation when none is configured.
+	DefaultMaxBatchBytes = 32 << 20
+	// DefaultBatchTimeout bounds a batch evaluation, including the read of its body, when no timeout is configured.
+	DefaultBatchTimeout = 10 * time.Second
+	// DefaultMatchIndexTTL is how long the reverse index of a user is used before it is loaded again, when no
+	// TTL is configured. It bounds how long matches miss changes made by other instances of the service.
+	DefaultMatchIndexTTL = time.Minute
+	// DefaultLinkTTL is how long a linked program is reused when no TTL is configured. It bounds how long
+	// evaluations miss changes made to referenced expressions by other instances of the service.
+	DefaultLinkTTL = time.Minute
+	// maxNDJSONLineSize bounds a single line of an NDJSON batch.
+	maxNDJSONLineSize = 1 << 20
+)
+
+// likeEscaper escapes the wildcards of LIKE patterns, so text searches match them literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+var (
+	errBatchTooLarge    = errors.New("batch too large")
+	errMalformedBatch   = errors.New("malformed batch")
+	errUnknownReference = errors.New("no such expression")
+)
+
+type (
+	// Controller defines the expression controllers and its required fields.
+	Controller struct {
+		store         expstore.Store
+		compiler      eval.Compiler
+		runner        eval.Runner
+		analyzer      eval.Analyzer
+		matcher       eval.Matcher
+		links         *eval.LinkCache
+		maxBatchSize  int
+		maxBatchBytes int64
+		batchTimeout  time.Duration
+	}
+
+	// Config holds the settings of a Controller. Zero values fall back to the defaults.
+	Config struct {
+		// MaxBatchSize is the maximum number of variable sets of a batch evaluation.
+		MaxBatchSize int
+		// MaxBatchBytes is the maximum size of the body of a batch evaluation.
+		MaxBatchBytes int64
+		// BatchTimeout bounds the time spent reading and evaluating a batch.
+		BatchTimeout time.Duration
+		// MatchIndexTTL is how long the reverse index of a user is used before it is loaded again.
+		MatchIndexTTL time.Duration
+		// LinkCacheSize is the number of linked programs kept.
+		LinkCacheSize int
+		// LinkTTL is how long a linked program is reused before its references are resolved again.
+		LinkTTL time.Duration
+	}
+)
 
 // New creates a pointer to a Controller
-func New(store expstore.Store, ev eval.Evaluator) *Controller {
+func New(store expstore.Store, ev eval.Evaluator, cfg Config) *Controller {
+	maxBatchSize := cfg.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
+	maxBatchBytes := cfg.MaxBatchBytes
+	if maxBatchBytes <= 0 {
+		maxBatchBytes = DefaultMaxBatchBytes
+	}
+
+	batchTimeout := cfg.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = DefaultBatchTimeout
+	}
+
+	matchIndexTTL := cfg.MatchIndexTTL
+	if matchIndexTTL <= 0 {
+		matchIndexTTL = DefaultMatchIndexTTL
+	}
+
+	linkTTL := cfg.LinkTTL
+	if linkTTL <= 0 {
+		linkTTL = DefaultLinkTTL
+	}
+
 	return &Controller{
-		store:     store,
-		evaluator: ev,
+		store:         store,
+		compiler:      ev,
+		runner:        ev,
+		analyzer:      ev,
+		matcher:       eval.NewMatcher(matchIndexTTL),
+		links:         eval.NewLinkCache(cfg.LinkCacheSize, linkTTL),
+		maxBatchSize:  maxBatchSize,
+		maxBatchBytes: maxBatchBytes,
+		batchTimeout:  batchTimeout,
 	}
 }
 
@@ -44,13 +165,20 @@ func (c *Controller) Create(ctx *gin.Context) {
 		return
 	}
 
-	// TODO: implement expression validator
-	exp := strings.TrimSpace(req.Expression)
-	if exp == "" {
-		ctx.JSON(
-			http.StatusBadRequest,
-			parse.ErrorAsJSON(fmt.Errorf("%s: empty expression", experrors.ErrInvalidExpressionID)),
-		)
+	prog, ok := c.compileRaw(ctx, req.Expression, experrors.ErrInvalidExpression)
+	if !ok {
+		return
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	name, err := normalizeName(req.Name)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
 		return
 	}
 
@@ -72,6 +200,11 @@ func (c *Controller) Create(ctx *gin.Context) {
 		return
 	}
 
+	newExp := expstore.Expressions{ExpressionID: expID, Expression: req.Expression, Username: authPayload.Username, Name: name}
+	if _, ok := c.link(ctx, newExp, prog, experrors.ErrInvalidExpression); !ok {
+		return
+	}
+
 	now := time.Now()
 	createExpArgs := expstore.CreateExpressionParams{
 		ExpressionID: expID,
@@ -79,16 +212,25 @@ func (c *Controller) Create(ctx *gin.Context) {
 		Username:     authPayload.Username,
 		CreatedAt:    now,
 		UpdatedAt:    now,
+		Tags:         tags,
+		Name:         name,
+		Variables:    variablesOf(prog),
 	}
 
-	createdExp, err := c.store.CreateExpression(ctx, createExpArgs)
+	createdExp, err := c.store.CreateExpressionTx(ctx, createExpArgs)
 	if err != nil {
+		if expstore.IsUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, parse.ErrorAsJSON(fmt.Errorf("%s: %s", experrors.ErrNameTaken, name)))
+			return
+		}
+
 		ctx.JSON(
 			http.StatusInternalServerError,
 			parse.ErrorAsJSON(experrors.ErrCreatingExpression.Error()),
 		)
 		return
 	}
+	c.index(ctx, createdExp)
 
 	var res expmodel.CreateExpressionResponse
 	err = marshaller.Response(createdExp, &res)
@@ -100,6 +242,7 @@ func (c *Controller) Create(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header(etagHeader, etag(createdExp))
 	ctx.JSON(http.StatusCreated, res)
 }
 
@@ -162,10 +305,39 @@ func (c *Controller) Get(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header(etagHeader, etag(gotExp))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetByName handles the request to retrieve an expression of the authenticated user by its name.
+func (c *Controller) GetByName(ctx *gin.Context) {
+	var req expmodel.GetExpressionByNameRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	gotExp, ok := c.getUserExpressionByName(ctx, req.Name)
+	if !ok {
+		return
+	}
+
+	var res expmodel.GetExpressionResponse
+	err := marshaller.Response(gotExp, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.Header(etagHeader, etag(gotExp))
 	ctx.JSON(http.StatusOK, res)
 }
 
-// Delete handles the request to soft deletes an expression by ID
+// Delete handles the request to soft delete an expression by ID. Deleted expressions can be restored until they are
+// purged.
 func (c *Controller) Delete(ctx *gin.Context) {
 	var req expmodel.DeleteExpressionRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
@@ -214,15 +386,153 @@ func (c *Controller) Delete(ctx *gin.Context) {
 		return
 	}
 
-	err = c.store.DeleteExpressionByID(ctx, gotExp.ExpressionID)
+	expectedVersion, ok := ifMatch(ctx, gotExp)
+	if !ok {
+		return
+	}
+
+	exps, err := c.store.ListExpressionsByUsername(ctx, expstore.ListExpressionsByUsernameParams{Username: gotExp.Username})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrDeletingExpression.Error()))
+		return
+	}
+	if dependents := c.dependents(exps, gotExp, false); len(dependents) > 0 {
+		c.expressionInUse(ctx, dependents)
+		return
+	}
+
+	deleted, err := c.store.DeleteExpressionByID(ctx, expstore.DeleteExpressionByIDParams{
+		ExpressionID:    gotExp.ExpressionID,
+		ExpectedVersion: expectedVersion,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrDeletingExpression.Error()))
 		return
 	}
+	if deleted == 0 {
+		if expectedVersion != 0 {
+			// another update went through since the expression was retrieved
+			ctx.JSON(http.StatusPreconditionFailed, parse.ErrorAsJSON(experrors.ErrExpressionModified.Error()))
+			return
+		}
+
+		// another deletion went through since the expression was retrieved
+		ctx.JSON(http.StatusNotFound, parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()))
+		return
+	}
+	c.invalidate(gotExp.ExpressionID)
+	c.matcher.Remove(gotExp.ExpressionID)
 
 	ctx.JSON(http.StatusNoContent, "")
 }
 
+// Restore handles the request to restore a deleted expression by ID. Expressions that reference expressions deleted
+// since are not restored, and neither are expressions whose name was given to another expression since. Like
+// updates, it checks the optional If-Match header against the entity tag of the deleted expression.
+func (c *Controller) Restore(ctx *gin.Context) {
+	var req expmodel.RestoreExpressionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	authPayload, err := extractAuthPayload(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(err),
+		)
+		return
+	}
+
+	sanitizedID := strings.TrimSpace(req.ID)
+	expID, err := uuid.Parse(sanitizedID)
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %s", experrors.ErrInvalidExpressionID, sanitizedID)),
+		)
+		return
+	}
+
+	gotExp, err := c.store.GetDeletedExpressionByID(ctx, expID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(
+				http.StatusNotFound,
+				parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
+			)
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRetrievingExpression.Error()))
+		return
+	}
+
+	if gotExp.Username != authPayload.Username {
+		ctx.JSON(
+			http.StatusNotFound,
+			parse.ErrorAsJSON(fmt.Errorf("expression %s not found for user %s (%s)", sanitizedID, authPayload.Username, authPayload.UserID)),
+		)
+		return
+	}
+
+	expectedVersion, ok := ifMatch(ctx, gotExp)
+	if !ok {
+		return
+	}
+
+	cacheKey := eval.CacheKey{ID: gotExp.ExpressionID, UpdatedAt: gotExp.UpdatedAt}
+	prog, err := c.compiler.CompileCached(cacheKey, gotExp.Expression)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrRestoringExpression, err)))
+		return
+	}
+	if _, ok := c.link(ctx, gotExp, prog, experrors.ErrRestoringExpression); !ok {
+		return
+	}
+
+	restoredExp, err := c.store.RestoreExpressionByID(ctx, expstore.RestoreExpressionByIDParams{
+		ExpressionID:    gotExp.ExpressionID,
+		ExpectedVersion: expectedVersion,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows && expectedVersion != 0 {
+			// the expression was restored or purged since it was retrieved
+			ctx.JSON(http.StatusPreconditionFailed, parse.ErrorAsJSON(experrors.ErrExpressionModified.Error()))
+			return
+		}
+		if err == sql.ErrNoRows {
+			ctx.JSON(
+				http.StatusNotFound,
+				parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
+			)
+			return
+		}
+		if expstore.IsUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, parse.ErrorAsJSON(fmt.Errorf("%s: %s", experrors.ErrNameTaken, gotExp.Name)))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRestoringExpression.Error()))
+		return
+	}
+	c.index(ctx, restoredExp)
+
+	var res expmodel.GetExpressionResponse
+	err = marshaller.Response(restoredExp, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.Header(etagHeader, etag(restoredExp))
+	ctx.JSON(http.StatusOK, res)
+}
+
 // Update handles the request to update an expression by ID
 func (c *Controller) Update(ctx *gin.Context) {
 	var req expmodel.UpdateExpressionRequest
@@ -241,11 +551,29 @@ func (c *Controller) Update(ctx *gin.Context) {
 	}
 
 	sanitizedExp := strings.TrimSpace(req.Expression)
-	if sanitizedExp == "" {
-		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: empty expression", experrors.ErrInvalidExpression)))
+	prog, ok := c.compileRaw(ctx, sanitizedExp, experrors.ErrInvalidExpression)
+	if !ok {
 		return
 	}
 
+	var tags []string
+	if req.Tags != nil {
+		tags, err = normalizeTags(req.Tags)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+			return
+		}
+	}
+
+	var name string
+	if req.Name != nil {
+		name, err = normalizeName(*req.Name)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+			return
+		}
+	}
+
 	sanitizedID := strings.TrimSpace(req.ExpressionID)
 	expID, err := uuid.Parse(sanitizedID)
 	if err != nil {
@@ -278,13 +606,59 @@ func (c *Controller) Update(ctx *gin.Context) {
 		return
 	}
 
-	updateExpArgs := expstore.UpdateExpressionParams{
-		ExpressionID: gotExp.ExpressionID,
-		Expression:   sanitizedExp,
-		UpdatedAt:    time.Now(),
+	expectedVersion, ok := ifMatch(ctx, gotExp)
+	if !ok {
+		return
+	}
+
+	// tags and name are kept unless the request replaces them
+	if req.Tags == nil {
+		tags = gotExp.Tags
+	}
+	if req.Name == nil {
+		name = gotExp.Name
+	}
+
+	if name != gotExp.Name && gotExp.Name != "" {
+		exps, err := c.store.ListExpressionsByUsername(ctx, expstore.ListExpressionsByUsernameParams{Username: gotExp.Username})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrUpdatingExpression.Error()))
+			return
+		}
+
+		// expressions referencing the current name would no longer resolve
+		if dependents := c.nameDependents(exps, gotExp.Name); len(dependents) > 0 {
+			c.expressionInUse(ctx, dependents)
+			return
+		}
+	}
+
+	pendingExp := gotExp
+	pendingExp.Expression = sanitizedExp
+	pendingExp.Name = name
+	if _, ok := c.link(ctx, pendingExp, prog, experrors.ErrInvalidExpression); !ok {
+		return
 	}
-	updatedExp, err := c.store.UpdateExpression(ctx, updateExpArgs)
+
+	updateExpArgs := expstore.UpdateExpressionTxParams{
+		UpdateExpressionParams: expstore.UpdateExpressionParams{
+			ExpressionID:    gotExp.ExpressionID,
+			Expression:      sanitizedExp,
+			Name:            name,
+			Tags:            tags,
+			Variables:       variablesOf(prog),
+			UpdatedAt:       time.Now(),
+			ExpectedVersion: expectedVersion,
+		},
+		Author: authPayload.Username,
+	}
+	updatedExp, err := c.store.UpdateExpressionTx(ctx, updateExpArgs)
 	if err != nil {
+		if err == sql.ErrNoRows && expectedVersion != 0 {
+			// another update went through since the expression was retrieved
+			ctx.JSON(http.StatusPreconditionFailed, parse.ErrorAsJSON(experrors.ErrExpressionModified.Error()))
+			return
+		}
 		if err == sql.ErrNoRows {
 			ctx.JSON(
 				http.StatusNotFound,
@@ -292,10 +666,16 @@ func (c *Controller) Update(ctx *gin.Context) {
 			)
 			return
 		}
+		if expstore.IsUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, parse.ErrorAsJSON(fmt.Errorf("%s: %s", experrors.ErrNameTaken, name)))
+			return
+		}
 
 		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrUpdatingExpression.Error()))
 		return
 	}
+	c.invalidate(updatedExp.ExpressionID)
+	c.reindex(ctx, updatedExp)
 
 	var res expmodel.UpdateExpressionResponse
 	err = marshaller.Response(updatedExp, &res)
@@ -307,6 +687,7 @@ func (c *Controller) Update(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header(etagHeader, etag(updatedExp))
 	ctx.JSON(http.StatusOK, res)
 }
 
@@ -321,10 +702,25 @@ func (c *Controller) List(ctx *gin.Context) {
 		return
 	}
 
+	// an empty cursor asks for the first page, so paging by cursor depends on the parameter being sent
+	_, hasCursor := ctx.GetQuery(cursorQueryKey)
 	var isPaginated bool
 	pageID := req.PageID
 	pageSize := req.PageSize
 	switch {
+	case pageID != 0 && hasCursor:
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(experrors.ErrConflictingPagination.Error()),
+		)
+		return
+	case hasCursor && pageSize > 0:
+	case hasCursor:
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(experrors.ErrRequiredCursorPageSize.Error()),
+		)
+		return
 	case pageID == 0 && pageSize == 0:
 	case pageID > 0 && pageSize > 0:
 		isPaginated = true
@@ -336,7 +732,59 @@ func (c *Controller) List(ctx *gin.Context) {
 		return
 	}
 
-	exps, err := c.listExpressions(ctx, isPaginated, pageID, pageSize)
+	params := expstore.ListExpressionsParams{
+		ListFilters: expstore.ListFilters{
+			Username:      strings.TrimSpace(req.Username),
+			CreatedAfter:  nullTime(req.CreatedAfter),
+			CreatedBefore: nullTime(req.CreatedBefore),
+			UpdatedAfter:  nullTime(req.UpdatedAfter),
+			UpdatedBefore: nullTime(req.UpdatedBefore),
+			Contains:      likeEscaper.Replace(req.Contains),
+			Search:        strings.TrimSpace(req.Search),
+			Variable:      strings.TrimSpace(req.Variable),
+		},
+		Sort:       expstore.ListSort(req.Sort),
+		Descending: req.Order == orderDescending,
+	}
+
+	if hasCursor && req.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(req.Cursor)
+		if err != nil {
+			ctx.JSON(
+				http.StatusBadRequest,
+				parse.ErrorAsJSON(experrors.ErrInvalidCursor.Error()),
+			)
+			return
+		}
+		if cursor.Sort != string(listSort(params)) || cursor.Descending != params.Descending {
+			ctx.JSON(
+				http.StatusBadRequest,
+				parse.ErrorAsJSON(experrors.ErrCursorSort.Error()),
+			)
+			return
+		}
+
+		params.After, err = cursorKey(cursor)
+		if err != nil {
+			ctx.JSON(
+				http.StatusBadRequest,
+				parse.ErrorAsJSON(experrors.ErrInvalidCursor.Error()),
+			)
+			return
+		}
+	}
+
+	var exps []expstore.Expressions
+	var nextCursor string
+	var err error
+	switch {
+	case hasCursor:
+		exps, nextCursor, err = c.listExpressionsAfter(ctx, params, pageSize, req.Total)
+	case isPaginated:
+		exps, err = c.listPaginatedExpressions(ctx, params, pageID, pageSize)
+	default:
+		exps, err = c.store.ListExpressions(ctx, params)
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, []expstore.Expressions{})
@@ -357,10 +805,15 @@ func (c *Controller) List(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, exps)
+	if hasCursor {
+		ctx.JSON(http.StatusOK, expmodel.ListExpressionsPageResponse{Items: res, NextCursor: nextCursor})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
 }
 
-// Evaluate handles the request to evaluate an expression.
+// Evaluate handles the request to evaluate an expression with the variable values given as query parameters.
+// The version query parameter evaluates a previous version of the expression instead of the current one.
 func (c *Controller) Evaluate(ctx *gin.Context) {
 	var req expmodel.EvaluateExpressionRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
@@ -368,93 +821,1966 @@ func (c *Controller) Evaluate(ctx *gin.Context) {
 		return
 	}
 
-	sanitizedID := strings.TrimSpace(req.ID)
-	expID, err := uuid.Parse(sanitizedID)
-	if err != nil {
-		ctx.JSON(
-			http.StatusBadRequest,
-			parse.ErrorAsJSON(fmt.Errorf("%s: %s", experrors.ErrInvalidExpressionID, sanitizedID)),
-		)
+	gotExp, ok := c.getExpression(ctx, req.ID)
+	if !ok {
 		return
 	}
 
-	gotExp, err := c.store.GetExpressionByID(ctx, expID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(
-				http.StatusNotFound,
-				parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
-			)
-			return
-		}
+	prog, ok := c.compileRequestedVersion(ctx, gotExp)
+	if !ok {
+		return
+	}
 
-		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrDeletingExpression.Error()))
+	c.evaluateQuery(ctx, gotExp, prog)
+}
+
+// EvaluateByName handles the request to evaluate an expression of the authenticated user, addressed by its name,
+// with the variable values given as query parameters. Like Evaluate, it accepts the version query parameter.
+func (c *Controller) EvaluateByName(ctx *gin.Context) {
+	var req expmodel.EvaluateExpressionByNameRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
 		return
 	}
 
-	evalExp, err := c.getEvalExp(ctx, gotExp.Expression)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression.Error(), err)))
+	gotExp, ok := c.getUserExpressionByName(ctx, req.Name)
+	if !ok {
 		return
 	}
 
-	expResult := c.evaluator.EvalLogicExp(evalExp)
-	res := expmodel.EvaluateExpressionResponse{
-		Result: expResult,
+	prog, ok := c.compileRequestedVersion(ctx, gotExp)
+	if !ok {
+		return
 	}
 
-	ctx.JSON(http.StatusOK, res)
+	c.evaluateQuery(ctx, gotExp, prog)
 }
 
-// getEvalExp is a helper function to replace the given query parameter values for the given expression
-func (c *Controller) getEvalExp(ctx *gin.Context, exp string) (string, error) {
-	qV := ctx.Request.URL.Query()
-	evalExp := exp
-	for k, v := range qV {
-		if k != "" {
-			// ignore multiple values
-			fV := v[0]
-			if fV != "0" && fV != "1" {
-				return "", fmt.Errorf("variable values must be either 0 or 1. received %s: %s", k, fV)
-			}
-
-			lowerK := strings.ToLower(k)
-			evalExp = strings.ReplaceAll(evalExp, lowerK, fV)
+// evaluateQuery evaluates the program of the given expression with the variable values and the options given as
+// query parameters. Explanations reveal the text of the expression, so only its owner can request them.
+func (c *Controller) evaluateQuery(ctx *gin.Context, gotExp expstore.Expressions, prog *eval.Program) {
+	vars, err := bindQueryVariables(ctx, prog)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression.Error(), err)))
+		return
+	}
+
+	mode, err := evaluationMode(ctx, prog.Variables())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)))
+		return
+	}
+
+	explain, err := explainRequested(ctx, prog.Variables())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)))
+		return
+	}
+
+	if explain && !c.isOwner(ctx, gotExp) {
+		return
+	}
+
+	if mode == modeKleene {
+		if explain {
+			ctx.JSON(
+				http.StatusBadRequest,
+				parse.ErrorAsJSON(fmt.Errorf("%s: %s is not supported with %s %s", experrors.ErrBadRequest, explainQueryKey, modeQueryKey, modeKleene)),
+			)
+			return
+		}
+
+		c.evaluateKleene(ctx, prog, vars)
+		return
+	}
+
+	if missing := prog.Missing(vars); len(missing) > 0 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: missing required arguments: %+v", experrors.ErrInvalidEvaluateExpression.Error(), missing)),
+		)
+		return
+	}
+
+	c.evaluate(ctx, prog, vars, explain)
+}
+
+// EvaluateJSON handles the request to evaluate an expression with the variable values given as a JSON object.
+// Every missing or invalid variable, and in strict mode every unknown one, is reported in a single response.
+// Like Evaluate, only the owner of the expression can request an explanation.
+func (c *Controller) EvaluateJSON(ctx *gin.Context) {
+	var req expmodel.EvaluateExpressionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	var body expmodel.EvaluateExpressionBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidArguments, err)))
+		return
+	}
+
+	gotExp, ok := c.getExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	prog, ok := c.compileStored(ctx, gotExp, experrors.ErrInvalidEvaluateExpression)
+	if !ok {
+		return
+	}
+
+	vars, varErrs := prog.Bind(body.Variables, body.Strict)
+	if len(varErrs) > 0 {
+		c.invalidVariables(ctx, varErrs)
+		return
+	}
+
+	explain, err := explainRequested(ctx, nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)))
+		return
+	}
+
+	if explain && !c.isOwner(ctx, gotExp) {
+		return
+	}
+
+	c.evaluate(ctx, prog, vars, explain)
+}
+
+// EvaluateAdHoc handles the request to evaluate an expression that is not stored, with the variable values
+// given as a JSON object. The expression is validated and its references are linked to the expressions of the
+// authenticated user like on Create, so rules can be previewed before saving them.
+func (c *Controller) EvaluateAdHoc(ctx *gin.Context) {
+	var req expmodel.EvaluateAdHocRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidArguments, err)))
+		return
+	}
+
+	prog, ok := c.compileLinked(ctx, req.Expression, experrors.ErrInvalidExpression)
+	if !ok {
+		return
+	}
+
+	vars, varErrs := prog.Bind(req.Variables, req.Strict)
+	if len(varErrs) > 0 {
+		c.invalidVariables(ctx, varErrs)
+		return
+	}
+
+	explain, err := explainRequested(ctx, nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)))
+		return
+	}
+
+	c.evaluate(ctx, prog, vars, explain)
+}
+
+// Match handles the request to find the expressions of the authenticated user, optionally restricted to a tag,
+// that the given variable values make true whatever the values of the variables that are not given. Matching
+// goes through the reverse index of the user's expressions, which is loaded on the first match, kept up to date
+// as expressions change through this instance, and loaded again once its TTL has passed.
+func (c *Controller) Match(ctx *gin.Context) {
+	var req expmodel.MatchExpressionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidArguments, err)))
+		return
+	}
+
+	authPayload, err := extractAuthPayload(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(err),
+		)
+		return
+	}
+
+	vars, varErrs := eval.ParseBindings(req.Variables)
+	if len(varErrs) > 0 {
+		c.invalidVariables(ctx, varErrs)
+		return
+	}
+
+	if !c.matcher.Loaded(authPayload.Username) {
+		if err := c.loadMatcher(ctx, authPayload.Username); err != nil {
+			ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRetrievingExpression.Error()))
+			return
+		}
+	}
+
+	res := expmodel.MatchExpressionsResponse{
+		ExpressionIDs: c.matcher.Match(authPayload.Username, strings.TrimSpace(req.Tag), vars),
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// loadMatcher indexes every expression of the user.
+func (c *Controller) loadMatcher(ctx *gin.Context, username string) error {
+	exps, err := c.store.ListExpressionsByUsername(ctx, expstore.ListExpressionsByUsernameParams{Username: username})
+	if err != nil {
+		return err
+	}
+
+	known := make(map[uuid.UUID]expstore.Expressions, len(exps))
+	for _, exp := range exps {
+		known[exp.ExpressionID] = exp
+	}
+
+	entries := make([]eval.MatchEntry, 0, len(exps))
+	for _, exp := range exps {
+		prog, err := c.linkedProgram(ctx, exp, known)
+		if err != nil {
+			// expressions are validated when stored, a row that no longer compiles or links cannot match
+			continue
+		}
+		entries = append(entries, matchEntry(exp, prog))
+	}
+	c.matcher.Load(username, entries)
+
+	return nil
+}
+
+// index keeps the reverse index in sync with a created expression. Users who never matched are not indexed,
+// their expressions are loaded on their first match.
+func (c *Controller) index(ctx context.Context, exp expstore.Expressions) {
+	if !c.matcher.Loaded(exp.Username) {
+		return
+	}
+
+	prog, err := c.linkedProgram(ctx, exp, nil)
+	if err != nil {
+		c.matcher.Remove(exp.ExpressionID)
+		return
+	}
+	c.matcher.Put(matchEntry(exp, prog))
+}
+
+// reindex keeps the reverse index in sync with an updated expression and with the expressions that reference it,
+// whose linked programs include it. When the expressions of the user cannot be listed, the index of the user is
+// dropped and loaded again on the next match.
+func (c *Controller) reindex(ctx context.Context, exp expstore.Expressions) {
+	if !c.matcher.Loaded(exp.Username) {
+		return
+	}
+
+	exps, err := c.store.ListExpressionsByUsername(ctx, expstore.ListExpressionsByUsernameParams{Username: exp.Username})
+	if err != nil {
+		c.matcher.Unload(exp.Username)
+		return
+	}
+
+	known := make(map[uuid.UUID]expstore.Expressions, len(exps))
+	for _, listed := range exps {
+		known[listed.ExpressionID] = listed
+	}
+	known[exp.ExpressionID] = exp
+
+	for _, indexed := range append([]expstore.Expressions{exp}, c.dependents(exps, exp, true)...) {
+		prog, err := c.linkedProgram(ctx, indexed, known)
+		if err != nil {
+			c.matcher.Remove(indexed.ExpressionID)
+			continue
+		}
+		c.matcher.Put(matchEntry(indexed, prog))
+	}
+}
+
+func matchEntry(exp expstore.Expressions, prog *eval.Program) eval.MatchEntry {
+	return eval.MatchEntry{
+		ID:      exp.ExpressionID,
+		Owner:   exp.Username,
+		Tags:    exp.Tags,
+		Order:   exp.RowID,
+		Program: prog,
+	}
+}
+
+// EvaluateBatch handles the request to evaluate an expression against many sets of variable values, sent as
+// a JSON array of objects or as NDJSON. The expression is compiled once and the results keep the order of the
+// input. Items whose variables cannot be bound or that cannot be evaluated get their own error without failing
+// the rest of the batch. The body is read and evaluated within the batch timeout and size limit before any
+// result is written.
+func (c *Controller) EvaluateBatch(ctx *gin.Context) {
+	var req expmodel.EvaluateExpressionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	strict := false
+	if raw, ok := ctx.GetQuery(strictQueryKey); ok {
+		var err error
+		strict, err = strconv.ParseBool(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: invalid %s value: %s", experrors.ErrBadRequest, strictQueryKey, raw)))
+			return
+		}
+	}
+
+	prog, ok := c.compileExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), c.batchTimeout)
+	defer cancel()
+
+	ndjson := ctx.ContentType() == mimeNDJSON
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxBatchBytes)
+	items, err := c.readBatch(timeoutCtx, deadlineReader{ctx: timeoutCtx, r: body}, ndjson)
+	if err != nil {
+		c.batchFailed(ctx, err)
+		return
+	}
+
+	results := make([]expmodel.EvaluateBatchResult, len(items))
+	batch := make([]eval.Bindings, 0, len(items))
+	bound := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].Index = i
+
+		vars, varErrs := prog.Bind(item, strict)
+		if len(varErrs) > 0 {
+			results[i].Error = experrors.ErrInvalidVariables.String()
+			if err := marshaller.Response(varErrs, &results[i].Variables); err != nil {
+				ctx.JSON(
+					http.StatusInternalServerError,
+					parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+				)
+				return
+			}
+			continue
+		}
+
+		batch = append(batch, vars)
+		bound = append(bound, i)
+	}
+
+	values, err := c.runner.EvalBatch(timeoutCtx, prog, batch)
+	if err != nil {
+		c.batchFailed(ctx, err)
+		return
+	}
+	for j, i := range bound {
+		if values[j].Err != nil {
+			results[i].Error = fmt.Sprintf("%s: %v", experrors.ErrInvalidEvaluateExpression, values[j].Err)
+			continue
+		}
+		value := values[j].Value
+		results[i].Result = &value
+	}
+
+	if ndjson {
+		ctx.Header("Content-Type", mimeNDJSON)
+		ctx.Status(http.StatusOK)
+		enc := json.NewEncoder(ctx.Writer)
+		for _, res := range results {
+			if err := enc.Encode(res); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, expmodel.EvaluateBatchResponse{Results: results})
+}
+
+// readBatch decodes the variable sets of a batch evaluation, either from a JSON array or from NDJSON, where
+// every non-blank line is an object. It fails as soon as the batch exceeds the maximum size or ctx is done.
+func (c *Controller) readBatch(ctx context.Context, body io.Reader, ndjson bool) ([]map[string]interface{}, error) {
+	var items []map[string]interface{}
+	add := func(item map[string]interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(items) == c.maxBatchSize {
+			return fmt.Errorf("%w: more than %d items", errBatchTooLarge, c.maxBatchSize)
+		}
+		items = append(items, item)
+		return nil
+	}
+
+	if ndjson {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxNDJSONLineSize)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+
+			var item map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", errMalformedBatch, line, err)
+			}
+			if err := add(item); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			if readFailed(err) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %v", errMalformedBatch, err)
+		}
+
+		return items, nil
+	}
+
+	dec := json.NewDecoder(body)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		if readFailed(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: expected a JSON array of variable objects", errMalformedBatch)
+	}
+	for dec.More() {
+		var item map[string]interface{}
+		if err := dec.Decode(&item); err != nil {
+			if readFailed(err) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: item %d: %v", errMalformedBatch, len(items), err)
+		}
+		if err := add(item); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		if readFailed(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errMalformedBatch, err)
+	}
+
+	return items, nil
+}
+
+// readFailed reports whether err comes from reading the body of a batch rather than from its content, because
+// the body is too large or the batch timed out.
+func readFailed(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// deadlineReader reads from r until ctx is done. Reads blocked on a client that stopped sending return as soon as
+// ctx is done, so the read of a body is bounded by the deadline of ctx.
+type deadlineReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (d deadlineReader) Read(p []byte) (int, error) {
+	if err := d.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := d.r.Read(p)
+		done <- result{n: n, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.n, res.err
+	case <-d.ctx.Done():
+		// the pending read ends when the server closes the body once the handler returns
+		return 0, d.ctx.Err()
+	}
+}
+
+// batchFailed writes the error response of a batch evaluation that could not be completed.
+func (c *Controller) batchFailed(ctx *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		ctx.JSON(http.StatusRequestEntityTooLarge, parse.ErrorAsJSON(fmt.Errorf("%s: %v: body larger than %d bytes", experrors.ErrInvalidArguments, errBatchTooLarge, c.maxBatchBytes)))
+	case errors.Is(err, errBatchTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidArguments, err)))
+	case errors.Is(err, errMalformedBatch):
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidArguments, err)))
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.JSON(http.StatusServiceUnavailable, parse.ErrorAsJSON(fmt.Errorf("%s: timed out after %s", experrors.ErrInvalidEvaluateExpression, c.batchTimeout)))
+	default:
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression, err)))
+	}
+}
+
+// compileExpression retrieves the expression with the given raw ID and compiles it through the cache.
+// On failure the error response is written to ctx and false is returned.
+func (c *Controller) compileExpression(ctx *gin.Context, rawID string) (*eval.Program, bool) {
+	gotExp, ok := c.getExpression(ctx, rawID)
+	if !ok {
+		return nil, false
+	}
+
+	return c.compileStored(ctx, gotExp, experrors.ErrInvalidEvaluateExpression)
+}
+
+// getExpression retrieves the expression with the given raw ID, whichever user it belongs to. On failure the
+// error response is written to ctx and false is returned.
+func (c *Controller) getExpression(ctx *gin.Context, rawID string) (expstore.Expressions, bool) {
+	sanitizedID := strings.TrimSpace(rawID)
+	expID, err := uuid.Parse(sanitizedID)
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %s", experrors.ErrInvalidExpressionID, sanitizedID)),
+		)
+		return expstore.Expressions{}, false
+	}
+
+	gotExp, err := c.store.GetExpressionByID(ctx, expID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(
+				http.StatusNotFound,
+				parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
+			)
+			return expstore.Expressions{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRetrievingExpression.Error()))
+		return expstore.Expressions{}, false
+	}
+
+	return gotExp, true
+}
+
+// compileRequestedVersion compiles the given stored expression, or the version of it requested by the version
+// query parameter. An expression variable named version takes precedence over the parameter, and only the owner of
+// the expression can request previous versions. On failure the error response is written to ctx and false is
+// returned.
+func (c *Controller) compileRequestedVersion(ctx *gin.Context, gotExp expstore.Expressions) (*eval.Program, bool) {
+	prog, ok := c.compileStored(ctx, gotExp, experrors.ErrInvalidEvaluateExpression)
+	if !ok {
+		return nil, false
+	}
+
+	raw, ok := controlQuery(ctx, versionQueryKey, prog.Variables())
+	if !ok {
+		return prog, true
+	}
+
+	version, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 32)
+	if err != nil || version < 1 {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: invalid %s value: %s", experrors.ErrBadRequest, versionQueryKey, raw)))
+		return nil, false
+	}
+
+	if !c.isOwner(ctx, gotExp) {
+		return nil, false
+	}
+
+	gotVersion, ok := c.getVersion(ctx, gotExp.ExpressionID, int32(version))
+	if !ok {
+		return nil, false
+	}
+
+	// the cache only holds the current version of each expression, so previous versions are compiled every time
+	prog, err = c.compiler.Compile(gotVersion.Expression)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression.Error(), err)))
+		return nil, false
+	}
+
+	// references resolve to the current version of the referenced expressions
+	pinnedExp := gotExp
+	pinnedExp.Expression = gotVersion.Expression
+	return c.link(ctx, pinnedExp, prog, experrors.ErrInvalidEvaluateExpression)
+}
+
+// compileStored compiles the given stored expression through the cache and links it, reusing the program linked
+// for its revision when there is one. Problems are reported under the given error. On failure the error response
+// is written to ctx and false is returned.
+func (c *Controller) compileStored(
+	ctx *gin.Context,
+	gotExp expstore.Expressions,
+	expErr experrors.ExpressionError,
+) (*eval.Program, bool) {
+	cacheKey := eval.CacheKey{ID: gotExp.ExpressionID, UpdatedAt: gotExp.UpdatedAt}
+	if linked, ok := c.links.Get(cacheKey); ok {
+		return linked, true
+	}
+
+	prog, err := c.compiler.CompileCached(cacheKey, gotExp.Expression)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", expErr, err)))
+		return nil, false
+	}
+
+	linked, err := c.linkStored(ctx, gotExp, prog, map[uuid.UUID]expstore.Expressions{gotExp.ExpressionID: gotExp})
+	if err != nil {
+		c.linkFailed(ctx, err, expErr)
+		return nil, false
+	}
+
+	return linked, true
+}
+
+// evaluate writes the result of the program, along with its explanation when requested.
+func (c *Controller) evaluate(ctx *gin.Context, prog *eval.Program, vars eval.Bindings, explain bool) {
+	if explain {
+		c.explain(ctx, prog, vars)
+		return
+	}
+
+	expResult, err := c.runner.Eval(prog, vars)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression.Error(), err)))
+		return
+	}
+
+	res := expmodel.EvaluateExpressionResponse{
+		Result: expResult,
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// evaluateKleene writes the three-valued result of the program, along with the variables that would decide it
+// when it is unknown.
+func (c *Controller) evaluateKleene(ctx *gin.Context, prog *eval.Program, vars eval.Bindings) {
+	kleene, err := c.runner.EvalKleene(prog, vars)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression.Error(), err)))
+		return
+	}
+
+	var res expmodel.EvaluateKleeneResponse
+	err = marshaller.Response(kleene, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// explain writes the result of the program along with the explanation of how it was reached.
+func (c *Controller) explain(ctx *gin.Context, prog *eval.Program, vars eval.Bindings) {
+	explanation, err := c.runner.Explain(prog, vars)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression.Error(), err)))
+		return
+	}
+
+	res := expmodel.EvaluateExpressionResponse{
+		Result:      explanation.Result,
+		Explanation: &expmodel.ExplanationResponse{},
+	}
+	err = marshaller.Response(explanation, res.Explanation)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// explainRequested reports whether the explain query parameter is set. An expression variable named explain
+// takes precedence over the parameter.
+func explainRequested(ctx *gin.Context, names []string) (bool, error) {
+	raw, ok := controlQuery(ctx, explainQueryKey, names)
+	if !ok {
+		return false, nil
+	}
+
+	explain, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value: %s", explainQueryKey, raw)
+	}
+
+	return explain, nil
+}
+
+// evaluationMode returns the logic requested by the mode query parameter, defaulting to modeBoolean. An expression
+// variable named mode takes precedence over the parameter.
+func evaluationMode(ctx *gin.Context, names []string) (string, error) {
+	raw, ok := controlQuery(ctx, modeQueryKey, names)
+	if !ok {
+		return modeBoolean, nil
+	}
+
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case modeBoolean, modeKleene:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s value: %s, expected %s or %s", modeQueryKey, raw, modeBoolean, modeKleene)
+	}
+}
+
+// controlQuery returns the value of a query parameter that controls the evaluation, unless an expression variable
+// has the same name, in which case the parameter holds the value of that variable.
+func controlQuery(ctx *gin.Context, key string, names []string) (string, bool) {
+	raw, ok := ctx.GetQuery(key)
+	if !ok {
+		return "", false
+	}
+
+	for _, name := range names {
+		if name == key {
+			return "", false
+		}
+	}
+
+	return raw, true
+}
+
+// CacheStats handles the request to retrieve the counters of the compiled expression cache.
+func (c *Controller) CacheStats(ctx *gin.Context) {
+	var res expmodel.CacheStatsResponse
+	err := marshaller.Response(c.compiler.CacheStats(), &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Compare handles the request to check whether two expressions are logically equivalent. Each side is either
+// a stored expression ID or a raw expression.
+func (c *Controller) Compare(ctx *gin.Context) {
+	var req expmodel.CompareExpressionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	left, ok := c.compileOperand(ctx, req.Left, experrors.ErrInvalidLeftOperand)
+	if !ok {
+		return
+	}
+	right, ok := c.compileOperand(ctx, req.Right, experrors.ErrInvalidRightOperand)
+	if !ok {
+		return
+	}
+
+	equivalence, err := c.analyzer.Equivalent(left, right)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrComparingExpressions.Error()))
+		return
+	}
+
+	var res expmodel.CompareExpressionsResponse
+	err = marshaller.Response(equivalence, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// compileOperand compiles one side of a comparison, retrieving it from the store when an expression ID is given.
+// On failure the error response is written to ctx and false is returned.
+func (c *Controller) compileOperand(
+	ctx *gin.Context,
+	operand expmodel.CompareOperand,
+	operandErr experrors.ExpressionError,
+) (*eval.Program, bool) {
+	hasID := strings.TrimSpace(operand.ExpressionID) != ""
+	hasExp := strings.TrimSpace(operand.Expression) != ""
+	if hasID == hasExp {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %s", operandErr, experrors.ErrRequiredCompareOperand)),
+		)
+		return nil, false
+	}
+
+	if hasExp {
+		return c.compileLinked(ctx, operand.Expression, operandErr)
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, operand.ExpressionID)
+	if !ok {
+		return nil, false
+	}
+
+	return c.compileStored(ctx, gotExp, operandErr)
+}
+
+// Simplify handles the request to simplify an expression. The simplified form replaces the stored expression
+// when the save flag is set and the expression changed. References are not linked but simplified like variables,
+// so that the simplified form keeps them.
+func (c *Controller) Simplify(ctx *gin.Context) {
+	var req expmodel.SimplifyExpressionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	var opts expmodel.SimplifyExpressionOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)),
+		)
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	var expectedVersion int32
+	if opts.Save {
+		expectedVersion, ok = ifMatch(ctx, gotExp)
+		if !ok {
+			return
+		}
+	}
+
+	cacheKey := eval.CacheKey{ID: gotExp.ExpressionID, UpdatedAt: gotExp.UpdatedAt}
+	prog, err := c.compiler.CompileCached(cacheKey, gotExp.Expression)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInvalidEvaluateExpression.Error(), err)))
+		return
+	}
+
+	simplified, err := c.analyzer.Simplify(prog, eval.SimplifyOptions{Minimize: opts.Minimize})
+	if err != nil {
+		if errors.Is(err, eval.ErrTooManyVariables) {
+			ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrSimplifyingExpression.Error(), err)))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrSimplifyingExpression.Error()))
+		return
+	}
+
+	res := expmodel.SimplifyExpressionResponse{
+		ExpressionID: gotExp.ExpressionID,
+		Original:     gotExp.Expression,
+		Simplified:   eval.Format(simplified.Root()),
+	}
+	// both sides are formatted, so that an expression only differing from its simplified form in whitespace or
+	// redundant parentheses is unchanged
+	res.Changed = res.Simplified != eval.Format(prog.Root())
+
+	updatedExp := gotExp
+	if opts.Save && res.Changed {
+		updateExpArgs := expstore.UpdateExpressionTxParams{
+			UpdateExpressionParams: expstore.UpdateExpressionParams{
+				ExpressionID:    gotExp.ExpressionID,
+				Expression:      res.Simplified,
+				Name:            gotExp.Name,
+				Tags:            gotExp.Tags,
+				Variables:       variablesOf(simplified),
+				UpdatedAt:       time.Now(),
+				ExpectedVersion: expectedVersion,
+			},
+			Author: gotExp.Username,
+		}
+		updatedExp, err = c.store.UpdateExpressionTx(ctx, updateExpArgs)
+		if err != nil {
+			if err == sql.ErrNoRows && expectedVersion != 0 {
+				ctx.JSON(http.StatusPreconditionFailed, parse.ErrorAsJSON(experrors.ErrExpressionModified.Error()))
+				return
+			}
+			if err == sql.ErrNoRows {
+				ctx.JSON(
+					http.StatusNotFound,
+					parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
+				)
+				return
+			}
+
+			ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrUpdatingExpression.Error()))
+			return
+		}
+		c.invalidate(updatedExp.ExpressionID)
+		c.reindex(ctx, updatedExp)
+		res.Saved = true
+	}
+
+	ctx.Header(etagHeader, etag(updatedExp))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// NormalForm handles the request to convert an expression to conjunctive or disjunctive normal form.
+func (c *Controller) NormalForm(ctx *gin.Context) {
+	var req expmodel.NormalFormRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	var opts expmodel.NormalFormOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)),
+		)
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	prog, ok := c.compileStored(ctx, gotExp, experrors.ErrInvalidEvaluateExpression)
+	if !ok {
+		return
+	}
+
+	nf, err := c.analyzer.NormalForm(prog, eval.NormalFormOptions{
+		Form:    strings.ToLower(opts.Form),
+		Tseitin: opts.Tseitin,
+	})
+	if err != nil {
+		if errors.Is(err, eval.ErrUnsupportedForm) || errors.Is(err, eval.ErrNormalFormTooLarge) {
+			ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrConvertingExpression.Error(), err)))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrConvertingExpression.Error()))
+		return
+	}
+
+	var res expmodel.NormalFormResponse
+	err = marshaller.Response(nf, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+	res.ExpressionID = gotExp.ExpressionID
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// TruthTable handles the request to generate the truth table of an expression. The table is returned as JSON
+// by default, or as CSV when the request accepts text/csv.
+func (c *Controller) TruthTable(ctx *gin.Context) {
+	var req expmodel.TruthTableRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	prog, ok := c.compileStored(ctx, gotExp, experrors.ErrInvalidEvaluateExpression)
+	if !ok {
+		return
+	}
+
+	table, err := c.analyzer.TruthTable(prog)
+	if err != nil {
+		if errors.Is(err, eval.ErrTooManyVariables) {
+			ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrGeneratingTruthTable.Error(), err)))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrGeneratingTruthTable.Error()))
+		return
+	}
+
+	if ctx.NegotiateFormat(gin.MIMEJSON, mimeCSV) == mimeCSV {
+		writeTruthTableCSV(ctx, table)
+		return
+	}
+
+	var res expmodel.TruthTableResponse
+	err = marshaller.Response(table, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Satisfiable handles the request to check whether an expression can ever evaluate to true.
+func (c *Controller) Satisfiable(ctx *gin.Context) {
+	var req expmodel.SatisfiableRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	prog, ok := c.compileStored(ctx, gotExp, experrors.ErrInvalidEvaluateExpression)
+	if !ok {
+		return
+	}
+
+	sat, err := c.analyzer.Satisfiable(prog)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrCheckingSatisfiability.Error()))
+		return
+	}
+
+	var res expmodel.SatisfiableResponse
+	err = marshaller.Response(sat, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Dependents handles the request to list the expressions of the authenticated user that reference an expression,
+// directly or, with the transitive flag, through other expressions.
+func (c *Controller) Dependents(ctx *gin.Context) {
+	var req expmodel.DependentsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	var opts expmodel.DependentsOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)),
+		)
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	exps, err := c.store.ListExpressionsByUsername(ctx, expstore.ListExpressionsByUsernameParams{Username: gotExp.Username})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrListingDependents.Error()))
+		return
+	}
+
+	res := expmodel.DependentsResponse{
+		ExpressionID: gotExp.ExpressionID,
+		Dependents:   []expmodel.ListExpressionsResponse{},
+	}
+	err = marshaller.Response(c.dependents(exps, gotExp, opts.Transitive), &res.Dependents)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Versions handles the request to list the versions of an expression of the authenticated user, oldest first.
+func (c *Controller) Versions(ctx *gin.Context) {
+	var req expmodel.ExpressionVersionsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	versions, err := c.store.ListExpressionVersions(ctx, gotExp.ExpressionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrListingVersions.Error()))
+		return
+	}
+
+	res := []expmodel.ExpressionVersionResponse{}
+	err = marshaller.Response(versions, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Version handles the request to retrieve a version of an expression of the authenticated user.
+func (c *Controller) Version(ctx *gin.Context) {
+	var req expmodel.ExpressionVersionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	gotVersion, ok := c.getVersion(ctx, gotExp.ExpressionID, req.Version)
+	if !ok {
+		return
+	}
+
+	var res expmodel.ExpressionVersionResponse
+	err := marshaller.Response(gotVersion, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DiffVersions handles the request to compare two versions of an expression of the authenticated user, token by
+// token.
+func (c *Controller) DiffVersions(ctx *gin.Context) {
+	var req expmodel.DiffVersionsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	var opts expmodel.DiffVersionsOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrBadRequest, err)),
+		)
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	from, ok := c.getVersion(ctx, gotExp.ExpressionID, opts.From)
+	if !ok {
+		return
+	}
+	to, ok := c.getVersion(ctx, gotExp.ExpressionID, opts.To)
+	if !ok {
+		return
+	}
+
+	res := expmodel.DiffVersionsResponse{
+		ExpressionID: gotExp.ExpressionID,
+		From:         from.Version,
+		To:           to.Version,
+		Chunks:       []expmodel.DiffChunkResponse{},
+	}
+	err := marshaller.Response(eval.Diff(from.Expression, to.Expression), &res.Chunks)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Rollback handles the request to restore a previous version of an expression of the authenticated user. The
+// restored text is recorded as a new version, so that the history is never rewritten, and is validated like an
+// update since the expressions it references may have changed.
+func (c *Controller) Rollback(ctx *gin.Context) {
+	var req expmodel.RollbackExpressionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	var body expmodel.RollbackExpressionBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(err))
+		return
+	}
+
+	gotExp, ok := c.getUserExpression(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	expectedVersion, ok := ifMatch(ctx, gotExp)
+	if !ok {
+		return
+	}
+
+	gotVersion, ok := c.getVersion(ctx, gotExp.ExpressionID, body.Version)
+	if !ok {
+		return
+	}
+
+	updatedExp := gotExp
+	if gotVersion.Expression != gotExp.Expression {
+		prog, ok := c.compileRaw(ctx, gotVersion.Expression, experrors.ErrInvalidExpression)
+		if !ok {
+			return
+		}
+
+		pendingExp := gotExp
+		pendingExp.Expression = gotVersion.Expression
+		if _, ok := c.link(ctx, pendingExp, prog, experrors.ErrInvalidExpression); !ok {
+			return
+		}
+
+		updateExpArgs := expstore.UpdateExpressionTxParams{
+			UpdateExpressionParams: expstore.UpdateExpressionParams{
+				ExpressionID:    gotExp.ExpressionID,
+				Expression:      gotVersion.Expression,
+				Name:            gotExp.Name,
+				Tags:            gotExp.Tags,
+				Variables:       variablesOf(prog),
+				UpdatedAt:       time.Now(),
+				ExpectedVersion: expectedVersion,
+			},
+			Author: gotExp.Username,
+		}
+		var err error
+		updatedExp, err = c.store.UpdateExpressionTx(ctx, updateExpArgs)
+		if err != nil {
+			if err == sql.ErrNoRows && expectedVersion != 0 {
+				ctx.JSON(http.StatusPreconditionFailed, parse.ErrorAsJSON(experrors.ErrExpressionModified.Error()))
+				return
+			}
+			if err == sql.ErrNoRows {
+				ctx.JSON(
+					http.StatusNotFound,
+					parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
+				)
+				return
+			}
+
+			ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRollingBackExpression.Error()))
+			return
+		}
+		c.invalidate(updatedExp.ExpressionID)
+		c.reindex(ctx, updatedExp)
+	}
+
+	var res expmodel.UpdateExpressionResponse
+	err := marshaller.Response(updatedExp, &res)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
+
+	ctx.Header(etagHeader, etag(updatedExp))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// writeTruthTableCSV writes the truth table as CSV, with one column per variable followed by the result column.
+// Values are written as 0 and 1, matching the literals of the expression language.
+func writeTruthTableCSV(ctx *gin.Context, table eval.TruthTable) {
+	ctx.Status(http.StatusOK)
+	ctx.Header("Content-Type", mimeCSV)
+
+	w := csv.NewWriter(ctx.Writer)
+	_ = w.Write(append(append([]string{}, table.Variables...), "result"))
+	for _, row := range table.Rows {
+		record := make([]string, 0, len(row.Values)+1)
+		for _, v := range row.Values {
+			record = append(record, boolDigit(v))
 		}
+		_ = w.Write(append(record, boolDigit(row.Result)))
+	}
+	w.Flush()
+}
+
+// boolDigit returns the 0 or 1 literal of a boolean value.
+func boolDigit(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+// bindQueryVariables is a helper function to bind the query parameter values to the variables of the given program.
+// Query keys are matched exactly, falling back to their lowercase form so that requests written for the
+// former single lowercase letter variables keep working. Boolean variables take 0 or 1, numbers and strings are
+// converted to the type the expression compares them with, and repeating a compared variable sends a list.
+func bindQueryVariables(ctx *gin.Context, prog *eval.Program) (eval.Bindings, error) {
+	used := make(map[string]struct{}, len(prog.Variables()))
+	for _, name := range prog.Variables() {
+		used[name] = struct{}{}
 	}
 
-	lowers, hasLowers := str.HasLowers(evalExp)
-	if hasLowers {
-		return "", fmt.Errorf("missing required arguments: %+v", lowers)
+	vars := make(eval.Bindings, len(used))
+	for k, v := range ctx.Request.URL.Query() {
+		name := k
+		if _, ok := used[name]; !ok {
+			name = strings.ToLower(k)
+			if _, ok := used[name]; !ok {
+				continue
+			}
+		}
+
+		if prog.Type(name) != eval.TypeBool {
+			var raw interface{} = v[0]
+			if len(v) > 1 {
+				list := make([]interface{}, 0, len(v))
+				for _, item := range v {
+					list = append(list, item)
+				}
+				raw = list
+			}
+
+			value, varErr := prog.Convert(name, raw)
+			if varErr != nil {
+				return nil, fmt.Errorf("variable %s: %s", k, varErr.Message)
+			}
+			vars[name] = value
+			continue
+		}
+
+		// ignore multiple values
+		fV := v[0]
+		if fV != "0" && fV != "1" {
+			return nil, fmt.Errorf("variable values must be either 0 or 1. received %s: %s", k, fV)
+		}
+
+		vars[name] = eval.Bool(fV == "1")
 	}
 
-	return evalExp, nil
+	return vars, nil
 }
 
-func (c *Controller) listExpressions(
+// listPaginatedExpressions lists the page of expressions with the given ID and sets the pagination headers.
+func (c *Controller) listPaginatedExpressions(
 	ctx *gin.Context,
-	isPaginated bool,
+	params expstore.ListExpressionsParams,
 	pageID,
 	pageSize int32,
 ) ([]expstore.Expressions, error) {
-	totalExps, err := c.store.ListExpressions(ctx)
+	total, err := c.store.CountExpressions(ctx, params.ListFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	pgData := pagination.GetData(int(total), pageID, pageSize)
+	ginmidctx.SetPaginationHeader(ctx, pgData)
+
+	params.Limit = pageSize
+	params.Offset = pageSize * (pageID - 1)
+	return c.store.ListExpressions(ctx, params)
+}
+
+// listExpressionsAfter lists the page of expressions that follows the key of the list, or the first page when it
+// has none. The cursor of the next page is returned, empty on the last page, and set in the pagination headers
+// along with the totals when requested.
+func (c *Controller) listExpressionsAfter(
+	ctx *gin.Context,
+	params expstore.ListExpressionsParams,
+	pageSize int32,
+	withTotal bool,
+) ([]expstore.Expressions, string, error) {
+	var pgData pagination.Pagination
+	if withTotal {
+		total, err := c.store.CountExpressions(ctx, params.ListFilters)
+		if err != nil {
+			return nil, "", err
+		}
+
+		pgData = pagination.GetData(int(total), 0, pageSize)
+		pgData.Page = ""
+	}
+
+	// one more row than the page size tells whether there is a next page
+	params.Limit = pageSize
+	if params.Limit < math.MaxInt32 {
+		params.Limit++
+	}
+
+	exps, err := c.store.ListExpressions(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if int32(len(exps)) > pageSize {
+		exps = exps[:pageSize]
+		pgData.NextCursor = listCursor(params, exps[len(exps)-1]).Encode()
+		ctx.Header(linkHeader, nextPageLink(ctx, pgData.NextCursor))
+	}
+	ginmidctx.SetPaginationHeader(ctx, pgData)
+
+	return exps, pgData.NextCursor, nil
+}
+
+// listCursor returns the cursor pointing at the given expression in the list: the value of the sort column of the
+// expression and its row ID.
+func listCursor(params expstore.ListExpressionsParams, exp expstore.Expressions) pagination.Cursor {
+	sort := listSort(params)
+
+	var key string
+	switch sort {
+	case expstore.SortUpdatedAt:
+		key = pagination.TimeKey(exp.UpdatedAt)
+	case expstore.SortName:
+		key = exp.Name
+	default:
+		key = pagination.TimeKey(exp.CreatedAt)
+	}
+
+	return pagination.Cursor{Sort: string(sort), Descending: params.Descending, Key: key, RowID: exp.RowID}
+}
+
+// listSort returns the column the list is sorted by, created_at unless another one was requested.
+func listSort(params expstore.ListExpressionsParams) expstore.ListSort {
+	if params.Sort == "" {
+		return expstore.SortCreatedAt
+	}
+	return params.Sort
+}
+
+// cursorKey returns the position in the list the cursor points at, parsing its key as the type of its sort column.
+func cursorKey(cursor pagination.Cursor) (*expstore.ListKey, error) {
+	if expstore.ListSort(cursor.Sort) == expstore.SortName {
+		return &expstore.ListKey{Value: cursor.Key, RowID: cursor.RowID}, nil
+	}
+
+	t, err := pagination.ParseTimeKey(cursor.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expstore.ListKey{Value: t, RowID: cursor.RowID}, nil
+}
+
+// nextPageLink returns the Link header value pointing at the page that starts after the given cursor, keeping the
+// other query parameters of the request.
+func nextPageLink(ctx *gin.Context, cursor string) string {
+	next := *ctx.Request.URL
+	query := next.Query()
+	query.Set(cursorQueryKey, cursor)
+	next.RawQuery = query.Encode()
+
+	return fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI())
+}
+
+// getUserExpression retrieves the expression with the given raw ID, making sure it belongs to the authenticated user.
+// On failure the error response is written to ctx and false is returned.
+func (c *Controller) getUserExpression(ctx *gin.Context, rawID string) (expstore.Expressions, bool) {
+	sanitizedID := strings.TrimSpace(rawID)
+	expID, err := uuid.Parse(sanitizedID)
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %s", experrors.ErrInvalidExpressionID, sanitizedID)),
+		)
+		return expstore.Expressions{}, false
+	}
+
+	gotExp, err := c.store.GetExpressionByID(ctx, expID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(
+				http.StatusNotFound,
+				parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
+			)
+			return expstore.Expressions{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRetrievingExpression.Error()))
+		return expstore.Expressions{}, false
+	}
+
+	if !c.isOwner(ctx, gotExp) {
+		return expstore.Expressions{}, false
+	}
+
+	return gotExp, true
+}
+
+// isOwner reports whether the expression belongs to the authenticated user. Expressions of other users are reported
+// as not found, so the error response is written to ctx when false is returned.
+func (c *Controller) isOwner(ctx *gin.Context, gotExp expstore.Expressions) bool {
+	authPayload, err := extractAuthPayload(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(err),
+		)
+		return false
+	}
+
+	if gotExp.Username != authPayload.Username {
+		ctx.JSON(
+			http.StatusNotFound,
+			parse.ErrorAsJSON(fmt.Errorf("expression %s not found for user %s (%s)", gotExp.ExpressionID, authPayload.Username, authPayload.UserID)),
+		)
+		return false
+	}
+
+	return true
+}
+
+// variablesOf returns the variables of the program as stored along with its expression, which is never nil.
+func variablesOf(prog *eval.Program) []string {
+	if vars := prog.Variables(); vars != nil {
+		return vars
+	}
+
+	return []string{}
+}
+
+// nullTime returns t as a nullable time, null when t is the zero time.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// etag formats the row version of an expression as a strong entity tag.
+func etag(exp expstore.Expressions) string {
+	return fmt.Sprintf(`"%d"`, exp.Version)
+}
+
+// ifMatch checks the If-Match header of the request against the entity tag of the expression. It returns the
+// version the update must still find in the store, or 0 when the request has no precondition. When no entity tag
+// matches, a 412 response carrying the current entity tag is written to ctx and false is returned.
+func ifMatch(ctx *gin.Context, exp expstore.Expressions) (int32, bool) {
+	header := strings.TrimSpace(ctx.GetHeader(ifMatchHeader))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	current := etag(exp)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == current {
+			return exp.Version, true
+		}
+	}
+
+	ctx.Header(etagHeader, current)
+	ctx.JSON(http.StatusPreconditionFailed, parse.ErrorAsJSON(experrors.ErrExpressionModified.Error()))
+	return 0, false
+}
+
+// getUserExpressionByName retrieves the expression of the authenticated user with the given name. Names are unique
+// per user, so expressions of other users are never found. On failure the error response is written to ctx and
+// false is returned.
+func (c *Controller) getUserExpressionByName(ctx *gin.Context, rawName string) (expstore.Expressions, bool) {
+	authPayload, err := extractAuthPayload(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(err),
+		)
+		return expstore.Expressions{}, false
+	}
+
+	args := expstore.GetExpressionByNameParams{Username: authPayload.Username, Name: strings.TrimSpace(rawName)}
+	gotExp, err := c.store.GetExpressionByName(ctx, args)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(
+				http.StatusNotFound,
+				parse.ErrorAsJSON(experrors.ErrRecordNotFound.Error()),
+			)
+			return expstore.Expressions{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRetrievingExpression.Error()))
+		return expstore.Expressions{}, false
+	}
+
+	return gotExp, true
+}
+
+// getVersion retrieves the given version of an expression. On failure the error response is written to ctx and
+// false is returned.
+func (c *Controller) getVersion(ctx *gin.Context, expID uuid.UUID, version int32) (expstore.ExpressionVersions, bool) {
+	args := expstore.GetExpressionVersionParams{ExpressionID: expID, Version: version}
+	gotVersion, err := c.store.GetExpressionVersion(ctx, args)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(
+				http.StatusNotFound,
+				parse.ErrorAsJSON(fmt.Errorf("%s: %d", experrors.ErrVersionNotFound, version)),
+			)
+			return expstore.ExpressionVersions{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRetrievingVersion.Error()))
+		return expstore.ExpressionVersions{}, false
+	}
+
+	return gotVersion, true
+}
+
+// compileRaw validates and compiles an expression that is not stored, reporting syntax problems with the same
+// diagnostics as Create under the given error. On failure the error response is written to ctx and false is returned.
+func (c *Controller) compileRaw(ctx *gin.Context, exp string, expErr experrors.ExpressionError) (*eval.Program, bool) {
+	if diags := c.compiler.Validate(exp); len(diags) > 0 {
+		c.invalidExpression(ctx, expErr, diags)
+		return nil, false
+	}
+
+	prog, err := c.compiler.Compile(exp)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", expErr, err)))
+		return nil, false
+	}
+
+	return prog, true
+}
+
+// compileLinked compiles an expression that is not stored like compileRaw, and links its references to the
+// expressions of the authenticated user. On failure the error response is written to ctx and false is returned.
+func (c *Controller) compileLinked(ctx *gin.Context, exp string, expErr experrors.ExpressionError) (*eval.Program, bool) {
+	prog, ok := c.compileRaw(ctx, exp, expErr)
+	if !ok {
+		return nil, false
+	}
+
+	authPayload, err := extractAuthPayload(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(err))
+		return nil, false
+	}
+
+	return c.link(ctx, expstore.Expressions{Expression: exp, Username: authPayload.Username}, prog, expErr)
+}
+
+// link resolves the references of the program compiled from the given expression to the other expressions of its
+// owner. The expression itself is known under its given ID and name, so that references back to it are cycles even
+// before it is stored. Unknown references, cycles and conflicting types are reported under the given error. On
+// failure the error response is written to ctx and false is returned.
+func (c *Controller) link(
+	ctx *gin.Context,
+	exp expstore.Expressions,
+	prog *eval.Program,
+	expErr experrors.ExpressionError,
+) (*eval.Program, bool) {
+	linked, _, err := c.linkProgram(ctx, exp, prog, map[uuid.UUID]expstore.Expressions{exp.ExpressionID: exp})
+	if err != nil {
+		c.linkFailed(ctx, err, expErr)
+		return nil, false
+	}
+
+	return linked, true
+}
+
+// invalidate drops the compiled program of the given expression and the linked programs that include it. It must
+// be called whenever a stored expression is updated or deleted.
+func (c *Controller) invalidate(id uuid.UUID) {
+	c.compiler.Invalidate(id)
+	c.links.Invalidate(id)
+}
+
+// linkFailed writes the response of a program that could not be linked. Unknown references, cycles and conflicting
+// types are reported under the given error, failures of the store as internal errors.
+func (c *Controller) linkFailed(ctx *gin.Context, err error, expErr experrors.ExpressionError) {
+	var syntaxErr *eval.SyntaxError
+	if errors.Is(err, errUnknownReference) ||
+		errors.Is(err, eval.ErrReferenceCycle) ||
+		errors.Is(err, eval.ErrInvalidReference) ||
+		errors.As(err, &syntaxErr) {
+		ctx.JSON(http.StatusBadRequest, parse.ErrorAsJSON(fmt.Errorf("%s: %v", expErr, err)))
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, parse.ErrorAsJSON(experrors.ErrRetrievingExpression.Error()))
+}
+
+// linkedProgram compiles the given stored expression through the cache and links it, reusing the program linked
+// for its revision when there is one.
+func (c *Controller) linkedProgram(
+	ctx context.Context,
+	exp expstore.Expressions,
+	known map[uuid.UUID]expstore.Expressions,
+) (*eval.Program, error) {
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+	if linked, ok := c.links.Get(cacheKey); ok {
+		return linked, nil
+	}
+
+	prog, err := c.compiler.CompileCached(cacheKey, exp.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.linkStored(ctx, exp, prog, known)
+}
+
+// linkStored links the program compiled from the current revision of a stored expression and caches the result
+// until the expression or one of the expressions it references is invalidated. Programs without references are
+// not cached, linking them costs nothing.
+func (c *Controller) linkStored(
+	ctx context.Context,
+	exp expstore.Expressions,
+	prog *eval.Program,
+	known map[uuid.UUID]expstore.Expressions,
+) (*eval.Program, error) {
+	linked, refs, err := c.linkProgram(ctx, exp, prog, known)
 	if err != nil {
 		return nil, err
 	}
+	if len(refs) > 0 {
+		c.links.Put(eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}, linked, refs)
+	}
+
+	return linked, nil
+}
+
+// linkProgram resolves the references of the program compiled from the given expression to the other expressions
+// of its owner, compiled through the cache. Referenced expressions are looked up in known before the store. The IDs
+// of the expressions resolved, directly or not, are returned along with the linked program.
+func (c *Controller) linkProgram(
+	ctx context.Context,
+	exp expstore.Expressions,
+	prog *eval.Program,
+	known map[uuid.UUID]expstore.Expressions,
+) (*eval.Program, []uuid.UUID, error) {
+	var refs []uuid.UUID
+	linked, err := eval.Link(prog, exp.ExpressionID, func(ref *eval.Reference) (uuid.UUID, *eval.Program, error) {
+		refExp, err := c.referencedExpression(ctx, exp.Username, ref, known)
+		if err != nil {
+			return uuid.Nil, nil, err
+		}
+		if refExp.ExpressionID == exp.ExpressionID {
+			// a reference back to the expression being linked is a cycle, reported as such by the linker
+			return exp.ExpressionID, prog, nil
+		}
+
+		cacheKey := eval.CacheKey{ID: refExp.ExpressionID, UpdatedAt: refExp.UpdatedAt}
+		refProg, err := c.compiler.CompileCached(cacheKey, refExp.Expression)
+		if err != nil {
+			return uuid.Nil, nil, err
+		}
+		refs = append(refs, refExp.ExpressionID)
+
+		return refExp.ExpressionID, refProg, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return linked, refs, nil
+}
+
+// referencedExpression returns the expression of the given user a reference points to, by ID or by name. Expressions
+// of other users are never resolved, an error wrapping errUnknownReference is returned as if they did not exist.
+func (c *Controller) referencedExpression(
+	ctx context.Context,
+	username string,
+	ref *eval.Reference,
+	known map[uuid.UUID]expstore.Expressions,
+) (expstore.Expressions, error) {
+	var gotExp expstore.Expressions
+	var err error
+	if ref.Name != "" {
+		for _, exp := range known {
+			if exp.Name == ref.Name {
+				return exp, nil
+			}
+		}
+		gotExp, err = c.store.GetExpressionByName(ctx, expstore.GetExpressionByNameParams{Username: username, Name: ref.Name})
+	} else {
+		if exp, ok := known[ref.ID]; ok {
+			return exp, nil
+		}
+		gotExp, err = c.store.GetExpressionByID(ctx, ref.ID)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return expstore.Expressions{}, errUnknownReference
+		}
+		return expstore.Expressions{}, err
+	}
+	if gotExp.Username != username {
+		return expstore.Expressions{}, errUnknownReference
+	}
+
+	return gotExp, nil
+}
+
+// dependents returns the expressions among exps that reference the target, directly or, when transitive is set,
+// through other expressions of exps. Expressions are returned in the order of exps, and the ones that no longer
+// compile are skipped.
+func (c *Controller) dependents(exps []expstore.Expressions, target expstore.Expressions, transitive bool) []expstore.Expressions {
+	named := make(map[string]uuid.UUID)
+	for _, exp := range exps {
+		if exp.Name != "" {
+			named[exp.Name] = exp.ExpressionID
+		}
+	}
+
+	referencing := make(map[uuid.UUID][]int)
+	for i, exp := range exps {
+		for _, ref := range c.references(exp) {
+			id, ok := ref.ID, true
+			if ref.Name != "" {
+				id, ok = named[ref.Name]
+			}
+			if ok {
+				referencing[id] = append(referencing[id], i)
+			}
+		}
+	}
+
+	found := make([]bool, len(exps))
+	queue := []uuid.UUID{target.ExpressionID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, i := range referencing[id] {
+			if found[i] || exps[i].ExpressionID == target.ExpressionID {
+				continue
+			}
+			found[i] = true
+			if transitive {
+				queue = append(queue, exps[i].ExpressionID)
+			}
+		}
+	}
+
+	deps := make([]expstore.Expressions, 0)
+	for i, exp := range exps {
+		if found[i] {
+			deps = append(deps, exp)
+		}
+	}
+
+	return deps
+}
+
+// nameDependents returns the expressions among exps that reference the given name.
+func (c *Controller) nameDependents(exps []expstore.Expressions, name string) []expstore.Expressions {
+	deps := make([]expstore.Expressions, 0)
+	for _, exp := range exps {
+		for _, ref := range c.references(exp) {
+			if ref.Name == name {
+				deps = append(deps, exp)
+				break
+			}
+		}
+	}
+
+	return deps
+}
+
+// references returns the references of the given stored expression, none when it no longer compiles.
+func (c *Controller) references(exp expstore.Expressions) []*eval.Reference {
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+	prog, err := c.compiler.CompileCached(cacheKey, exp.Expression)
+	if err != nil {
+		return nil
+	}
+
+	return prog.References()
+}
+
+// expressionInUse writes a conflict response listing the expressions that reference an expression.
+func (c *Controller) expressionInUse(ctx *gin.Context, dependents []expstore.Expressions) {
+	res := experrors.ExpressionInUseResponse{Error: experrors.ErrExpressionInUse.String()}
+	for _, dependent := range dependents {
+		res.Dependents = append(res.Dependents, dependent.ExpressionID)
+	}
+	ctx.JSON(http.StatusConflict, res)
+}
+
+// normalizeName trims the given expression name, which may be empty. Names follow the syntax of variable names so
+// that expressions can be referenced by name.
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name != "" && !eval.IsName(name) {
+		return "", fmt.Errorf("%s: %q must start with a letter or an underscore followed by letters, digits, underscores or dots", experrors.ErrInvalidName, name)
+	}
+
+	return name, nil
+}
 
-	if isPaginated {
-		listPagArgs := expstore.ListPaginatedExpressionsParams{
-			Limit:  pageSize,
-			Offset: pageSize * (pageID - 1),
+// normalizeTags trims the given tags and drops duplicates, keeping their order. Empty tags are rejected.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, experrors.ErrInvalidTags.Error()
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
 		}
+	}
+
+	return normalized, nil
+}
+
+// invalidExpression writes a bad request response listing the diagnostics of an invalid expression.
+func (c *Controller) invalidExpression(ctx *gin.Context, expErr experrors.ExpressionError, diags []eval.Diagnostic) {
+	res := experrors.InvalidExpressionResponse{
+		Error: expErr.String(),
+	}
+	if err := marshaller.Response(diags, &res.Diagnostics); err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
+	}
 
-		pgData := pagination.GetData(len(totalExps), pageID, pageSize)
-		ginmidctx.SetPaginationHeader(ctx, pgData)
+	ctx.JSON(http.StatusBadRequest, res)
+}
 
-		return c.store.ListPaginatedExpressions(ctx, listPagArgs)
+// invalidVariables writes a bad request response listing the variables that could not be bound.
+func (c *Controller) invalidVariables(ctx *gin.Context, varErrs []eval.VariableError) {
+	res := experrors.InvalidVariablesResponse{
+		Error: experrors.ErrInvalidVariables.String(),
+	}
+	if err := marshaller.Response(varErrs, &res.Variables); err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			parse.ErrorAsJSON(fmt.Errorf("%s: %v", experrors.ErrInternalServer, err)),
+		)
+		return
 	}
 
-	return totalExps, nil
+	ctx.JSON(http.StatusBadRequest, res)
 }
 
 // extractAuthPayload extracts the payload information from the provided context.
@@ -57,11 +57,11 @@ func EqCreateExpParams(arg expstore.CreateExpressionParams, id uuid.UUID) gomock
 
 // eqUpdateExpMatcher is a matcher type to validate the create expression method
 type eqUpdateExpMatcher struct {
-	arg expstore.UpdateExpressionParams
+	arg expstore.UpdateExpressionTxParams
 }
 
 func (e eqUpdateExpMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(expstore.UpdateExpressionParams)
+	arg, ok := x.(expstore.UpdateExpressionTxParams)
 	if !ok {
 		return false
 	}
@@ -78,6 +78,6 @@ func (e eqUpdateExpMatcher) String() string {
 	return fmt.Sprintf("matches arg %v", e.arg)
 }
 
-func EqUpdateExpParams(arg expstore.UpdateExpressionParams) gomock.Matcher {
+func EqUpdateExpParams(arg expstore.UpdateExpressionTxParams) gomock.Matcher {
 	return eqUpdateExpMatcher{arg}
 }
@@ -2,23 +2,30 @@ package expcontroller_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"github.com/gin-gonic/gin"
 	authmid "github.com/gmaschi/log-exp-eval/internal/controllers/middlewares/auth-mid"
 	expmodel "github.com/gmaschi/log-exp-eval/internal/models/expressions"
+	experrors "github.com/gmaschi/log-exp-eval/internal/models/expressions/errors"
 	expserver "github.com/gmaschi/log-exp-eval/internal/servers/expressions"
 	expstore "github.com/gmaschi/log-exp-eval/internal/services/datastore/postgresql/exp"
 	mockedexpstore "github.com/gmaschi/log-exp-eval/internal/services/datastore/postgresql/exp/mocks"
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
 	mockedeval "github.com/gmaschi/log-exp-eval/internal/services/eval/mocks"
 	"github.com/gmaschi/log-exp-eval/pkg/tools/config/env"
+	"github.com/gmaschi/log-exp-eval/pkg/tools/pagination"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -31,13 +38,24 @@ func TestCreate(t *testing.T) {
 	}
 
 	exp := getExp(t, authValue.Username)
+	exp.Version = 1
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+
+	varsExp := getExp(t, authValue.Username)
+	varsExp.Expression = `country IN ("BR", "PT") AND age >= 18 AND @adults`
+	varsExp.Variables = []string{"age", "country"}
+	varsProg, err := eval.Compile(varsExp.Expression)
+	require.NoError(t, err)
+	adults := getExp(t, authValue.Username)
+	adults.Name = "adults"
 
 	testCases := []struct {
 		name          string
 		body          map[string]interface{}
 		bearerToken   authmid.BearerToken
 		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
-		buildStubs    func(store *mockedexpstore.MockStore)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
@@ -49,23 +67,236 @@ func TestCreate(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(exp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(exp.Expression).Times(1).Return(prog, nil)
 				createArg := expstore.CreateExpressionParams{
 					ExpressionID: exp.ExpressionID,
 					Expression:   exp.Expression,
+					Variables:    []string{},
 					Username:     exp.Username,
 					CreatedAt:    exp.CreatedAt,
 					UpdatedAt:    exp.UpdatedAt,
+					Tags:         []string{},
 				}
 				store.EXPECT().
-					CreateExpression(gomock.Any(), EqCreateExpParams(createArg, exp.ExpressionID)).
+					CreateExpressionTx(gomock.Any(), EqCreateExpParams(createArg, exp.ExpressionID)).
 					Times(1).Return(exp, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
 				requireBodyMatchCreate(t, recorder.Body, exp)
 			},
 		},
+		{
+			name: "Happy path - variables are stored",
+			body: map[string]interface{}{
+				"expression": varsExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(varsExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(varsExp.Expression).Times(1).Return(varsProg, nil)
+				nameArgs := expstore.GetExpressionByNameParams{Username: authValue.Username, Name: adults.Name}
+				store.EXPECT().GetExpressionByName(gomock.Any(), nameArgs).Times(1).Return(adults, nil)
+				adultsKey := eval.CacheKey{ID: adults.ExpressionID, UpdatedAt: adults.UpdatedAt}
+				evaluator.EXPECT().CompileCached(adultsKey, adults.Expression).Times(1).Return(prog, nil)
+				createArg := expstore.CreateExpressionParams{
+					ExpressionID: varsExp.ExpressionID,
+					Expression:   varsExp.Expression,
+					Variables:    varsExp.Variables,
+					Username:     varsExp.Username,
+					CreatedAt:    varsExp.CreatedAt,
+					UpdatedAt:    varsExp.UpdatedAt,
+					Tags:         []string{},
+				}
+				store.EXPECT().
+					CreateExpressionTx(gomock.Any(), EqCreateExpParams(createArg, varsExp.ExpressionID)).
+					Times(1).Return(varsExp, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchCreate(t, recorder.Body, varsExp)
+			},
+		},
+		{
+			name: "Happy path - named expression",
+			body: map[string]interface{}{
+				"expression": exp.Expression,
+				"name":       " rules.adults ",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				namedExp := exp
+				namedExp.Name = "rules.adults"
+
+				evaluator.EXPECT().Validate(exp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(exp.Expression).Times(1).Return(prog, nil)
+				createArg := expstore.CreateExpressionParams{
+					ExpressionID: exp.ExpressionID,
+					Expression:   exp.Expression,
+					Variables:    []string{},
+					Username:     exp.Username,
+					CreatedAt:    exp.CreatedAt,
+					UpdatedAt:    exp.UpdatedAt,
+					Tags:         []string{},
+					Name:         namedExp.Name,
+				}
+				store.EXPECT().
+					CreateExpressionTx(gomock.Any(), EqCreateExpParams(createArg, exp.ExpressionID)).
+					Times(1).Return(namedExp, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				namedExp := exp
+				namedExp.Name = "rules.adults"
+				requireBodyMatchCreate(t, recorder.Body, namedExp)
+			},
+		},
+		{
+			name: "Error - invalid name",
+			body: map[string]interface{}{
+				"expression": exp.Expression,
+				"name":       "adult-users",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(exp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrInvalidName.String())
+			},
+		},
+		{
+			name: "Error - name already in use",
+			body: map[string]interface{}{
+				"expression": exp.Expression,
+				"name":       "adults",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(exp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrNameTaken.String())
+			},
+		},
+		{
+			name: "Error - reference to itself by name",
+			body: map[string]interface{}{
+				"expression": "x AND @adults",
+				"name":       "adults",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate("x AND @adults").Times(1).Return(nil)
+				evaluator.EXPECT().Compile("x AND @adults").Times(1).DoAndReturn(eval.Compile)
+				store.EXPECT().GetExpressionByName(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "reference cycle")
+			},
+		},
+		{
+			name: "Error - reference to an unknown expression",
+			body: map[string]interface{}{
+				"expression": "x AND @{" + uuid.NewString() + "}",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(1).DoAndReturn(eval.Compile)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Happy path - tags are trimmed and de-duplicated",
+			body: map[string]interface{}{
+				"expression": exp.Expression,
+				"tags":       []string{" fraud ", "payments", "fraud"},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				taggedExp := exp
+				taggedExp.Tags = []string{"fraud", "payments"}
+
+				evaluator.EXPECT().Validate(exp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(exp.Expression).Times(1).Return(prog, nil)
+				createArg := expstore.CreateExpressionParams{
+					ExpressionID: exp.ExpressionID,
+					Expression:   exp.Expression,
+					Variables:    []string{},
+					Username:     exp.Username,
+					CreatedAt:    exp.CreatedAt,
+					UpdatedAt:    exp.UpdatedAt,
+					Tags:         taggedExp.Tags,
+				}
+				store.EXPECT().
+					CreateExpressionTx(gomock.Any(), EqCreateExpParams(createArg, exp.ExpressionID)).
+					Times(1).Return(taggedExp, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var res expmodel.CreateExpressionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, []string{"fraud", "payments"}, res.Tags)
+			},
+		},
+		{
+			name: "Error - empty tag",
+			body: map[string]interface{}{
+				"expression": exp.Expression,
+				"tags":       []string{"fraud", " "},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(exp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "Error - empty expression provided",
 			body: map[string]interface{}{
@@ -75,8 +306,10 @@ func TestCreate(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().CreateExpression(gomock.Any(), gomock.Any()).Times(0).Return(exp, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(0)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(exp, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -91,11 +324,30 @@ func TestCreate(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().CreateExpression(gomock.Any(), gomock.Any()).Times(0).Return(exp, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate("  ").Times(1).Return([]eval.Diagnostic{{Offset: 0, Line: 1, Column: 1, Code: eval.CodeEmptyExpression, Message: "empty expression"}})
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(exp, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - invalid expression",
+			body: map[string]interface{}{
+				"expression": "(x AND",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate("(x AND").Times(1).Return(eval.Validate("(x AND"))
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(exp, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchDiagnostics(t, recorder.Body, experrors.ErrInvalidExpression, eval.Validate("(x AND"))
 			},
 		},
 		{
@@ -107,16 +359,20 @@ func TestCreate(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(exp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(exp.Expression).Times(1).Return(prog, nil)
 				createArg := expstore.CreateExpressionParams{
 					ExpressionID: exp.ExpressionID,
 					Expression:   exp.Expression,
+					Variables:    []string{},
 					Username:     exp.Username,
 					CreatedAt:    exp.CreatedAt,
 					UpdatedAt:    exp.UpdatedAt,
+					Tags:         []string{},
 				}
 				store.EXPECT().
-					CreateExpression(gomock.Any(), EqCreateExpParams(createArg, exp.ExpressionID)).
+					CreateExpressionTx(gomock.Any(), EqCreateExpParams(createArg, exp.ExpressionID)).
 					Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -130,8 +386,10 @@ func TestCreate(t *testing.T) {
 			},
 			bearerToken: authValue.BearerToken,
 			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().CreateExpression(gomock.Any(), gomock.Any()).Times(0).Return(exp, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(0)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+				store.EXPECT().CreateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(exp, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -145,12 +403,13 @@ func TestCreate(t *testing.T) {
 			defer ctrl.Finish()
 
 			store := mockedexpstore.NewMockStore(ctrl)
-			tc.buildStubs(store)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
 
 			config, err := env.NewConfig()
 			require.NoError(t, err)
 
-			server, err := expserver.New(config, store, nil)
+			server, err := expserver.New(config, store, evaluator)
 			require.NoError(t, err)
 			recorder := httptest.NewRecorder()
 
@@ -182,6 +441,7 @@ func TestGet(t *testing.T) {
 	}
 
 	exp := getExp(t, authValue.Username)
+	exp.Version = 1
 
 	testCases := []struct {
 		name          string
@@ -203,6 +463,7 @@ func TestGet(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
 				requireBodyMatchGet(t, recorder.Body, exp)
 			},
 		},
@@ -307,126 +568,70 @@ func TestGet(t *testing.T) {
 	}
 }
 
-func TestDelete(t *testing.T) {
+func TestGetByName(t *testing.T) {
 	authValue := authmid.AuthValue{
 		BearerToken: authmid.BearerToken1,
 		UserID:      "12345",
 		Username:    "John Doe",
 	}
-	anotherAuthValue := authmid.AuthValue{
-		BearerToken: authmid.BearerToken2,
-		UserID:      "98765",
-		Username:    "Jane Doe",
-	}
 
 	exp := getExp(t, authValue.Username)
+	exp.Name = "adults"
+	getArgs := expstore.GetExpressionByNameParams{Username: authValue.Username, Name: exp.Name}
 
 	testCases := []struct {
 		name          string
-		id            string
-		bearerToken   authmid.BearerToken
-		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		expName       string
+		setupAuth     func(t *testing.T, request *http.Request)
 		buildStubs    func(store *mockedexpstore.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name:        "Happy path",
-			id:          exp.ExpressionID.String(),
-			bearerToken: authValue.BearerToken,
-			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
-			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-				store.EXPECT().DeleteExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(nil)
-			},
-			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNoContent, recorder.Code)
-			},
-		},
-		{
-			name:        "Error - invalid expression ID",
-			id:          "some-invalid-id",
-			bearerToken: authValue.BearerToken,
-			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			name:    "Happy path",
+			expName: exp.Name,
+			setupAuth: func(t *testing.T, request *http.Request) {
+				addAuthorization(request, authValue.BearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
-				store.EXPECT().DeleteExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(nil)
+				store.EXPECT().GetExpressionByName(gomock.Any(), getArgs).Times(1).Return(exp, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchGet(t, recorder.Body, exp)
 			},
 		},
 		{
-			name:        "Error - database - get expression not found",
-			id:          exp.ExpressionID.String(),
-			bearerToken: authValue.BearerToken,
-			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			name:    "Error - database - get expression not found",
+			expName: exp.Name,
+			setupAuth: func(t *testing.T, request *http.Request) {
+				addAuthorization(request, authValue.BearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
-				store.EXPECT().DeleteExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(nil)
+				store.EXPECT().GetExpressionByName(gomock.Any(), getArgs).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
 		{
-			name:        "Error - database - get expression internal error",
-			id:          exp.ExpressionID.String(),
-			bearerToken: authValue.BearerToken,
-			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
-			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
-				store.EXPECT().DeleteExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(nil)
-			},
-			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
-			},
-		},
-		{
-			name:        "Error - expression does not belong to authenticated user",
-			id:          exp.ExpressionID.String(),
-			bearerToken: anotherAuthValue.BearerToken,
-			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
-			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-				store.EXPECT().DeleteExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(nil)
-			},
-			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
-			},
-		},
-		{
-			name:        "Error - database - delete expression error",
-			id:          exp.ExpressionID.String(),
-			bearerToken: authValue.BearerToken,
-			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			name:    "Error - database - get expression internal error",
+			expName: exp.Name,
+			setupAuth: func(t *testing.T, request *http.Request) {
+				addAuthorization(request, authValue.BearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-				store.EXPECT().DeleteExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(sql.ErrConnDone)
+				store.EXPECT().GetExpressionByName(gomock.Any(), getArgs).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 		{
-			name:        "Error - not authenticated",
-			id:          exp.ExpressionID.String(),
-			bearerToken: authValue.BearerToken,
-			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			name:      "Error - not authenticated",
+			expName:   exp.Name,
+			setupAuth: func(t *testing.T, request *http.Request) {},
 			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
-				store.EXPECT().DeleteExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(nil)
+				store.EXPECT().GetExpressionByName(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -449,254 +654,4926 @@ func TestDelete(t *testing.T) {
 			require.NoError(t, err)
 			recorder := httptest.NewRecorder()
 
-			url := "/v1/expressions/" + tc.id
-
-			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			req, err := http.NewRequest(http.MethodGet, "/v1/expressions/by-name/"+tc.expName, nil)
 			require.NoError(t, err)
 
-			tc.setupAuth(t, req, tc.bearerToken)
+			tc.setupAuth(t, req)
 			server.Router.ServeHTTP(recorder, req)
 			tc.checkResponse(t, recorder)
 		})
 	}
 }
 
-func TestList(t *testing.T) {
+func TestDelete(t *testing.T) {
 	authValue := authmid.AuthValue{
 		BearerToken: authmid.BearerToken1,
 		UserID:      "12345",
 		Username:    "John Doe",
 	}
+	anotherAuthValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken2,
+		UserID:      "98765",
+		Username:    "Jane Doe",
+	}
 
-	n := 10
-	exps := make([]expstore.Expressions, 0, n)
-	var exp expstore.Expressions
-	for i := 0; i < n; i++ {
+	exp := getExp(t, authValue.Username)
+	exp.Version = 1
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+
+	dependent := getExp(t, authValue.Username)
+	dependent.Expression = fmt.Sprintf("@{%s} OR x", exp.ExpressionID)
+	dependentProg, err := eval.Compile(dependent.Expression)
+	require.NoError(t, err)
+	dependentKey := eval.CacheKey{ID: dependent.ExpressionID, UpdatedAt: dependent.UpdatedAt}
+	listArgs := expstore.ListExpressionsByUsernameParams{Username: authValue.Username}
+	deleteArgs := expstore.DeleteExpressionByIDParams{ExpressionID: exp.ExpressionID}
+
+	testCases := []struct {
+		name          string
+		id            string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Happy path",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp}, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), deleteArgs).Times(1).Return(int64(1), nil)
+				evaluator.EXPECT().Invalidate(exp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - If-Match lists the current entity tag",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"1"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp}, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), expstore.DeleteExpressionByIDParams{
+					ExpressionID:    exp.ExpressionID,
+					ExpectedVersion: exp.Version,
+				}).Times(1).Return(int64(1), nil)
+				evaluator.EXPECT().Invalidate(exp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - If-Match does not match the current entity tag",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"7"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:        "Error - expression modified between the read and the delete",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"1"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp}, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression deleted between the read and the delete",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp}, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), deleteArgs).Times(1).Return(int64(0), nil)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression referenced by other expressions",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp, dependent}, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().CompileCached(dependentKey, dependent.Expression).Times(1).Return(dependentProg, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+
+				var res experrors.ExpressionInUseResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, experrors.ErrExpressionInUse.String(), res.Error)
+				require.Equal(t, []uuid.UUID{dependent.ExpressionID}, res.Dependents)
+			},
+		},
+		{
+			name:        "Error - database - list dependents error",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return(nil, sql.ErrConnDone)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid expression ID",
+			id:          "some-invalid-id",
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - get expression not found",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - get expression internal error",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression does not belong to authenticated user",
+			id:          exp.ExpressionID.String(),
+			bearerToken: anotherAuthValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - delete expression error",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp}, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), deleteArgs).Times(1).Return(int64(0), sql.ErrConnDone)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - not authenticated",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
+				store.EXPECT().DeleteExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := "/v1/expressions/" + tc.id
+
+			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+	anotherAuthValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken2,
+		UserID:      "98765",
+		Username:    "Jane Doe",
+	}
+
+	exp := getExp(t, authValue.Username)
+	exp.Name = "rule"
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+
+	deletedExp := exp
+	deletedExp.DeletedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	deletedExp.Version = 1
+	restoreArgs := expstore.RestoreExpressionByIDParams{ExpressionID: exp.ExpressionID}
+
+	// an expression referencing another one deleted in the meantime
+	referenced := getExp(t, authValue.Username)
+	dangling := deletedExp
+	dangling.Expression = fmt.Sprintf("@{%s} OR x", referenced.ExpressionID)
+	danglingProg, err := eval.Compile(dangling.Expression)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		id            string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Happy path",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), restoreArgs).Times(1).Return(exp, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchGet(t, recorder.Body, exp)
+			},
+		},
+		{
+			name:        "Happy path - If-Match lists the current entity tag",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"1"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), expstore.RestoreExpressionByIDParams{
+					ExpressionID:    exp.ExpressionID,
+					ExpectedVersion: 1,
+				}).Times(1).Return(exp, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - If-Match does not match the current entity tag",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"7"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:        "Error - expression modified between the read and the restore",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"1"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression restored between the read and the restore",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), restoreArgs).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid expression ID",
+			id:          "some-invalid-id",
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - deleted expression not found",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - get deleted expression internal error",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression does not belong to authenticated user",
+			id:          exp.ExpressionID.String(),
+			bearerToken: anotherAuthValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - referenced expression no longer exists",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(dangling, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, dangling.Expression).Times(1).Return(danglingProg, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), referenced.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - name taken by another expression",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), restoreArgs).Times(1).Return(expstore.Expressions{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - restore expression error",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(deletedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), restoreArgs).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - not authenticated",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetDeletedExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RestoreExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := "/v1/expressions/" + tc.id + "/restore"
+
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDependents(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	base := getExp(t, authValue.Username)
+	direct := getExp(t, authValue.Username)
+	direct.Expression = fmt.Sprintf("@{%s} OR x", base.ExpressionID)
+	direct.Name = "direct"
+	indirect := getExp(t, authValue.Username)
+	indirect.Expression = "y AND NOT @direct"
+	unrelated := getExp(t, authValue.Username)
+	exps := []expstore.Expressions{base, direct, indirect, unrelated}
+	listArgs := expstore.ListExpressionsByUsernameParams{Username: authValue.Username}
+
+	// compileAll expects every listed expression to be compiled once
+	compileAll := func(t *testing.T, evaluator *mockedeval.MockEvaluator) {
+		for _, exp := range exps {
+			prog, err := eval.Compile(exp.Expression)
+			require.NoError(t, err)
+			cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+			evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		id            string
+		query         string
+		buildStubs    func(t *testing.T, store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path - direct dependents",
+			id:   base.ExpressionID.String(),
+			buildStubs: func(t *testing.T, store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), base.ExpressionID).Times(1).Return(base, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return(exps, nil)
+				compileAll(t, evaluator)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireDependents(t, recorder.Body, base.ExpressionID, direct.ExpressionID)
+			},
+		},
+		{
+			name:  "Happy path - transitive dependents",
+			id:    base.ExpressionID.String(),
+			query: "?transitive=true",
+			buildStubs: func(t *testing.T, store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), base.ExpressionID).Times(1).Return(base, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return(exps, nil)
+				compileAll(t, evaluator)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireDependents(t, recorder.Body, base.ExpressionID, direct.ExpressionID, indirect.ExpressionID)
+			},
+		},
+		{
+			name: "Happy path - no dependents",
+			id:   unrelated.ExpressionID.String(),
+			buildStubs: func(t *testing.T, store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), unrelated.ExpressionID).Times(1).Return(unrelated, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return(exps, nil)
+				compileAll(t, evaluator)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireDependents(t, recorder.Body, unrelated.ExpressionID)
+			},
+		},
+		{
+			name: "Error - expression not found",
+			id:   base.ExpressionID.String(),
+			buildStubs: func(t *testing.T, store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), base.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - database - list expressions error",
+			id:   base.ExpressionID.String(),
+			buildStubs: func(t *testing.T, store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), base.ExpressionID).Times(1).Return(base, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:  "Error - invalid transitive flag",
+			id:    base.ExpressionID.String(),
+			query: "?transitive=maybe",
+			buildStubs: func(t *testing.T, store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(t, store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/v1/expressions/%s/dependents%s", tc.id, tc.query)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(req, authValue.BearerToken, authmid.AuthorizationTypeBearer)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestVersions(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp := getExp(t, authValue.Username)
+	versions := []expstore.ExpressionVersions{
+		{ExpressionID: exp.ExpressionID, Version: 1, Expression: "(1 OR 0)", Author: authValue.Username, CreatedAt: exp.CreatedAt},
+		{ExpressionID: exp.ExpressionID, Version: 2, Expression: exp.Expression, Author: authValue.Username, CreatedAt: exp.UpdatedAt},
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		buildStubs    func(store *mockedexpstore.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path - list versions",
+			url:  fmt.Sprintf("/v1/expressions/%s/versions", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionVersions(gomock.Any(), exp.ExpressionID).Times(1).Return(versions, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res []expmodel.ExpressionVersionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Len(t, res, len(versions))
+				for i, version := range versions {
+					require.Equal(t, version.Version, res[i].Version)
+					require.Equal(t, version.Expression, res[i].Expression)
+					require.Equal(t, version.Author, res[i].Author)
+				}
+			},
+		},
+		{
+			name: "Happy path - get version",
+			url:  fmt.Sprintf("/v1/expressions/%s/versions/1", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				versionArgs := expstore.GetExpressionVersionParams{ExpressionID: exp.ExpressionID, Version: 1}
+				store.EXPECT().GetExpressionVersion(gomock.Any(), versionArgs).Times(1).Return(versions[0], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.ExpressionVersionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, exp.ExpressionID, res.ExpressionID)
+				require.Equal(t, int32(1), res.Version)
+				require.Equal(t, versions[0].Expression, res.Expression)
+			},
+		},
+		{
+			name: "Happy path - diff versions",
+			url:  fmt.Sprintf("/v1/expressions/%s/diff?from=1&to=2", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				fromArgs := expstore.GetExpressionVersionParams{ExpressionID: exp.ExpressionID, Version: 1}
+				store.EXPECT().GetExpressionVersion(gomock.Any(), fromArgs).Times(1).Return(versions[0], nil)
+				toArgs := expstore.GetExpressionVersionParams{ExpressionID: exp.ExpressionID, Version: 2}
+				store.EXPECT().GetExpressionVersion(gomock.Any(), toArgs).Times(1).Return(versions[1], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.DiffVersionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, exp.ExpressionID, res.ExpressionID)
+				require.Equal(t, int32(1), res.From)
+				require.Equal(t, int32(2), res.To)
+				require.Equal(t, []expmodel.DiffChunkResponse{
+					{Op: "equal", Text: "(1"},
+					{Op: "delete", Text: "OR"},
+					{Op: "insert", Text: "AND"},
+					{Op: "equal", Text: "0)"},
+				}, res.Chunks)
+			},
+		},
+		{
+			name: "Error - version not found",
+			url:  fmt.Sprintf("/v1/expressions/%s/versions/3", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(1).Return(expstore.ExpressionVersions{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - invalid version",
+			url:  fmt.Sprintf("/v1/expressions/%s/versions/latest", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - diff without versions",
+			url:  fmt.Sprintf("/v1/expressions/%s/diff?from=1", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - expression of another user",
+			url:  fmt.Sprintf("/v1/expressions/%s/versions", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				other := exp
+				other.Username = "Jane Doe"
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(other, nil)
+				store.EXPECT().ListExpressionVersions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - database - list versions error",
+			url:  fmt.Sprintf("/v1/expressions/%s/versions", exp.ExpressionID),
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionVersions(gomock.Any(), exp.ExpressionID).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(req, authValue.BearerToken, authmid.AuthorizationTypeBearer)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp := getExp(t, authValue.Username)
+	exp.Name = "rule"
+	exp.Tags = []string{"fraud"}
+	exp.Version = 2
+	version := expstore.ExpressionVersions{ExpressionID: exp.ExpressionID, Version: 1, Expression: "(1 OR 0)", Author: authValue.Username}
+	versionArgs := expstore.GetExpressionVersionParams{ExpressionID: exp.ExpressionID, Version: 1}
+	prog, err := eval.Compile(version.Expression)
+	require.NoError(t, err)
+
+	rolledBack := exp
+	rolledBack.Expression = version.Expression
+	rolledBack.UpdatedAt = time.Now()
+	rolledBack.Version = 3
+
+	testCases := []struct {
+		name          string
+		body          map[string]interface{}
+		ifMatch       string
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path",
+			body: map[string]interface{}{"version": 1},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), versionArgs).Times(1).Return(version, nil)
+				evaluator.EXPECT().Validate(version.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(version.Expression).Times(1).Return(prog, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: exp.ExpressionID,
+						Expression:   version.Expression,
+						Variables:    []string{},
+						Name:         exp.Name,
+						Tags:         exp.Tags,
+						UpdatedAt:    rolledBack.UpdatedAt,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(rolledBack, nil)
+				evaluator.EXPECT().Invalidate(exp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+				requireBodyMatchUpdate(t, recorder.Body, rolledBack)
+			},
+		},
+		{
+			name:    "Happy path - If-Match lists the current entity tag",
+			body:    map[string]interface{}{"version": 1},
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), versionArgs).Times(1).Return(version, nil)
+				evaluator.EXPECT().Validate(version.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(version.Expression).Times(1).Return(prog, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID:    exp.ExpressionID,
+						Expression:      version.Expression,
+						Variables:       []string{},
+						Name:            exp.Name,
+						Tags:            exp.Tags,
+						UpdatedAt:       rolledBack.UpdatedAt,
+						ExpectedVersion: exp.Version,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(rolledBack, nil)
+				evaluator.EXPECT().Invalidate(exp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+				requireBodyMatchUpdate(t, recorder.Body, rolledBack)
+			},
+		},
+		{
+			name:    "Error - If-Match does not match the current entity tag",
+			body:    map[string]interface{}{"version": 1},
+			ifMatch: `"7"`,
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				require.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:    "Error - expression modified between the read and the rollback",
+			body:    map[string]interface{}{"version": 1},
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), versionArgs).Times(1).Return(version, nil)
+				evaluator.EXPECT().Validate(version.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(version.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name: "Happy path - current version",
+			body: map[string]interface{}{"version": 2},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				current := expstore.ExpressionVersions{ExpressionID: exp.ExpressionID, Version: 2, Expression: exp.Expression}
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(1).Return(current, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUpdate(t, recorder.Body, exp)
+			},
+		},
+		{
+			name: "Error - version references a missing expression",
+			body: map[string]interface{}{"version": 1},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				referencing := version
+				referencing.Expression = "x AND @{" + uuid.NewString() + "}"
+				referencingProg, err := eval.Compile(referencing.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), versionArgs).Times(1).Return(referencing, nil)
+				evaluator.EXPECT().Validate(referencing.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(referencing.Expression).Times(1).Return(referencingProg, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Not(exp.ExpressionID)).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - version not found",
+			body: map[string]interface{}{"version": 3},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(1).Return(expstore.ExpressionVersions{}, sql.ErrNoRows)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - missing version",
+			body: map[string]interface{}{},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - database - failed to update expression",
+			body: map[string]interface{}{"version": 1},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), versionArgs).Times(1).Return(version, nil)
+				evaluator.EXPECT().Validate(version.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(version.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/v1/expressions/%s/rollback", exp.ExpressionID)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(req, authValue.BearerToken, authmid.AuthorizationTypeBearer)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	n := 10
+	exps := make([]expstore.Expressions, 0, n)
+	var exp expstore.Expressions
+	for i := 0; i < n; i++ {
 		exp = getExp(t, authValue.Username)
 		exps = append(exps, exp)
 	}
-	pageID := 1
-	pageSize := 5
+	pageID := 1
+	pageSize := 5
+
+	named := make([]expstore.Expressions, pageSize+1)
+	for i := range named {
+		named[i] = exps[i]
+		named[i].RowID = int64(i + 1)
+		named[i].Name = fmt.Sprintf("rule:%d", i)
+	}
+
+	testCases := []struct {
+		name          string
+		queries       map[string]string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Happy path - list all",
+			queries:     map[string]string{},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), expstore.ListExpressionsParams{}).Times(1).Return(exps, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchList(t, recorder.Body, exps)
+			},
+		},
+		{
+			name: "Happy path - list paginated",
+			queries: map[string]string{
+				"page_id":   strconv.Itoa(pageID),
+				"page_size": strconv.Itoa(pageSize),
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().CountExpressions(gomock.Any(), expstore.ListFilters{}).Times(1).Return(int64(n), nil)
+
+				listPagArgs := expstore.ListExpressionsParams{
+					Limit:  int32(pageSize),
+					Offset: int32(pageSize) * int32(pageID-1),
+				}
+				store.EXPECT().ListExpressions(gomock.Any(), listPagArgs).Times(1).Return(exps[:5], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchList(t, recorder.Body, exps[:5])
+				require.Equal(t, "1", recorder.Header().Get("x-pagination-page"))
+				require.Equal(t, "2", recorder.Header().Get("x-pagination-pages"))
+				require.Equal(t, strconv.Itoa(n), recorder.Header().Get("x-pagination-items"))
+				require.Empty(t, recorder.Header().Get("x-pagination-next-cursor"))
+			},
+		},
+		{
+			name: "Happy path - list by cursor - first page",
+			queries: map[string]string{
+				"page_size": strconv.Itoa(pageSize),
+				"cursor":    "",
+				"variable":  "age",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().CountExpressions(gomock.Any(), gomock.Any()).Times(0)
+
+				listArgs := expstore.ListExpressionsParams{
+					ListFilters: expstore.ListFilters{Variable: "age"},
+					Limit:       int32(pageSize) + 1,
+				}
+				store.EXPECT().ListExpressions(gomock.Any(), listArgs).Times(1).Return(exps[:pageSize+1], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				next := pagination.Cursor{
+					Sort:  "created_at",
+					Key:   pagination.TimeKey(exps[pageSize-1].CreatedAt),
+					RowID: exps[pageSize-1].RowID,
+				}.Encode()
+				requireBodyMatchPage(t, recorder.Body, exps[:pageSize], next)
+				require.Equal(t, next, recorder.Header().Get("x-pagination-next-cursor"))
+				nextURL := fmt.Sprintf("/v1/expressions?cursor=%s&page_size=%d&variable=age", next, pageSize)
+				require.Equal(t, fmt.Sprintf(`<%s>; rel="next"`, nextURL), recorder.Header().Get("Link"))
+				require.Empty(t, recorder.Header().Get("x-pagination-items"))
+			},
+		},
+		{
+			name: "Happy path - list by cursor - single page",
+			queries: map[string]string{
+				"page_size": strconv.Itoa(n),
+				"cursor":    "",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				listArgs := expstore.ListExpressionsParams{
+					Limit: int32(n) + 1,
+				}
+				store.EXPECT().ListExpressions(gomock.Any(), listArgs).Times(1).Return(exps, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPage(t, recorder.Body, exps, "")
+				require.Empty(t, recorder.Header().Get("x-pagination-next-cursor"))
+				require.Empty(t, recorder.Header().Get("Link"))
+			},
+		},
+		{
+			name: "Happy path - list by cursor - last page in descending order with totals",
+			queries: map[string]string{
+				"page_size": strconv.Itoa(pageSize),
+				"cursor": pagination.Cursor{
+					Sort:       "created_at",
+					Descending: true,
+					Key:        pagination.TimeKey(exps[pageSize].CreatedAt),
+					RowID:      exps[pageSize].RowID,
+				}.Encode(),
+				"order":    "desc",
+				"variable": "age",
+				"total":    "true",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				countArgs := expstore.ListFilters{Variable: "age"}
+				store.EXPECT().CountExpressions(gomock.Any(), countArgs).Times(1).Return(int64(n), nil)
+
+				listArgs := expstore.ListExpressionsParams{
+					ListFilters: countArgs,
+					Descending:  true,
+					After: &expstore.ListKey{
+						Value: exps[pageSize].CreatedAt.Truncate(time.Microsecond).UTC(),
+						RowID: exps[pageSize].RowID,
+					},
+					Limit: int32(pageSize) + 1,
+				}
+				store.EXPECT().ListExpressions(gomock.Any(), listArgs).Times(1).Return(exps[:3], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPage(t, recorder.Body, exps[:3], "")
+				require.Empty(t, recorder.Header().Get("x-pagination-next-cursor"))
+				require.Empty(t, recorder.Header().Get("Link"))
+				require.Empty(t, recorder.Header().Get("x-pagination-page"))
+				require.Equal(t, "2", recorder.Header().Get("x-pagination-pages"))
+				require.Equal(t, strconv.Itoa(n), recorder.Header().Get("x-pagination-items"))
+			},
+		},
+		{
+			name: "Happy path - list by cursor - next page sorted by name",
+			queries: map[string]string{
+				"page_size": strconv.Itoa(pageSize),
+				"cursor":    pagination.Cursor{Sort: "name", Key: "fraud:high", RowID: 12}.Encode(),
+				"sort":      "name",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				listArgs := expstore.ListExpressionsParams{
+					Sort:  expstore.SortName,
+					After: &expstore.ListKey{Value: "fraud:high", RowID: 12},
+					Limit: int32(pageSize) + 1,
+				}
+				store.EXPECT().ListExpressions(gomock.Any(), listArgs).Times(1).Return(named, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				next := pagination.Cursor{Sort: "name", Key: named[pageSize-1].Name, RowID: named[pageSize-1].RowID}.Encode()
+				requireBodyMatchPage(t, recorder.Body, named[:pageSize], next)
+				require.Equal(t, next, recorder.Header().Get("x-pagination-next-cursor"))
+			},
+		},
+		{
+			name: "Happy path - filtered and sorted",
+			queries: map[string]string{
+				"username":       authValue.Username,
+				"created_after":  "2023-02-01T00:00:00Z",
+				"created_before": "2023-03-01T00:00:00Z",
+				"updated_after":  "2023-02-15T00:00:00Z",
+				"contains":       `100%_\`,
+				"search":         " country ",
+				"variable":       "age",
+				"sort":           "updated_at",
+				"order":          "desc",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				listArgs := expstore.ListExpressionsParams{
+					ListFilters: expstore.ListFilters{
+						Username:      authValue.Username,
+						CreatedAfter:  sql.NullTime{Time: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+						CreatedBefore: sql.NullTime{Time: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+						UpdatedAfter:  sql.NullTime{Time: time.Date(2023, 2, 15, 0, 0, 0, 0, time.UTC), Valid: true},
+						Contains:      `100\%\_\\`,
+						Search:        "country",
+						Variable:      "age",
+					},
+					Sort:       expstore.SortUpdatedAt,
+					Descending: true,
+				}
+				store.EXPECT().ListExpressions(gomock.Any(), listArgs).Times(1).Return(exps[:2], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchList(t, recorder.Body, exps[:2])
+			},
+		},
+		{
+			name: "Happy path - list paginated and sorted by name",
+			queries: map[string]string{
+				"page_id":   strconv.Itoa(pageID),
+				"page_size": strconv.Itoa(pageSize),
+				"sort":      "name",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().CountExpressions(gomock.Any(), expstore.ListFilters{}).Times(1).Return(int64(n), nil)
+
+				listPagArgs := expstore.ListExpressionsParams{
+					Sort:   expstore.SortName,
+					Limit:  int32(pageSize),
+					Offset: int32(pageSize) * int32(pageID-1),
+				}
+				store.EXPECT().ListExpressions(gomock.Any(), listPagArgs).Times(1).Return(exps[:5], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchList(t, recorder.Body, exps[:5])
+			},
+		},
+		{
+			name: "Error - invalid sort field",
+			queries: map[string]string{
+				"sort": "row_id",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - invalid date",
+			queries: map[string]string{
+				"created_after": "2023-02-01",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - invalid pagination data - invalid page ID",
+			queries: map[string]string{
+				"page_id":   "invalid-page-id",
+				"page_size": strconv.Itoa(pageSize),
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - invalid pagination data - invalid page size",
+			queries: map[string]string{
+				"page_id":   strconv.Itoa(pageID),
+				"page_size": "invalid-page-size",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - missing pagination data - missing page id",
+			queries: map[string]string{
+				"page_id":   "",
+				"page_size": strconv.Itoa(pageSize),
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrRequiredPaginationData.String())
+			},
+		},
+		{
+			name: "Error - invalid pagination data - page ID and cursor",
+			queries: map[string]string{
+				"page_id":   strconv.Itoa(pageID),
+				"page_size": strconv.Itoa(pageSize),
+				"cursor":    pagination.Cursor{Sort: "created_at", Key: pagination.TimeKey(exps[0].CreatedAt), RowID: exps[0].RowID}.Encode(),
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrConflictingPagination.String())
+			},
+		},
+		{
+			name: "Error - missing pagination data - cursor without page size",
+			queries: map[string]string{
+				"cursor": pagination.Cursor{Sort: "created_at", Key: pagination.TimeKey(exps[0].CreatedAt), RowID: exps[0].RowID}.Encode(),
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrRequiredCursorPageSize.String())
+			},
+		},
+		{
+			name: "Error - invalid pagination data - invalid cursor",
+			queries: map[string]string{
+				"page_size": strconv.Itoa(pageSize),
+				"cursor":    "not-a-cursor",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrInvalidCursor.String())
+			},
+		},
+		{
+			name: "Error - invalid pagination data - cursor of another sort",
+			queries: map[string]string{
+				"page_size": strconv.Itoa(pageSize),
+				"cursor":    pagination.Cursor{Sort: "name", Key: exps[0].Name, RowID: exps[0].RowID}.Encode(),
+				"sort":      "updated_at",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrCursorSort.String())
+			},
+		},
+		{
+			name: "Error - missing pagination data - missing page size",
+			queries: map[string]string{
+				"page_id":   strconv.Itoa(pageID),
+				"page_size": "",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - failed to list expressions - not found",
+			queries:     map[string]string{},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(1).Return([]expstore.Expressions{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - failed to list expressions - internal error",
+			queries:     map[string]string{},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().ListExpressions(gomock.Any(), gomock.Any()).Times(1).Return([]expstore.Expressions{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		//{
+		//	name:        "Error - invalid expression ID",
+		//	id:          "some-invalid-id",
+		//	bearerToken: authValue.BearerToken,
+		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+		//	},
+		//	buildStubs: func(store *mockedexpstore.MockStore) {
+		//		store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
+		//	},
+		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		//		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		//	},
+		//},
+		//{
+		//	name:        "Error - database - expression not found",
+		//	id:          exp.ExpressionID.String(),
+		//	bearerToken: authValue.BearerToken,
+		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+		//	},
+		//	buildStubs: func(store *mockedexpstore.MockStore) {
+		//		store.EXPECT().
+		//			GetExpressionByID(gomock.Any(), exp.ExpressionID).
+		//			Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+		//	},
+		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		//		require.Equal(t, http.StatusNotFound, recorder.Code)
+		//	},
+		//},
+		//{
+		//	name:        "Error - database - internal error",
+		//	id:          exp.ExpressionID.String(),
+		//	bearerToken: authValue.BearerToken,
+		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+		//	},
+		//	buildStubs: func(store *mockedexpstore.MockStore) {
+		//		store.EXPECT().
+		//			GetExpressionByID(gomock.Any(), exp.ExpressionID).
+		//			Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+		//	},
+		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		//		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+		//	},
+		//},
+		//{
+		//	name:        "Error - expression does not belong to authenticated user",
+		//	id:          exp.ExpressionID.String(),
+		//	bearerToken: anotherAuthValue.BearerToken,
+		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+		//	},
+		//	buildStubs: func(store *mockedexpstore.MockStore) {
+		//		store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+		//	},
+		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		//		require.Equal(t, http.StatusNotFound, recorder.Code)
+		//	},
+		//},
+		//{
+		//	name:        "Error - not authenticated",
+		//	id:          exp.ExpressionID.String(),
+		//	bearerToken: authValue.BearerToken,
+		//	setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+		//	buildStubs: func(store *mockedexpstore.MockStore) {
+		//		store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
+		//	},
+		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		//		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		//	},
+		//},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := "/v1/expressions"
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			q := req.URL.Query()
+			for k, v := range tc.queries {
+				// an empty cursor is sent as is since it asks for the first page
+				if v != "" || k == "cursor" {
+					q.Set(k, v)
+				}
+			}
+			req.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+	anotherAuthValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken2,
+		UserID:      "98765",
+		Username:    "Jane Doe",
+	}
+
+	exp := getExp(t, authValue.Username)
+	exp.Version = 1
+	now := time.Now()
+	updatedExp := expstore.Expressions{
+		RowID:        exp.RowID,
+		ExpressionID: exp.ExpressionID,
+		Expression:   "(0 OR 0)",
+		Username:     exp.Username,
+		CreatedAt:    exp.CreatedAt,
+		UpdatedAt:    now,
+		Version:      2,
+	}
+	prog, err := eval.Compile(updatedExp.Expression)
+	require.NoError(t, err)
+
+	dependent := getExp(t, authValue.Username)
+	dependent.Expression = fmt.Sprintf("x OR @{%s}", exp.ExpressionID)
+
+	testCases := []struct {
+		name          string
+		body          map[string]interface{}
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: updatedExp.ExpressionID,
+						Expression:   updatedExp.Expression,
+						Variables:    []string{},
+						UpdatedAt:    updatedExp.UpdatedAt,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(updatedExp, nil)
+				evaluator.EXPECT().Invalidate(updatedExp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+				requireBodyMatchUpdate(t, recorder.Body, updatedExp)
+			},
+		},
+		{
+			name: "Happy path - If-Match lists the current entity tag",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"7", "1"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID:    updatedExp.ExpressionID,
+						Expression:      updatedExp.Expression,
+						Variables:       []string{},
+						UpdatedAt:       updatedExp.UpdatedAt,
+						ExpectedVersion: exp.Version,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(updatedExp, nil)
+				evaluator.EXPECT().Invalidate(updatedExp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+				requireBodyMatchUpdate(t, recorder.Body, updatedExp)
+			},
+		},
+		{
+			name: "Error - If-Match does not match the current entity tag",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"7"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name: "Error - expression modified between the read and the update",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"1"`)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name: "Happy path - renamed",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+				"name":          "renamed",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				renamedExp := updatedExp
+				renamedExp.Name = "renamed"
+
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), gomock.Any()).Times(0)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: updatedExp.ExpressionID,
+						Expression:   updatedExp.Expression,
+						Variables:    []string{},
+						Name:         renamedExp.Name,
+						UpdatedAt:    updatedExp.UpdatedAt,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(renamedExp, nil)
+				evaluator.EXPECT().Invalidate(updatedExp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				renamedExp := updatedExp
+				renamedExp.Name = "renamed"
+				requireBodyMatchUpdate(t, recorder.Body, renamedExp)
+			},
+		},
+		{
+			name: "Error - renaming an expression referenced by name",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+				"name":          "",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				namedExp := exp
+				namedExp.Name = "adults"
+				byName := getExp(t, authValue.Username)
+				byName.Expression = "@adults OR y"
+				byNameProg, err := eval.Compile(byName.Expression)
+				require.NoError(t, err)
+				namedProg, err := eval.Compile(namedExp.Expression)
+				require.NoError(t, err)
+
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(namedExp, nil)
+				store.EXPECT().
+					ListExpressionsByUsername(gomock.Any(), expstore.ListExpressionsByUsernameParams{Username: authValue.Username}).
+					Times(1).
+					Return([]expstore.Expressions{namedExp, byName}, nil)
+				evaluator.EXPECT().
+					CompileCached(eval.CacheKey{ID: namedExp.ExpressionID, UpdatedAt: namedExp.UpdatedAt}, namedExp.Expression).
+					Times(1).
+					Return(namedProg, nil)
+				evaluator.EXPECT().
+					CompileCached(eval.CacheKey{ID: byName.ExpressionID, UpdatedAt: byName.UpdatedAt}, byName.Expression).
+					Times(1).
+					Return(byNameProg, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Error - name already in use",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+				"name":          "taken",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, &pq.Error{Code: "23505"})
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Error - reference cycle",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    "@{" + dependent.ExpressionID.String() + "}",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				cyclic := "@{" + dependent.ExpressionID.String() + "}"
+				evaluator.EXPECT().Validate(cyclic).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(cyclic).Times(1).DoAndReturn(eval.Compile)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), dependent.ExpressionID).Times(1).Return(dependent, nil)
+				evaluator.EXPECT().
+					CompileCached(eval.CacheKey{ID: dependent.ExpressionID, UpdatedAt: dependent.UpdatedAt}, dependent.Expression).
+					Times(1).
+					DoAndReturn(func(_ eval.CacheKey, expression string) (*eval.Program, error) {
+						return eval.Compile(expression)
+					})
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "reference cycle")
+			},
+		},
+		{
+			name: "Happy path - tags are kept unless replaced",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				taggedExp := exp
+				taggedExp.Tags = []string{"fraud"}
+
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(taggedExp, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: updatedExp.ExpressionID,
+						Expression:   updatedExp.Expression,
+						Variables:    []string{},
+						Tags:         taggedExp.Tags,
+						UpdatedAt:    updatedExp.UpdatedAt,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(updatedExp, nil)
+				evaluator.EXPECT().Invalidate(updatedExp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Happy path - tags are replaced",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+				"tags":          []string{},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				taggedExp := exp
+				taggedExp.Tags = []string{"fraud"}
+
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(taggedExp, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: updatedExp.ExpressionID,
+						Expression:   updatedExp.Expression,
+						Variables:    []string{},
+						Tags:         []string{},
+						UpdatedAt:    updatedExp.UpdatedAt,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(updatedExp, nil)
+				evaluator.EXPECT().Invalidate(updatedExp.ExpressionID).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Error - missing expression ID",
+			body: map[string]interface{}{
+				"expression_id": "",
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(0)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - invalid expression ID",
+			body: map[string]interface{}{
+				"expression_id": "invalid-expression-id",
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - empty expression",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    "",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate("").Times(1).Return([]eval.Diagnostic{{Offset: 0, Line: 1, Column: 1, Code: eval.CodeEmptyExpression, Message: "empty expression"}})
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - database - get expression not found",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - database - get expression internal error",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Error - expression does not belong to authenticated user",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: anotherAuthValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Error - database - update expression not found",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: updatedExp.ExpressionID,
+						Expression:   updatedExp.Expression,
+						Variables:    []string{},
+						UpdatedAt:    updatedExp.UpdatedAt,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - database - update expression internal error",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(updatedExp.Expression).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(updatedExp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+
+				updateArg := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: updatedExp.ExpressionID,
+						Expression:   updatedExp.Expression,
+						Variables:    []string{},
+						UpdatedAt:    updatedExp.UpdatedAt,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().
+					UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArg)).
+					Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Error - not authenticated",
+			body: map[string]interface{}{
+				"expression_id": updatedExp.ExpressionID,
+				"expression":    updatedExp.Expression,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(0)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := "/v1/expressions"
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, qMap := getExpToEvaluate(t, authValue.Username)
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	vars := eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)}
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+	pinnedQueries := map[string]string{"version": "1"}
+	for k, v := range qMap {
+		pinnedQueries[k] = v
+	}
+
+	testCases := []struct {
+		name          string
+		id            string
+		queries       map[string]string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Happy path",
+			id:          exp.ExpressionID.String(),
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(prog, vars).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - references to other expressions",
+			id:          exp.ExpressionID.String(),
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				referenced := getExp(t, authValue.Username)
+				referenced.Expression = "y OR z"
+				referencedProg, err := eval.Compile(referenced.Expression)
+				require.NoError(t, err)
+
+				referencing := exp
+				referencing.Expression = fmt.Sprintf("x AND @{%s}", referenced.ExpressionID)
+				referencingProg, err := eval.Compile(referencing.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(referencing, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, referencing.Expression).Times(1).Return(referencingProg, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), referenced.ExpressionID).Times(1).Return(referenced, nil)
+				evaluator.EXPECT().
+					CompileCached(eval.CacheKey{ID: referenced.ExpressionID, UpdatedAt: referenced.UpdatedAt}, referenced.Expression).
+					Times(1).
+					Return(referencedProg, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), vars).Times(1).DoAndReturn(func(prog *eval.Program, vars eval.Bindings) (bool, error) {
+					require.Equal(t, "(x AND (y OR z))", prog.Root().String())
+					return prog.Eval(vars)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - references by name",
+			id:          exp.ExpressionID.String(),
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				referenced := getExp(t, authValue.Username)
+				referenced.Expression = "y OR z"
+				referenced.Name = "y_or_z"
+				referencedProg, err := eval.Compile(referenced.Expression)
+				require.NoError(t, err)
+
+				referencing := exp
+				referencing.Expression = "NOT @y_or_z OR x"
+				referencingProg, err := eval.Compile(referencing.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(referencing, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, referencing.Expression).Times(1).Return(referencingProg, nil)
+				store.EXPECT().
+					GetExpressionByName(gomock.Any(), expstore.GetExpressionByNameParams{Username: authValue.Username, Name: referenced.Name}).
+					Times(1).
+					Return(referenced, nil)
+				evaluator.EXPECT().
+					CompileCached(eval.CacheKey{ID: referenced.ExpressionID, UpdatedAt: referenced.UpdatedAt}, referenced.Expression).
+					Times(1).
+					Return(referencedProg, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), vars).Times(1).DoAndReturn(func(prog *eval.Program, vars eval.Bindings) (bool, error) {
+					require.Equal(t, "(NOT (y OR z) OR x)", prog.Root().String())
+					return prog.Eval(vars)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - previous version",
+			id:          exp.ExpressionID.String(),
+			queries:     pinnedQueries,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				version := expstore.ExpressionVersions{ExpressionID: exp.ExpressionID, Version: 1, Expression: "x AND y"}
+				versionProg, err := eval.Compile(version.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				versionArgs := expstore.GetExpressionVersionParams{ExpressionID: exp.ExpressionID, Version: 1}
+				store.EXPECT().GetExpressionVersion(gomock.Any(), versionArgs).Times(1).Return(version, nil)
+				evaluator.EXPECT().Compile(version.Expression).Times(1).Return(versionProg, nil)
+				pinnedVars := eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false)}
+				evaluator.EXPECT().Eval(versionProg, pinnedVars).Times(1).Return(false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"result":false}`, recorder.Body.String())
+			},
+		},
+		{
+			name:        "Error - version not found",
+			id:          exp.ExpressionID.String(),
+			queries:     pinnedQueries,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(1).Return(expstore.ExpressionVersions{}, sql.ErrNoRows)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid version",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "y": "0", "z": "1", "version": "0"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - variable named version",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "version": "1"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				versioned := exp
+				versioned.Expression = "x AND version"
+				versionedProg, err := eval.Compile(versioned.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(versioned, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, versioned.Expression).Times(1).Return(versionedProg, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(0)
+				versionedVars := eval.Bindings{"x": eval.Bool(true), "version": eval.Bool(true)}
+				evaluator.EXPECT().Eval(versionedProg, versionedVars).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - reference to an expression of another user",
+			id:          exp.ExpressionID.String(),
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				foreign := getExp(t, "Jane Doe")
+
+				referencing := exp
+				referencing.Expression = fmt.Sprintf("x AND @{%s}", foreign.ExpressionID)
+				referencingProg, err := eval.Compile(referencing.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(referencing, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, referencing.Expression).Times(1).Return(referencingProg, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), foreign.ExpressionID).Times(1).Return(foreign, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - multi-character variable names",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"is_admin": "1", "x": "0", "xy": "1"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				namedExp := exp
+				namedExp.Expression = "is_admin AND (x OR xy)"
+				namedProg, err := eval.Compile(namedExp.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(namedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, namedExp.Expression).Times(1).Return(namedProg, nil)
+				evaluator.EXPECT().
+					Eval(namedProg, eval.Bindings{"is_admin": eval.Bool(true), "x": eval.Bool(false), "xy": eval.Bool(true)}).
+					Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - uppercase query keys bind lowercase variables",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"X": "1", "Y": "0", "Z": "1"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(prog, vars).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - comparisons over typed variables",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"age": "21", "country": "BR", "admin": "0"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				typedExp := exp
+				typedExp.Expression = `admin OR (age >= 18 AND country IN ("BR", "PT"))`
+				typedProg, err := eval.Compile(typedExp.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(typedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, typedExp.Expression).Times(1).Return(typedProg, nil)
+				evaluator.EXPECT().
+					Eval(typedProg, eval.Bindings{"admin": eval.Bool(false), "age": eval.Number(21), "country": eval.String("BR")}).
+					Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid number",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"age": "old"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				typedExp := exp
+				typedExp.Expression = "age >= 18"
+				typedProg, err := eval.Compile(typedExp.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(typedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, typedExp.Expression).Times(1).Return(typedProg, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid variable value",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "y": "2", "z": "1"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - explain",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "y": "0", "z": "1", "explain": "true"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				explanation, err := eval.New(eval.Config{}).Explain(prog, vars)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Explain(prog, vars).Times(1).Return(explanation, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRes expmodel.EvaluateExpressionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.True(t, gotRes.Result)
+				require.NotNil(t, gotRes.Explanation)
+				require.Equal(t, "OR", gotRes.Explanation.Tree.Operator)
+				require.Len(t, gotRes.Explanation.Tree.Children, 2)
+				require.Equal(t, map[string]interface{}{"z": true}, gotRes.Explanation.Justification)
+			},
+		},
+		{
+			name:        "Error - explain an expression of another user",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "y": "0", "z": "1", "explain": "true"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				foreign := exp
+				foreign.Username = "Jane Doe"
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(foreign, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Explain(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), exp.Expression)
+			},
+		},
+		{
+			name:        "Error - previous version of an expression of another user",
+			id:          exp.ExpressionID.String(),
+			queries:     pinnedQueries,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				foreign := exp
+				foreign.Username = "Jane Doe"
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(foreign, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				store.EXPECT().GetExpressionVersion(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid explain value",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "y": "0", "z": "1", "explain": "sure"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Explain(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - kleene mode with unknown result",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "z": "0", "mode": "kleene"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				partial := eval.Bindings{"x": eval.Bool(true), "z": eval.Bool(false)}
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().EvalKleene(prog, partial).Times(1).Return(eval.New(eval.Config{}).EvalKleene(prog, partial))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRes expmodel.EvaluateKleeneResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.Equal(t, "unknown", gotRes.Result)
+				require.Equal(t, []string{"y"}, gotRes.Deciding)
+			},
+		},
+		{
+			name:        "Happy path - kleene mode with known result",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"z": "1", "mode": "Kleene"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				partial := eval.Bindings{"z": eval.Bool(true)}
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalKleene(prog, partial).Times(1).Return(eval.New(eval.Config{}).EvalKleene(prog, partial))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"result":"true"}`, recorder.Body.String())
+			},
+		},
+		{
+			name:        "Error - kleene mode with a value of the wrong type",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "mode": "kleene"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalKleene(prog, eval.Bindings{"x": eval.Bool(true)}).Times(1).
+					Return(eval.KleeneResult{}, fmt.Errorf("%w: x expects a bool, received 2", eval.ErrInvalidValue))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrInvalidEvaluateExpression.String())
+			},
+		},
+		{
+			name:        "Happy path - boolean mode",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "y": "0", "z": "1", "mode": "boolean"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(prog, vars).Times(1).Return(true, nil)
+				evaluator.EXPECT().EvalKleene(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid mode",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "y": "0", "z": "1", "mode": "fuzzy"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().EvalKleene(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - kleene mode with explain",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"x": "1", "mode": "kleene", "explain": "true"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Explain(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().EvalKleene(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid expression id",
+			id:          "invalid-id",
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - get expression not found",
+			id:          exp.ExpressionID.String(),
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - get expression internal error",
+			id:          exp.ExpressionID.String(),
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - missing expression required arguments",
+			id:          exp.ExpressionID.String(),
+			queries:     nil,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - not authenticated",
+			id:          exp.ExpressionID.String(),
+			queries:     qMap,
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := "/v1/evaluate/" + tc.id
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			q := req.URL.Query()
+			for k, v := range tc.queries {
+				if v != "" {
+					q.Set(k, v)
+				}
+			}
+			req.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestEvaluateLinkedProgramCache(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	referenced := getExp(t, authValue.Username)
+	referenced.Expression = "y OR z"
+	referencedProg, err := eval.Compile(referenced.Expression)
+	require.NoError(t, err)
+
+	referencing, qMap := getExpToEvaluate(t, authValue.Username)
+	referencing.Expression = fmt.Sprintf("x AND @{%s}", referenced.ExpressionID)
+	referencingProg, err := eval.Compile(referencing.Expression)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockedexpstore.NewMockStore(ctrl)
+	evaluator := mockedeval.NewMockEvaluator(ctrl)
+
+	// the references are resolved by the first evaluation only, the second one reuses the linked program
+	store.EXPECT().GetExpressionByID(gomock.Any(), referencing.ExpressionID).Times(2).Return(referencing, nil)
+	evaluator.EXPECT().
+		CompileCached(eval.CacheKey{ID: referencing.ExpressionID, UpdatedAt: referencing.UpdatedAt}, referencing.Expression).
+		Times(1).
+		Return(referencingProg, nil)
+	store.EXPECT().GetExpressionByID(gomock.Any(), referenced.ExpressionID).Times(1).Return(referenced, nil)
+	evaluator.EXPECT().
+		CompileCached(eval.CacheKey{ID: referenced.ExpressionID, UpdatedAt: referenced.UpdatedAt}, referenced.Expression).
+		Times(1).
+		Return(referencedProg, nil)
+	evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(prog *eval.Program, vars eval.Bindings) (bool, error) {
+		require.Equal(t, "(x AND (y OR z))", prog.Root().String())
+		return prog.Eval(vars)
+	})
+
+	config, err := env.NewConfig()
+	require.NoError(t, err)
+
+	server, err := expserver.New(config, store, evaluator)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/v1/evaluate/"+referencing.ExpressionID.String(), nil)
+		require.NoError(t, err)
+
+		q := req.URL.Query()
+		for k, v := range qMap {
+			q.Set(k, v)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		addAuthorization(req, authValue.BearerToken, authmid.AuthorizationTypeBearer)
+		server.Router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+}
+
+func TestEvaluateByName(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, qMap := getExpToEvaluate(t, authValue.Username)
+	exp.Name = "rule.main"
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	vars := eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)}
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+	getArgs := expstore.GetExpressionByNameParams{Username: authValue.Username, Name: exp.Name}
+
+	testCases := []struct {
+		name          string
+		queries       map[string]string
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "Happy path",
+			queries: qMap,
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByName(gomock.Any(), getArgs).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(prog, vars).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"result":true}`, recorder.Body.String())
+			},
+		},
+		{
+			name:    "Error - missing variables",
+			queries: map[string]string{"x": "1"},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByName(gomock.Any(), getArgs).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Error - database - get expression not found",
+			queries: qMap,
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByName(gomock.Any(), getArgs).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/v1/evaluate/by-name/"+exp.Name, nil)
+			require.NoError(t, err)
+
+			q := req.URL.Query()
+			for k, v := range tc.queries {
+				q.Add(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			addAuthorization(req, authValue.BearerToken, authmid.AuthorizationTypeBearer)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestEvaluateJSON(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, _ := getExpToEvaluate(t, authValue.Username)
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	vars := eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)}
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+
+	testCases := []struct {
+		name          string
+		id            string
+		body          map[string]interface{}
+		queries       map[string]string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path - booleans, numbers and strings",
+			id:   exp.ExpressionID.String(),
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true, "y": 0, "z": "1"},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(prog, vars).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateExpressionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.True(t, res.Result)
+				require.Nil(t, res.Explanation)
+			},
+		},
+		{
+			name: "Happy path - unknown variables are ignored when not strict",
+			id:   exp.ExpressionID.String(),
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true, "y": false, "z": true, "w": "anything"},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(prog, vars).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Happy path - explain",
+			id:   exp.ExpressionID.String(),
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": 1, "y": 0, "z": 1},
+			},
+			queries:     map[string]string{"explain": "true"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				explanation, err := eval.New(eval.Config{}).Explain(prog, vars)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Explain(prog, vars).Times(1).Return(explanation, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateExpressionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.True(t, res.Result)
+				require.NotNil(t, res.Explanation)
+			},
+		},
+		{
+			name: "Error - explain an expression of another user",
+			id:   exp.ExpressionID.String(),
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": 1, "y": 0, "z": 1},
+			},
+			queries:     map[string]string{"explain": "true"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				foreign := exp
+				foreign.Username = "Jane Doe"
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(foreign, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Explain(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), exp.Expression)
+			},
+		},
+		{
+			name: "Error - every missing, invalid and unknown variable is reported",
+			id:   exp.ExpressionID.String(),
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": 2, "y": "yes", "w": true},
+				"strict":    true,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var res experrors.InvalidVariablesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, experrors.ErrInvalidVariables.String(), res.Error)
+				require.Len(t, res.Variables, 4)
+
+				got := make(map[string]string, len(res.Variables))
+				for _, v := range res.Variables {
+					got[v.Name] = v.Code
+				}
+				require.Equal(t, map[string]string{
+					"w": string(eval.CodeUnknownVariable),
+					"x": string(eval.CodeInvalidValue),
+					"y": string(eval.CodeInvalidValue),
+					"z": string(eval.CodeMissingVariable),
+				}, got)
+			},
+		},
+		{
+			name:        "Error - malformed body",
+			id:          exp.ExpressionID.String(),
+			body:        map[string]interface{}{"variables": []int{1, 0, 1}},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - expression not found",
+			id:   exp.ExpressionID.String(),
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true, "y": false, "z": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - not authenticated",
+			id:   exp.ExpressionID.String(),
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true, "y": false, "z": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/v1/evaluate/" + tc.id
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			q := req.URL.Query()
+			for k, v := range tc.queries {
+				q.Set(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestEvaluateAdHoc(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	rawExp := "(x AND y) OR z"
+	prog, err := eval.Compile(rawExp)
+	require.NoError(t, err)
+	vars := eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)}
+
+	// the store is only expected to be accessed to resolve references, any other access fails the test
+	testCases := []struct {
+		name          string
+		body          map[string]interface{}
+		queries       map[string]string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path",
+			body: map[string]interface{}{
+				"expression": rawExp,
+				"variables":  map[string]interface{}{"x": true, "y": 0, "z": "1"},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(prog, vars).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateExpressionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.True(t, res.Result)
+			},
+		},
+		{
+			name: "Happy path - references to other expressions",
+			body: map[string]interface{}{
+				"expression": "@x_and_y OR z",
+				"variables":  map[string]interface{}{"x": true, "y": false, "z": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				referenced := getExp(t, authValue.Username)
+				referenced.Expression = "x AND y"
+				referenced.Name = "x_and_y"
+				referencedProg, err := eval.Compile(referenced.Expression)
+				require.NoError(t, err)
+				referencingProg, err := eval.Compile("@x_and_y OR z")
+				require.NoError(t, err)
+
+				evaluator.EXPECT().Validate("@x_and_y OR z").Times(1).Return(nil)
+				evaluator.EXPECT().Compile("@x_and_y OR z").Times(1).Return(referencingProg, nil)
+				store.EXPECT().
+					GetExpressionByName(gomock.Any(), expstore.GetExpressionByNameParams{Username: authValue.Username, Name: referenced.Name}).
+					Times(1).
+					Return(referenced, nil)
+				evaluator.EXPECT().
+					CompileCached(eval.CacheKey{ID: referenced.ExpressionID, UpdatedAt: referenced.UpdatedAt}, referenced.Expression).
+					Times(1).
+					Return(referencedProg, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), vars).Times(1).DoAndReturn(func(prog *eval.Program, vars eval.Bindings) (bool, error) {
+					require.Equal(t, "((x AND y) OR z)", prog.Root().String())
+					return prog.Eval(vars)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateExpressionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.True(t, res.Result)
+			},
+		},
+		{
+			name: "Error - reference to an expression of another user",
+			body: map[string]interface{}{
+				"expression": "@{" + uuid.NewString() + "} OR z",
+				"variables":  map[string]interface{}{"z": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				foreign := getExp(t, "Jane Doe")
+
+				evaluator.EXPECT().Validate(gomock.Any()).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(1).DoAndReturn(eval.Compile)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(1).Return(foreign, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "no such expression")
+			},
+		},
+		{
+			name: "Happy path - explain",
+			body: map[string]interface{}{
+				"expression": rawExp,
+				"variables":  map[string]interface{}{"x": true, "y": false, "z": true},
+			},
+			queries:     map[string]string{"explain": "1"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				explanation, err := eval.New(eval.Config{}).Explain(prog, vars)
+				require.NoError(t, err)
+
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Explain(prog, vars).Times(1).Return(explanation, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateExpressionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.NotNil(t, res.Explanation)
+				require.Equal(t, map[string]interface{}{"z": true}, res.Explanation.Justification)
+			},
+		},
+		{
+			name: "Error - invalid expression",
+			body: map[string]interface{}{
+				"expression": "(x AND",
+				"variables":  map[string]interface{}{"x": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate("(x AND").Times(1).Return(eval.Validate("(x AND"))
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchDiagnostics(t, recorder.Body, experrors.ErrInvalidExpression, eval.Validate("(x AND"))
+			},
+		},
+		{
+			name: "Error - invalid variables",
+			body: map[string]interface{}{
+				"expression": rawExp,
+				"variables":  map[string]interface{}{"x": "maybe", "y": false, "w": true},
+				"strict":     true,
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Eval(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var res experrors.InvalidVariablesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, experrors.ErrInvalidVariables.String(), res.Error)
+				require.Len(t, res.Variables, 3)
+			},
+		},
+		{
+			name: "Error - missing expression",
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(0)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Error - not authenticated",
+			body: map[string]interface{}{
+				"expression": rawExp,
+				"variables":  map[string]interface{}{"x": true, "y": false, "z": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(0)
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/v1/evaluate", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			q := req.URL.Query()
+			for k, v := range tc.queries {
+				q.Set(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	// (x AND y) OR z, decided by the given values
+	matching, _ := getExpToEvaluate(t, authValue.Username)
+	// x AND w, which the missing w could make false
+	undecided, _ := getExpToEvaluate(t, authValue.Username)
+	undecided.Expression = "x AND w"
+	// NOT z, which is false
+	failing, _ := getExpToEvaluate(t, authValue.Username)
+	failing.Expression = "NOT z"
+	exps := []expstore.Expressions{matching, undecided, failing}
+
+	progs := make([]*eval.Program, len(exps))
+	for i, exp := range exps {
+		prog, err := eval.Compile(exp.Expression)
+		require.NoError(t, err)
+		progs[i] = prog
+	}
+	vars := eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)}
+
+	testCases := []struct {
+		name          string
+		body          map[string]interface{}
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path",
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true, "y": 0, "z": "1"},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				listArgs := expstore.ListExpressionsByUsernameParams{Username: authValue.Username}
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return(exps, nil)
+
+				for i, exp := range exps {
+					cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+					evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(progs[i], nil)
+				}
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.MatchExpressionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, []uuid.UUID{matching.ExpressionID}, res.ExpressionIDs)
+			},
+		},
+		{
+			name: "Happy path - filtered by tag",
+			body: map[string]interface{}{
+				"variables": vars,
+				"tag":       " fraud ",
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				tagged := make([]expstore.Expressions, len(exps))
+				copy(tagged, exps)
+				tagged[0].Tags = []string{"payments"}
+				tagged[1].Tags = []string{"fraud"}
+				tagged[1].Expression = "x OR w"
+
+				listArgs := expstore.ListExpressionsByUsernameParams{Username: authValue.Username}
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return(tagged, nil)
+				for _, exp := range tagged {
+					prog, err := eval.Compile(exp.Expression)
+					require.NoError(t, err)
+
+					cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+					evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				}
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.MatchExpressionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, []uuid.UUID{undecided.ExpressionID}, res.ExpressionIDs)
+			},
+		},
+		{
+			name: "Happy path - no expressions",
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				listArgs := expstore.ListExpressionsByUsernameParams{Username: authValue.Username}
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{}, nil)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.MatchExpressionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.NotNil(t, res.ExpressionIDs)
+				require.Empty(t, res.ExpressionIDs)
+			},
+		},
+		{
+			name: "Error - invalid variable value",
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": map[string]interface{}{"is": "yes"}},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var res experrors.InvalidVariablesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Len(t, res.Variables, 1)
+				require.Equal(t, string(eval.CodeInvalidValue), res.Variables[0].Code)
+			},
+		},
+		{
+			name: "Error - database",
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Error - not authenticated",
+			body: map[string]interface{}{
+				"variables": map[string]interface{}{"x": true},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().ListExpressionsByUsername(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/v1/evaluate/match", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestMatchFollowsDeletes(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, _ := getExpToEvaluate(t, authValue.Username)
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockedexpstore.NewMockStore(ctrl)
+	evaluator := mockedeval.NewMockEvaluator(ctrl)
+
+	// the index is loaded once, later matches use it
+	listArgs := expstore.ListExpressionsByUsernameParams{Username: authValue.Username}
+	store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp}, nil)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+	evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(2).Return(prog, nil)
+	store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+	// deleting lists the expressions again to find dependents
+	store.EXPECT().ListExpressionsByUsername(gomock.Any(), listArgs).Times(1).Return([]expstore.Expressions{exp}, nil)
+	store.EXPECT().DeleteExpressionByID(gomock.Any(), expstore.DeleteExpressionByIDParams{ExpressionID: exp.ExpressionID}).Times(1).Return(int64(1), nil)
+	evaluator.EXPECT().Invalidate(exp.ExpressionID).Times(1)
+
+	config, err := env.NewConfig()
+	require.NoError(t, err)
+
+	server, err := expserver.New(config, store, evaluator)
+	require.NoError(t, err)
+
+	match := func() []uuid.UUID {
+		data, err := json.Marshal(map[string]interface{}{
+			"variables": map[string]interface{}{"z": true},
+		})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/v1/evaluate/match", bytes.NewReader(data))
+		require.NoError(t, err)
+		addAuthorization(req, authValue.BearerToken, authmid.AuthorizationTypeBearer)
+
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var res expmodel.MatchExpressionsResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		return res.ExpressionIDs
+	}
+
+	require.Equal(t, []uuid.UUID{exp.ExpressionID}, match())
+
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/expressions/%s", exp.ExpressionID), nil)
+	require.NoError(t, err)
+	addAuthorization(req, authValue.BearerToken, authmid.AuthorizationTypeBearer)
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	require.Empty(t, match())
+}
+
+func TestEvaluateBatch(t *testing.T) {
+	const mimeNDJSON = "application/x-ndjson"
+
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, _ := getExpToEvaluate(t, authValue.Username)
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+	batch := []eval.Bindings{
+		{"x": eval.Bool(true), "y": eval.Bool(true), "z": eval.Bool(false)},
+		{"x": eval.Bool(false), "y": eval.Bool(false), "z": eval.Bool(false)},
+	}
+
+	testCases := []struct {
+		name          string
+		id            string
+		body          string
+		contentType   string
+		queries       map[string]string
+		maxBatchSize  int
+		maxBatchBytes int
+		batchTimeout  time.Duration
+		bodyReader    func() io.Reader
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Happy path - JSON array",
+			id:          exp.ExpressionID.String(),
+			body:        `[{"x": true, "y": 1, "z": false}, {"x": 0, "y": "0", "z": 0}]`,
+			contentType: gin.MIMEJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), prog, batch).Times(1).Return([]eval.BatchResult{{Value: true}, {Value: false}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Len(t, res.Results, 2)
+				for i, expected := range []bool{true, false} {
+					require.Equal(t, i, res.Results[i].Index)
+					require.NotNil(t, res.Results[i].Result)
+					require.Equal(t, expected, *res.Results[i].Result)
+				}
+			},
+		},
+		{
+			name:        "Happy path - NDJSON with an invalid item",
+			id:          exp.ExpressionID.String(),
+			body:        "{\"x\": true, \"y\": true, \"z\": false}\n\n{\"x\": 2}\n{\"x\": false, \"y\": false, \"z\": false}\n",
+			contentType: mimeNDJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), prog, batch).Times(1).Return([]eval.BatchResult{{Value: true}, {Value: false}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, mimeNDJSON, recorder.Header().Get("Content-Type"))
+
+				var results []expmodel.EvaluateBatchResult
+				dec := json.NewDecoder(recorder.Body)
+				for dec.More() {
+					var res expmodel.EvaluateBatchResult
+					require.NoError(t, dec.Decode(&res))
+					results = append(results, res)
+				}
+				require.Len(t, results, 3)
+
+				require.True(t, *results[0].Result)
+				require.Nil(t, results[1].Result)
+				require.Equal(t, experrors.ErrInvalidVariables.String(), results[1].Error)
+				require.Len(t, results[1].Variables, 3)
+				require.False(t, *results[2].Result)
+			},
+		},
+		{
+			name:        "Happy path - strict batch reports unknown variables per item",
+			id:          exp.ExpressionID.String(),
+			body:        `[{"x": true, "y": true, "z": false, "w": true}]`,
+			contentType: gin.MIMEJSON,
+			queries:     map[string]string{"strict": "true"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), prog, []eval.Bindings{}).Times(1).Return([]eval.BatchResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Len(t, res.Results, 1)
+				require.Nil(t, res.Results[0].Result)
+				require.Equal(t, "w", res.Results[0].Variables[0].Name)
+				require.Equal(t, string(eval.CodeUnknownVariable), res.Results[0].Variables[0].Code)
+			},
+		},
+		{
+			name:         "Error - batch too large",
+			id:           exp.ExpressionID.String(),
+			body:         `[{"x": 1, "y": 1, "z": 1}, {"x": 1, "y": 1, "z": 1}, {"x": 1, "y": 1, "z": 1}]`,
+			contentType:  gin.MIMEJSON,
+			maxBatchSize: 2,
+			bearerToken:  authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:        "Happy path - items that cannot be evaluated report their error",
+			id:          exp.ExpressionID.String(),
+			body:        `[{"x": true, "y": 1, "z": false}, {"x": 0, "y": "0", "z": 0}]`,
+			contentType: gin.MIMEJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().
+					EvalBatch(gomock.Any(), prog, batch).
+					Times(1).
+					Return([]eval.BatchResult{{Err: eval.ErrUnresolvedReference}, {Value: false}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res expmodel.EvaluateBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Len(t, res.Results, 2)
+				require.Nil(t, res.Results[0].Result)
+				require.Contains(t, res.Results[0].Error, experrors.ErrInvalidEvaluateExpression.String())
+				require.NotNil(t, res.Results[1].Result)
+				require.False(t, *res.Results[1].Result)
+			},
+		},
+		{
+			name:          "Error - body too large",
+			id:            exp.ExpressionID.String(),
+			body:          `[{"x": 1, "y": 1, "z": 1}, {"x": 1, "y": 1, "z": 1}]`,
+			contentType:   gin.MIMEJSON,
+			maxBatchBytes: 32,
+			bearerToken:   authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:         "Error - timed out reading the body",
+			id:           exp.ExpressionID.String(),
+			contentType:  mimeNDJSON,
+			batchTimeout: 20 * time.Millisecond,
+			bodyReader: func() io.Reader {
+				// a client that sends one item and then stalls
+				r, w := io.Pipe()
+				go w.Write([]byte("{\"x\": 1, \"y\": 1, \"z\": 1}\n"))
+				return r
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - body is not an array",
+			id:          exp.ExpressionID.String(),
+			body:        `{"x": 1, "y": 1, "z": 1}`,
+			contentType: gin.MIMEJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - malformed NDJSON line",
+			id:          exp.ExpressionID.String(),
+			body:        "{\"x\": 1, \"y\": 1, \"z\": 1}\n{\"x\": \n",
+			contentType: mimeNDJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "line 2")
+			},
+		},
+		{
+			name:        "Error - timed out",
+			id:          exp.ExpressionID.String(),
+			body:        `[{"x": 1, "y": 1, "z": 0}]`,
+			contentType: gin.MIMEJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), prog, batch[:1]).Times(1).Return(nil, context.DeadlineExceeded)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression not found",
+			id:          exp.ExpressionID.String(),
+			body:        `[]`,
+			contentType: gin.MIMEJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - not authenticated",
+			id:          exp.ExpressionID.String(),
+			body:        `[]`,
+			contentType: gin.MIMEJSON,
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().CompileCached(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().EvalBatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+			config.EvalBatchMaxSize = tc.maxBatchSize
+			config.EvalBatchMaxBytes = tc.maxBatchBytes
+			config.EvalBatchTimeout = tc.batchTimeout
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			var body io.Reader = strings.NewReader(tc.body)
+			if tc.bodyReader != nil {
+				body = tc.bodyReader()
+			}
+
+			url := "/v1/evaluate/" + tc.id + "/batch"
+			req, err := http.NewRequest(http.MethodPost, url, body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tc.contentType)
+
+			q := req.URL.Query()
+			for k, v := range tc.queries {
+				q.Set(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	stats := eval.CacheStats{Hits: 10, Misses: 2, Evictions: 1, Size: 5, Capacity: 100}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request)
+		buildStubs    func(evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Happy path",
+			setupAuth: func(t *testing.T, request *http.Request) {
+				addAuthorization(request, authmid.BearerToken1, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().CacheStats().Times(1).Return(stats)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotStats expmodel.CacheStatsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotStats)
+				require.NoError(t, err)
+				require.Equal(t, stats.Hits, gotStats.Hits)
+				require.Equal(t, stats.Misses, gotStats.Misses)
+				require.Equal(t, stats.Evictions, gotStats.Evictions)
+				require.Equal(t, stats.Size, gotStats.Size)
+				require.Equal(t, stats.Capacity, gotStats.Capacity)
+			},
+		},
+		{
+			name: "Error - not an admin",
+			setupAuth: func(t *testing.T, request *http.Request) {
+				addAuthorization(request, authmid.BearerToken2, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().CacheStats().Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "Error - not authenticated",
+			setupAuth: func(t *testing.T, request *http.Request) {},
+			buildStubs: func(evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().CacheStats().Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+			config.AdminUserIDs = []string{"12345"}
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/v1/evaluate/cache-stats", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestTruthTable(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, _ := getExpToEvaluate(t, authValue.Username)
+	otherUserExp, _ := getExpToEvaluate(t, "Jane Doe")
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	table, err := eval.New(eval.Config{}).TruthTable(prog)
+	require.NoError(t, err)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+
+	testCases := []struct {
+		name          string
+		id            string
+		accept        string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Happy path - JSON",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().TruthTable(prog).Times(1).Return(table, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotTable expmodel.TruthTableResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotTable)
+				require.NoError(t, err)
+				require.Equal(t, table.Variables, gotTable.Variables)
+				require.Len(t, gotTable.Rows, len(table.Rows))
+				for i, row := range table.Rows {
+					require.Equal(t, row.Values, gotTable.Rows[i].Values)
+					require.Equal(t, row.Result, gotTable.Rows[i].Result)
+				}
+			},
+		},
+		{
+			name:        "Happy path - CSV",
+			id:          exp.ExpressionID.String(),
+			accept:      "text/csv",
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().TruthTable(prog).Times(1).Return(table, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				require.Equal(t,
+					"x,y,z,result\n"+
+						"0,0,0,0\n0,0,1,1\n0,1,0,0\n0,1,1,1\n"+
+						"1,0,0,0\n1,0,1,1\n1,1,0,1\n1,1,1,1\n",
+					recorder.Body.String(),
+				)
+			},
+		},
+		{
+			name:        "Error - too many variables",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().TruthTable(prog).Times(1).Return(eval.TruthTable{}, eval.ErrTooManyVariables)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - invalid expression id",
+			id:          "invalid-id",
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().TruthTable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - get expression not found",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().TruthTable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression does not belong to authenticated user",
+			id:          otherUserExp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), otherUserExp.ExpressionID).Times(1).Return(otherUserExp, nil)
+				evaluator.EXPECT().TruthTable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - not authenticated",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().TruthTable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/v1/expressions/%s/truth-table", tc.id)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestSatisfiable(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, _ := getExpToEvaluate(t, authValue.Username)
+	otherUserExp, _ := getExpToEvaluate(t, "Jane Doe")
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	sat := eval.SatResult{
+		Satisfiable:    true,
+		Witness:        eval.Assignment{"x": false, "y": false, "z": true},
+		Counterexample: eval.Assignment{"x": false, "y": false, "z": false},
+	}
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+
+	testCases := []struct {
+		name          string
+		id            string
+		bearerToken   authmid.BearerToken
+		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Happy path",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Satisfiable(prog).Times(1).Return(sat, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotSat expmodel.SatisfiableResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotSat)
+				require.NoError(t, err)
+				require.True(t, gotSat.Satisfiable)
+				require.False(t, gotSat.Tautology)
+				require.False(t, gotSat.Contradiction)
+				require.Equal(t, map[string]bool(sat.Witness), gotSat.Witness)
+				require.Equal(t, map[string]bool(sat.Counterexample), gotSat.Counterexample)
+			},
+		},
+		{
+			name:        "Happy path - contradiction",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Satisfiable(prog).Times(1).Return(eval.SatResult{
+					Contradiction:  true,
+					Counterexample: eval.Assignment{"x": false, "y": false, "z": false},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotSat expmodel.SatisfiableResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotSat)
+				require.NoError(t, err)
+				require.False(t, gotSat.Satisfiable)
+				require.True(t, gotSat.Contradiction)
+				require.Nil(t, gotSat.Witness)
+			},
+		},
+		{
+			name:        "Error - invalid expression id",
+			id:          "invalid-id",
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Satisfiable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - database - get expression internal error",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				evaluator.EXPECT().Satisfiable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - expression does not belong to authenticated user",
+			id:          otherUserExp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), otherUserExp.ExpressionID).Times(1).Return(otherUserExp, nil)
+				evaluator.EXPECT().Satisfiable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - not authenticated",
+			id:          exp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Satisfiable(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
+
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/v1/expressions/%s/satisfiable", tc.id)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, tc.bearerToken)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	authValue := authmid.AuthValue{
+		BearerToken: authmid.BearerToken1,
+		UserID:      "12345",
+		Username:    "John Doe",
+	}
+
+	exp, _ := getExpToEvaluate(t, authValue.Username)
+	otherUserExp, _ := getExpToEvaluate(t, "Jane Doe")
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+
+	rawExp := "z OR (x AND y)"
+	rawProg, err := eval.Compile(rawExp)
+	require.NoError(t, err)
+	diffExp := "x AND y"
+	diffProg, err := eval.Compile(diffExp)
+	require.NoError(t, err)
+	counterexample := eval.Assignment{"x": false, "y": false, "z": true}
 
 	testCases := []struct {
 		name          string
-		queries       map[string]string
+		body          map[string]interface{}
 		bearerToken   authmid.BearerToken
 		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
-		buildStubs    func(store *mockedexpstore.MockStore)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name:        "Happy path - list all",
-			queries:     map[string]string{},
+			name: "Happy path - stored and raw expressions are equivalent",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression_id": exp.ExpressionID.String()},
+				"right": map[string]string{"expression": rawExp},
+			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(1).Return(exps, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(rawProg, nil)
+				evaluator.EXPECT().Equivalent(prog, rawProg).Times(1).Return(eval.Equivalence{Equivalent: true}, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchList(t, recorder.Body, exps)
+
+				var gotRes expmodel.CompareExpressionsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.True(t, gotRes.Equivalent)
+				require.Nil(t, gotRes.Counterexample)
 			},
 		},
 		{
-			name: "Happy path - list paginated",
-			queries: map[string]string{
-				"page_id":   strconv.Itoa(pageID),
-				"page_size": strconv.Itoa(pageSize),
+			name: "Happy path - raw expressions are linked",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression": "z OR @x_and_y"},
+				"right": map[string]string{"expression": rawExp},
 			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(1).Return(exps, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				referenced := getExp(t, authValue.Username)
+				referenced.Expression = "x AND y"
+				referenced.Name = "x_and_y"
+				referencedProg, err := eval.Compile(referenced.Expression)
+				require.NoError(t, err)
+				referencingProg, err := eval.Compile("z OR @x_and_y")
+				require.NoError(t, err)
 
-				listPagArgs := expstore.ListPaginatedExpressionsParams{
-					Limit:  int32(pageSize),
-					Offset: int32(pageSize) * int32(pageID-1),
-				}
-				store.EXPECT().ListPaginatedExpressions(gomock.Any(), listPagArgs).Times(1).Return(exps[:5], nil)
+				evaluator.EXPECT().Validate("z OR @x_and_y").Times(1).Return(nil)
+				evaluator.EXPECT().Compile("z OR @x_and_y").Times(1).Return(referencingProg, nil)
+				store.EXPECT().
+					GetExpressionByName(gomock.Any(), expstore.GetExpressionByNameParams{Username: authValue.Username, Name: referenced.Name}).
+					Times(1).
+					Return(referenced, nil)
+				evaluator.EXPECT().
+					CompileCached(eval.CacheKey{ID: referenced.ExpressionID, UpdatedAt: referenced.UpdatedAt}, referenced.Expression).
+					Times(1).
+					Return(referencedProg, nil)
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(rawProg, nil)
+				evaluator.EXPECT().
+					Equivalent(gomock.Any(), rawProg).
+					Times(1).
+					DoAndReturn(func(left, right *eval.Program) (eval.Equivalence, error) {
+						require.Equal(t, "(z OR (x AND y))", left.Root().String())
+						return eval.Equivalence{Equivalent: true}, nil
+					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchList(t, recorder.Body, exps[:5])
+
+				var gotRes expmodel.CompareExpressionsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.True(t, gotRes.Equivalent)
 			},
 		},
 		{
-			name: "Error - invalid pagination data - invalid page ID",
-			queries: map[string]string{
-				"page_id":   "invalid-page-id",
-				"page_size": strconv.Itoa(pageSize),
+			name: "Error - raw expression references an unknown expression",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression": "z OR @missing"},
+				"right": map[string]string{"expression": rawExp},
 			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(0).Return(nil, nil)
-				store.EXPECT().ListPaginatedExpressions(gomock.Any(), gomock.Any()).Times(0).Return(nil, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				referencingProg, err := eval.Compile("z OR @missing")
+				require.NoError(t, err)
+
+				evaluator.EXPECT().Validate("z OR @missing").Times(1).Return(nil)
+				evaluator.EXPECT().Compile("z OR @missing").Times(1).Return(referencingProg, nil)
+				store.EXPECT().
+					GetExpressionByName(gomock.Any(), expstore.GetExpressionByNameParams{Username: authValue.Username, Name: "missing"}).
+					Times(1).
+					Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().Equivalent(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), experrors.ErrInvalidLeftOperand.String())
 			},
 		},
 		{
-			name: "Error - invalid pagination data - invalid page size",
-			queries: map[string]string{
-				"page_id":   strconv.Itoa(pageID),
-				"page_size": "invalid-page-size",
+			name: "Happy path - raw expressions differ",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression": rawExp},
+				"right": map[string]string{"expression": diffExp},
 			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(0).Return(nil, nil)
-				store.EXPECT().ListPaginatedExpressions(gomock.Any(), gomock.Any()).Times(0).Return(nil, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(rawProg, nil)
+				evaluator.EXPECT().Validate(diffExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(diffExp).Times(1).Return(diffProg, nil)
+				evaluator.EXPECT().Equivalent(rawProg, diffProg).Times(1).Return(eval.Equivalence{
+					Counterexample: counterexample,
+					LeftResult:     true,
+					RightResult:    false,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRes expmodel.CompareExpressionsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.False(t, gotRes.Equivalent)
+				require.Equal(t, map[string]bool(counterexample), gotRes.Counterexample)
+				require.True(t, gotRes.LeftResult)
+				require.False(t, gotRes.RightResult)
+			},
+		},
+		{
+			name: "Error - operand with both expression ID and expression",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression_id": exp.ExpressionID.String(), "expression": rawExp},
+				"right": map[string]string{"expression": diffExp},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Equivalent(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name: "Error - missing pagination data - missing page id",
-			queries: map[string]string{
-				"page_id":   "",
-				"page_size": strconv.Itoa(pageSize),
+			name: "Error - missing operand",
+			body: map[string]interface{}{
+				"left": map[string]string{"expression": rawExp},
 			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(0).Return(nil, nil)
-				store.EXPECT().ListPaginatedExpressions(gomock.Any(), gomock.Any()).Times(0).Return(nil, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(rawProg, nil)
+				evaluator.EXPECT().Equivalent(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name: "Error - missing pagination data - missing page size",
-			queries: map[string]string{
-				"page_id":   strconv.Itoa(pageID),
-				"page_size": "",
+			name: "Error - invalid raw expression",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression": "x AND"},
+				"right": map[string]string{"expression": diffExp},
 			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(0).Return(nil, nil)
-				store.EXPECT().ListPaginatedExpressions(gomock.Any(), gomock.Any()).Times(0).Return(nil, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate("x AND").Times(1).Return(eval.Validate("x AND"))
+				evaluator.EXPECT().Compile(gomock.Any()).Times(0)
+				evaluator.EXPECT().Equivalent(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchDiagnostics(t, recorder.Body, experrors.ErrInvalidLeftOperand, eval.Validate("x AND"))
 			},
 		},
 		{
-			name:        "Error - failed to list expressions - not found",
-			queries:     map[string]string{},
+			name: "Error - expression does not belong to authenticated user",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression_id": otherUserExp.ExpressionID.String()},
+				"right": map[string]string{"expression": rawExp},
+			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(1).Return([]expstore.Expressions{}, sql.ErrNoRows)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), otherUserExp.ExpressionID).Times(1).Return(otherUserExp, nil)
+				evaluator.EXPECT().Equivalent(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
 		{
-			name:        "Error - failed to list expressions - internal error",
-			queries:     map[string]string{},
+			name: "Error - database - get expression not found",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression": rawExp},
+				"right": map[string]string{"expression_id": exp.ExpressionID.String()},
+			},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().ListExpressions(gomock.Any()).Times(1).Return([]expstore.Expressions{}, sql.ErrConnDone)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(rawExp).Times(1).Return(nil)
+				evaluator.EXPECT().Compile(rawExp).Times(1).Return(rawProg, nil)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().Equivalent(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Error - not authenticated",
+			body: map[string]interface{}{
+				"left":  map[string]string{"expression": rawExp},
+				"right": map[string]string{"expression": diffExp},
+			},
+			bearerToken: authValue.BearerToken,
+			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				evaluator.EXPECT().Validate(gomock.Any()).Times(0)
+				evaluator.EXPECT().Equivalent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
-		//{
-		//	name:        "Error - invalid expression ID",
-		//	id:          "some-invalid-id",
-		//	bearerToken: authValue.BearerToken,
-		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
-		//	},
-		//	buildStubs: func(store *mockedexpstore.MockStore) {
-		//		store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
-		//	},
-		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-		//		require.Equal(t, http.StatusBadRequest, recorder.Code)
-		//	},
-		//},
-		//{
-		//	name:        "Error - database - expression not found",
-		//	id:          exp.ExpressionID.String(),
-		//	bearerToken: authValue.BearerToken,
-		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
-		//	},
-		//	buildStubs: func(store *mockedexpstore.MockStore) {
-		//		store.EXPECT().
-		//			GetExpressionByID(gomock.Any(), exp.ExpressionID).
-		//			Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
-		//	},
-		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-		//		require.Equal(t, http.StatusNotFound, recorder.Code)
-		//	},
-		//},
-		//{
-		//	name:        "Error - database - internal error",
-		//	id:          exp.ExpressionID.String(),
-		//	bearerToken: authValue.BearerToken,
-		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
-		//	},
-		//	buildStubs: func(store *mockedexpstore.MockStore) {
-		//		store.EXPECT().
-		//			GetExpressionByID(gomock.Any(), exp.ExpressionID).
-		//			Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
-		//	},
-		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-		//		require.Equal(t, http.StatusInternalServerError, recorder.Code)
-		//	},
-		//},
-		//{
-		//	name:        "Error - expression does not belong to authenticated user",
-		//	id:          exp.ExpressionID.String(),
-		//	bearerToken: anotherAuthValue.BearerToken,
-		//	setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
-		//		addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
-		//	},
-		//	buildStubs: func(store *mockedexpstore.MockStore) {
-		//		store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-		//	},
-		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-		//		require.Equal(t, http.StatusNotFound, recorder.Code)
-		//	},
-		//},
-		//{
-		//	name:        "Error - not authenticated",
-		//	id:          exp.ExpressionID.String(),
-		//	bearerToken: authValue.BearerToken,
-		//	setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
-		//	buildStubs: func(store *mockedexpstore.MockStore) {
-		//		store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
-		//	},
-		//	checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-		//		require.Equal(t, http.StatusUnauthorized, recorder.Code)
-		//	},
-		//},
 	}
 
 	for _, tc := range testCases {
@@ -705,28 +5582,22 @@ func TestList(t *testing.T) {
 			defer ctrl.Finish()
 
 			store := mockedexpstore.NewMockStore(ctrl)
-			tc.buildStubs(store)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
 
 			config, err := env.NewConfig()
 			require.NoError(t, err)
 
-			server, err := expserver.New(config, store, nil)
-			require.NoError(t, err)
-			recorder := httptest.NewRecorder()
-
-			url := "/v1/expressions"
-
-			req, err := http.NewRequest(http.MethodGet, url, nil)
+			server, err := expserver.New(config, store, evaluator)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/v1/expressions/compare", bytes.NewReader(data))
 			require.NoError(t, err)
 
-			q := req.URL.Query()
-			for k, v := range tc.queries {
-				if v != "" {
-					q.Set(k, v)
-				}
-			}
-			req.URL.RawQuery = q.Encode()
-
 			tc.setupAuth(t, req, tc.bearerToken)
 			server.Router.ServeHTTP(recorder, req)
 			tc.checkResponse(t, recorder)
@@ -734,235 +5605,303 @@ func TestList(t *testing.T) {
 	}
 }
 
-func TestUpdate(t *testing.T) {
+func TestSimplify(t *testing.T) {
 	authValue := authmid.AuthValue{
 		BearerToken: authmid.BearerToken1,
 		UserID:      "12345",
 		Username:    "John Doe",
 	}
-	anotherAuthValue := authmid.AuthValue{
-		BearerToken: authmid.BearerToken2,
-		UserID:      "98765",
-		Username:    "Jane Doe",
-	}
 
 	exp := getExp(t, authValue.Username)
-	now := time.Now()
-	updatedExp := expstore.Expressions{
-		RowID:        exp.RowID,
-		ExpressionID: exp.ExpressionID,
-		Expression:   "(0 OR 0)",
-		Username:     exp.Username,
-		CreatedAt:    exp.CreatedAt,
-		UpdatedAt:    now,
-	}
+	exp.Expression = "((x OR x) AND (x OR y))"
+	exp.Version = 1
+	otherUserExp := getExp(t, "Jane Doe")
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	simplified, err := eval.Compile("x")
+	require.NoError(t, err)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
+
+	updatedExp := exp
+	updatedExp.Expression = "x"
+	updatedExp.UpdatedAt = time.Now()
+	updatedExp.Version = 2
 
 	testCases := []struct {
 		name          string
-		body          map[string]interface{}
+		id            string
+		queries       map[string]string
 		bearerToken   authmid.BearerToken
 		setupAuth     func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken)
-		buildStubs    func(store *mockedexpstore.MockStore)
+		buildStubs    func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "Happy path",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    updatedExp.Expression,
-			},
+			name:        "Happy path",
+			id:          exp.ExpressionID.String(),
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
 				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Simplify(prog, eval.SimplifyOptions{}).Times(1).Return(simplified, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
 
-				updateArg := expstore.UpdateExpressionParams{
-					ExpressionID: updatedExp.ExpressionID,
-					Expression:   updatedExp.Expression,
-					UpdatedAt:    updatedExp.UpdatedAt,
+				var gotRes expmodel.SimplifyExpressionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.Equal(t, exp.ExpressionID, gotRes.ExpressionID)
+				require.Equal(t, exp.Expression, gotRes.Original)
+				require.Equal(t, "x", gotRes.Simplified)
+				require.True(t, gotRes.Changed)
+				require.False(t, gotRes.Saved)
+			},
+		},
+		{
+			name:        "Happy path - minimize and save",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"minimize": "true", "save": "true"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Simplify(prog, eval.SimplifyOptions{Minimize: true}).Times(1).Return(simplified, nil)
+				updateArgs := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID: exp.ExpressionID,
+						Expression:   "x",
+						Variables:    []string{"x"},
+						UpdatedAt:    time.Now(),
+					},
+					Author: authValue.Username,
 				}
-				store.EXPECT().
-					UpdateExpression(gomock.Any(), EqUpdateExpParams(updateArg)).
-					Times(1).Return(updatedExp, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArgs)).Times(1).Return(updatedExp, nil)
+				evaluator.EXPECT().Invalidate(exp.ExpressionID).Times(1)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchUpdate(t, recorder.Body, updatedExp)
+				require.Equal(t, `"2"`, recorder.Header().Get("ETag"))
+
+				var gotRes expmodel.SimplifyExpressionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.Equal(t, "x", gotRes.Simplified)
+				require.True(t, gotRes.Saved)
 			},
 		},
 		{
-			name: "Error - missing expression ID",
-			body: map[string]interface{}{
-				"expression_id": "",
-				"expression":    updatedExp.Expression,
-			},
+			name:        "Happy path - saving an already simplified expression is a no-op",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"save": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
-				store.EXPECT().UpdateExpression(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				simplifiedExp := exp
+				simplifiedExp.Expression = "  ((x) AND   y ) "
+				simplifiedProg, err := eval.Compile(simplifiedExp.Expression)
+				require.NoError(t, err)
+
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(simplifiedExp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, simplifiedExp.Expression).Times(1).Return(simplifiedProg, nil)
+				evaluator.EXPECT().Simplify(simplifiedProg, eval.SimplifyOptions{}).Times(1).Return(simplifiedProg, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
+
+				var gotRes expmodel.SimplifyExpressionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.Equal(t, "x AND y", gotRes.Simplified)
+				require.False(t, gotRes.Changed)
+				require.False(t, gotRes.Saved)
 			},
 		},
 		{
-			name: "Error - invalid expression ID",
-			body: map[string]interface{}{
-				"expression_id": "invalid-expression-id",
-				"expression":    updatedExp.Expression,
-			},
+			name:        "Happy path - save with If-Match listing the current entity tag",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"save": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"1"`)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
-				store.EXPECT().UpdateExpression(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Simplify(prog, eval.SimplifyOptions{}).Times(1).Return(simplified, nil)
+				updateArgs := expstore.UpdateExpressionTxParams{
+					UpdateExpressionParams: expstore.UpdateExpressionParams{
+						ExpressionID:    exp.ExpressionID,
+						Expression:      "x",
+						Variables:       []string{"x"},
+						UpdatedAt:       time.Now(),
+						ExpectedVersion: exp.Version,
+					},
+					Author: authValue.Username,
+				}
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), EqUpdateExpParams(updateArgs)).Times(1).Return(updatedExp, nil)
+				evaluator.EXPECT().Invalidate(exp.ExpressionID).Times(1)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"2"`, recorder.Header().Get("ETag"))
 			},
 		},
 		{
-			name: "Error - empty expression",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    "",
-			},
+			name:        "Error - If-Match does not match the current entity tag",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"save": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"7"`)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
-				store.EXPECT().UpdateExpression(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().Simplify(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				require.Equal(t, `"1"`, recorder.Header().Get("ETag"))
 			},
 		},
 		{
-			name: "Error - database - get expression not found",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    updatedExp.Expression,
-			},
+			name:        "Error - expression modified between the read and the save",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"save": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+				request.Header.Set("If-Match", `"1"`)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
-				store.EXPECT().UpdateExpression(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Simplify(prog, eval.SimplifyOptions{}).Times(1).Return(simplified, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
 			},
 		},
 		{
-			name: "Error - database - get expression internal error",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    updatedExp.Expression,
-			},
+			name:        "Happy path - save skipped when the expression is already simplified",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"save": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
-				store.EXPECT().UpdateExpression(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(updatedExp, nil)
+				updatedKey := eval.CacheKey{ID: updatedExp.ExpressionID, UpdatedAt: updatedExp.UpdatedAt}
+				evaluator.EXPECT().CompileCached(updatedKey, updatedExp.Expression).Times(1).Return(simplified, nil)
+				evaluator.EXPECT().Simplify(simplified, eval.SimplifyOptions{}).Times(1).Return(simplified, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRes expmodel.SimplifyExpressionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.False(t, gotRes.Changed)
+				require.False(t, gotRes.Saved)
 			},
 		},
 		{
-			name: "Error - expression does not belong to authenticated user",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    updatedExp.Expression,
-			},
-			bearerToken: anotherAuthValue.BearerToken,
+			name:        "Error - invalid flag value",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"save": "maybe"},
+			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-				store.EXPECT().UpdateExpression(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Simplify(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name: "Error - database - update expression not found",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    updatedExp.Expression,
-			},
+			name:        "Error - too many variables to minimize",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"minimize": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
 				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-
-				updateArg := expstore.UpdateExpressionParams{
-					ExpressionID: updatedExp.ExpressionID,
-					Expression:   updatedExp.Expression,
-					UpdatedAt:    updatedExp.UpdatedAt,
-				}
-				store.EXPECT().
-					UpdateExpression(gomock.Any(), EqUpdateExpParams(updateArg)).
-					Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Simplify(prog, eval.SimplifyOptions{Minimize: true}).Times(1).Return(nil, eval.ErrTooManyVariables)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name: "Error - database - update expression internal error",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    updatedExp.Expression,
-			},
+			name:        "Error - database - update expression internal error",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"save": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
-			buildStubs: func(store *mockedexpstore.MockStore) {
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
 				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-
-				updateArg := expstore.UpdateExpressionParams{
-					ExpressionID: updatedExp.ExpressionID,
-					Expression:   updatedExp.Expression,
-					UpdatedAt:    updatedExp.UpdatedAt,
-				}
-				store.EXPECT().
-					UpdateExpression(gomock.Any(), EqUpdateExpParams(updateArg)).
-					Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().Simplify(prog, eval.SimplifyOptions{}).Times(1).Return(simplified, nil)
+				store.EXPECT().UpdateExpressionTx(gomock.Any(), gomock.Any()).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
+				evaluator.EXPECT().Invalidate(gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 		{
-			name: "Error - not authenticated",
-			body: map[string]interface{}{
-				"expression_id": updatedExp.ExpressionID,
-				"expression":    updatedExp.Expression,
+			name:        "Error - expression does not belong to authenticated user",
+			id:          otherUserExp.ExpressionID.String(),
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), otherUserExp.ExpressionID).Times(1).Return(otherUserExp, nil)
+				evaluator.EXPECT().Simplify(gomock.Any(), gomock.Any()).Times(0)
 			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - not authenticated",
+			id:          exp.ExpressionID.String(),
 			bearerToken: authValue.BearerToken,
 			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
-			buildStubs: func(store *mockedexpstore.MockStore) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
-				store.EXPECT().UpdateExpression(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().Simplify(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -976,22 +5915,25 @@ func TestUpdate(t *testing.T) {
 			defer ctrl.Finish()
 
 			store := mockedexpstore.NewMockStore(ctrl)
-			tc.buildStubs(store)
+			evaluator := mockedeval.NewMockEvaluator(ctrl)
+			tc.buildStubs(store, evaluator)
 
 			config, err := env.NewConfig()
 			require.NoError(t, err)
 
-			server, err := expserver.New(config, store, nil)
+			server, err := expserver.New(config, store, evaluator)
 			require.NoError(t, err)
 			recorder := httptest.NewRecorder()
 
-			url := "/v1/expressions"
-
-			data, err := json.Marshal(tc.body)
+			url := fmt.Sprintf("/v1/expressions/%s/simplify", tc.id)
+			req, err := http.NewRequest(http.MethodPost, url, nil)
 			require.NoError(t, err)
 
-			req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
-			require.NoError(t, err)
+			q := req.URL.Query()
+			for k, v := range tc.queries {
+				q.Set(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
 
 			tc.setupAuth(t, req, tc.bearerToken)
 			server.Router.ServeHTTP(recorder, req)
@@ -1000,14 +5942,20 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
-func TestEvaluate(t *testing.T) {
+func TestNormalForm(t *testing.T) {
 	authValue := authmid.AuthValue{
 		BearerToken: authmid.BearerToken1,
 		UserID:      "12345",
 		Username:    "John Doe",
 	}
 
-	exp, qMap := getExpToEvaluate(t, authValue.Username)
+	exp, _ := getExpToEvaluate(t, authValue.Username)
+	otherUserExp, _ := getExpToEvaluate(t, "Jane Doe")
+	prog, err := eval.Compile(exp.Expression)
+	require.NoError(t, err)
+	cnf, err := eval.New(eval.Config{}).NormalForm(prog, eval.NormalFormOptions{Form: eval.FormCNF})
+	require.NoError(t, err)
+	cacheKey := eval.CacheKey{ID: exp.ExpressionID, UpdatedAt: exp.UpdatedAt}
 
 	testCases := []struct {
 		name          string
@@ -1021,92 +5969,137 @@ func TestEvaluate(t *testing.T) {
 		{
 			name:        "Happy path",
 			id:          exp.ExpressionID.String(),
-			queries:     qMap,
+			queries:     map[string]string{"form": "CNF"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
 				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-				evaluator.EXPECT().EvalLogicExp(gomock.Any()).Times(1).Return(true)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().NormalForm(prog, eval.NormalFormOptions{Form: eval.FormCNF}).Times(1).Return(cnf, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRes expmodel.NormalFormResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.Equal(t, exp.ExpressionID, gotRes.ExpressionID)
+				require.Equal(t, eval.FormCNF, gotRes.Form)
+				require.Equal(t, cnf.Expression, gotRes.Expression)
+				require.Len(t, gotRes.Clauses, len(cnf.Clauses))
+				for i, c := range cnf.Clauses {
+					require.Len(t, gotRes.Clauses[i], len(c))
+					for j, lit := range c {
+						require.Equal(t, lit.Variable, gotRes.Clauses[i][j].Variable)
+						require.Equal(t, lit.Negated, gotRes.Clauses[i][j].Negated)
+					}
+				}
 			},
 		},
 		{
-			name:        "Error - invalid expression id",
-			id:          "invalid-id",
-			queries:     qMap,
+			name:        "Happy path - Tseitin encoding",
+			id:          exp.ExpressionID.String(),
+			queries:     map[string]string{"form": "cnf", "tseitin": "true"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0).Return(expstore.Expressions{}, nil)
-				evaluator.EXPECT().EvalLogicExp(gomock.Any()).Times(0).Return(false)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().
+					NormalForm(prog, eval.NormalFormOptions{Form: eval.FormCNF, Tseitin: true}).
+					Times(1).Return(eval.NormalForm{Form: eval.FormCNF, Auxiliary: []string{"_t1", "_t2"}}, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRes expmodel.NormalFormResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRes)
+				require.NoError(t, err)
+				require.Equal(t, []string{"_t1", "_t2"}, gotRes.Auxiliary)
 			},
 		},
 		{
-			name:        "Error - database - get expression not found",
+			name:        "Error - missing form",
 			id:          exp.ExpressionID.String(),
-			queries:     qMap,
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrNoRows)
-				evaluator.EXPECT().EvalLogicExp(gomock.Any()).Times(0).Return(false)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().NormalForm(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name:        "Error - database - get expression internal error",
+			name:        "Error - unsupported form",
 			id:          exp.ExpressionID.String(),
-			queries:     qMap,
+			queries:     map[string]string{"form": "anf"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(expstore.Expressions{}, sql.ErrConnDone)
-				evaluator.EXPECT().EvalLogicExp(gomock.Any()).Times(0).Return(false)
+				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().
+					NormalForm(prog, eval.NormalFormOptions{Form: "anf"}).
+					Times(1).Return(eval.NormalForm{}, eval.ErrUnsupportedForm)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name:        "Error - missing expression required arguments",
+			name:        "Error - normal form too large",
 			id:          exp.ExpressionID.String(),
-			queries:     nil,
+			queries:     map[string]string{"form": "dnf"},
 			bearerToken: authValue.BearerToken,
 			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
 				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
 			},
 			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
 				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(1).Return(exp, nil)
-				evaluator.EXPECT().EvalLogicExp(gomock.Any()).Times(0).Return(false)
+				evaluator.EXPECT().CompileCached(cacheKey, exp.Expression).Times(1).Return(prog, nil)
+				evaluator.EXPECT().
+					NormalForm(prog, eval.NormalFormOptions{Form: eval.FormDNF}).
+					Times(1).Return(eval.NormalForm{}, eval.ErrNormalFormTooLarge)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name:        "Error - expression does not belong to authenticated user",
+			id:          otherUserExp.ExpressionID.String(),
+			queries:     map[string]string{"form": "cnf"},
+			bearerToken: authValue.BearerToken,
+			setupAuth: func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {
+				addAuthorization(request, bearerToken, authmid.AuthorizationTypeBearer)
+			},
+			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
+				store.EXPECT().GetExpressionByID(gomock.Any(), otherUserExp.ExpressionID).Times(1).Return(otherUserExp, nil)
+				evaluator.EXPECT().NormalForm(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
 		{
 			name:        "Error - not authenticated",
 			id:          exp.ExpressionID.String(),
-			queries:     qMap,
+			queries:     map[string]string{"form": "cnf"},
 			bearerToken: authValue.BearerToken,
 			setupAuth:   func(t *testing.T, request *http.Request, bearerToken authmid.BearerToken) {},
 			buildStubs: func(store *mockedexpstore.MockStore, evaluator *mockedeval.MockEvaluator) {
-				store.EXPECT().GetExpressionByID(gomock.Any(), exp.ExpressionID).Times(0).Return(expstore.Expressions{}, nil)
-				evaluator.EXPECT().EvalLogicExp(gomock.Any()).Times(0).Return(false)
+				store.EXPECT().GetExpressionByID(gomock.Any(), gomock.Any()).Times(0)
+				evaluator.EXPECT().NormalForm(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -1130,16 +6123,13 @@ func TestEvaluate(t *testing.T) {
 			require.NoError(t, err)
 			recorder := httptest.NewRecorder()
 
-			url := "/v1/evaluate/" + tc.id
-
+			url := fmt.Sprintf("/v1/expressions/%s/normal-form", tc.id)
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
 			q := req.URL.Query()
 			for k, v := range tc.queries {
-				if v != "" {
-					q.Set(k, v)
-				}
+				q.Set(k, v)
 			}
 			req.URL.RawQuery = q.Encode()
 
@@ -1218,10 +6208,24 @@ func requireBodyMatchCreate(t *testing.T, body *bytes.Buffer, exp expstore.Expre
 	require.Equal(t, expectedExp.ExpressionID, gotExp.ExpressionID)
 	require.Equal(t, expectedExp.Expression, gotExp.Expression)
 	require.Equal(t, expectedExp.Username, gotExp.Username)
+	require.Equal(t, expectedExp.Name, gotExp.Name)
 	require.WithinDuration(t, expectedExp.CreatedAt, gotExp.CreatedAt, time.Second)
 	require.WithinDuration(t, expectedExp.UpdatedAt, gotExp.UpdatedAt, time.Second)
 }
 
+// requireDependents is a helper function to validate the response from the dependents handler
+func requireDependents(t *testing.T, body *bytes.Buffer, expID uuid.UUID, dependents ...uuid.UUID) {
+	var res expmodel.DependentsResponse
+	require.NoError(t, json.Unmarshal(body.Bytes(), &res))
+	require.Equal(t, expID, res.ExpressionID)
+
+	got := make([]uuid.UUID, 0, len(res.Dependents))
+	for _, dep := range res.Dependents {
+		got = append(got, dep.ExpressionID)
+	}
+	require.Equal(t, append([]uuid.UUID{}, dependents...), got)
+}
+
 // requireBodyMatchGet is a helper function to validate the response from the get handler
 func requireBodyMatchGet(t *testing.T, body *bytes.Buffer, exp expstore.Expressions) {
 	data, err := io.ReadAll(body)
@@ -1241,6 +6245,7 @@ func requireBodyMatchGet(t *testing.T, body *bytes.Buffer, exp expstore.Expressi
 	require.Equal(t, expectedExp.ExpressionID, gotExp.ExpressionID)
 	require.Equal(t, expectedExp.Expression, gotExp.Expression)
 	require.Equal(t, expectedExp.Username, gotExp.Username)
+	require.Equal(t, expectedExp.Name, gotExp.Name)
 	require.WithinDuration(t, expectedExp.CreatedAt, gotExp.CreatedAt, time.Second)
 	require.WithinDuration(t, expectedExp.UpdatedAt, gotExp.UpdatedAt, time.Second)
 }
@@ -1254,17 +6259,42 @@ func requireBodyMatchList(t *testing.T, body *bytes.Buffer, exps []expstore.Expr
 	var gotExps []expmodel.ListExpressionsResponse
 	err = json.Unmarshal(data, &gotExps)
 	require.NoError(t, err)
+	require.Len(t, gotExps, len(exps))
+
+	// only the fields of the response are listed, not the internal columns of the rows
+	var rawExps []map[string]interface{}
+	err = json.Unmarshal(data, &rawExps)
+	require.NoError(t, err)
+	for _, rawExp := range rawExps {
+		require.NotContains(t, rawExp, "rowID")
+		require.NotContains(t, rawExp, "deletedAt")
+		require.NotContains(t, rawExp, "variables")
+	}
 
 	for i, gotExp := range gotExps {
 		require.Empty(t, gotExp.RowID)
 		require.Equal(t, exps[i].ExpressionID, gotExp.ExpressionID)
 		require.Equal(t, exps[i].Expression, gotExp.Expression)
 		require.Equal(t, exps[i].Username, gotExp.Username)
+		require.Equal(t, exps[i].Version, gotExp.Version)
 		require.WithinDuration(t, exps[i].CreatedAt, gotExp.CreatedAt, time.Second)
 		require.WithinDuration(t, exps[i].UpdatedAt, gotExp.UpdatedAt, time.Second)
 	}
 }
 
+// requireBodyMatchPage is a helper function to validate the response from the list handler by cursor
+func requireBodyMatchPage(t *testing.T, body *bytes.Buffer, exps []expstore.Expressions, nextCursor string) {
+	var page struct {
+		Items      json.RawMessage `json:"items"`
+		NextCursor string          `json:"next_cursor"`
+	}
+	err := json.NewDecoder(body).Decode(&page)
+	require.NoError(t, err)
+	require.Equal(t, nextCursor, page.NextCursor)
+
+	requireBodyMatchList(t, bytes.NewBuffer(page.Items), exps)
+}
+
 // requireBodyMatchUpdate is a helper function to validate the response from the update handler
 func requireBodyMatchUpdate(t *testing.T, body *bytes.Buffer, exp expstore.Expressions) {
 	data, err := io.ReadAll(body)
@@ -1287,3 +6317,23 @@ func requireBodyMatchUpdate(t *testing.T, body *bytes.Buffer, exp expstore.Expre
 	require.WithinDuration(t, expectedExp.CreatedAt, updatedExp.CreatedAt, time.Second)
 	require.WithinDuration(t, expectedExp.UpdatedAt, updatedExp.UpdatedAt, time.Second)
 }
+
+// requireBodyMatchDiagnostics is a helper function to validate the response of an invalid expression
+func requireBodyMatchDiagnostics(t *testing.T, body *bytes.Buffer, expErr experrors.ExpressionError, diags []eval.Diagnostic) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotRes experrors.InvalidExpressionResponse
+	err = json.Unmarshal(data, &gotRes)
+	require.NoError(t, err)
+	require.Equal(t, expErr.String(), gotRes.Error)
+	require.Len(t, gotRes.Diagnostics, len(diags))
+
+	for i, diag := range diags {
+		require.Equal(t, diag.Offset, gotRes.Diagnostics[i].Offset)
+		require.Equal(t, diag.Line, gotRes.Diagnostics[i].Line)
+		require.Equal(t, diag.Column, gotRes.Diagnostics[i].Column)
+		require.Equal(t, string(diag.Code), gotRes.Diagnostics[i].Code)
+		require.Equal(t, diag.Message, gotRes.Diagnostics[i].Message)
+	}
+}
@@ -84,3 +84,30 @@ func AuthMiddleware() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// AdminMiddleware restricts the request to the given users. It must run after AuthMiddleware. Authenticated users
+// that are not listed are refused with 403, and nobody is an admin when no user is given.
+func AdminMiddleware(userIDs []string) gin.HandlerFunc {
+	admins := make(map[string]struct{}, len(userIDs))
+	for _, userID := range userIDs {
+		admins[userID] = struct{}{}
+	}
+
+	return func(ctx *gin.Context) {
+		payload, _ := ctx.Get(AuthorizationPayloadKey)
+		authValue, ok := payload.(AuthValue)
+		if !ok {
+			err := errors.New("authorization not provided")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, parse.ErrorAsJSON(err))
+			return
+		}
+
+		if _, ok := admins[authValue.UserID]; !ok {
+			err := errors.New("admin access required")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, parse.ErrorAsJSON(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
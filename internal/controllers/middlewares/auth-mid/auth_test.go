@@ -105,6 +105,67 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestAdminMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		bearerToken   authmid.BearerToken
+		adminUserIDs  []string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "Happy path",
+			bearerToken:  authmid.BearerToken1,
+			adminUserIDs: []string{"12345"},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:         "Error - Not an Admin",
+			bearerToken:  authmid.BearerToken2,
+			adminUserIDs: []string{"12345"},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:        "Error - No Admins",
+			bearerToken: authmid.BearerToken1,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := env.NewConfig()
+			require.NoError(t, err)
+			server, err := expserver.New(config, nil, nil)
+			require.NoError(t, err)
+
+			adminPath := "/admin"
+
+			server.Router.GET(
+				adminPath,
+				authmid.AuthMiddleware(),
+				authmid.AdminMiddleware(tc.adminUserIDs),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, map[string]interface{}{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, adminPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(req, tc.bearerToken, authmid.AuthorizationTypeBearer)
+			server.Router.ServeHTTP(recorder, req)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 // addAuthorization adds authorization to the given request
 func addAuthorization(
 	request *http.Request,
@@ -8,11 +8,16 @@ import (
 // swagger:route POST /v1/expressions Expressions createExpressionParams
 // Creates an expression and returns the created expression to the user.
 //
+// Expressions may reference other expressions of the same user as @{id}, or as @name for named expressions, which evaluates to the value of the referenced expression. References must point to existing expressions and must not lead back to the expression itself.
+//
+// The optional name must be unique among the expressions of the user and follows the syntax of variable names: a letter or an underscore followed by letters, digits, underscores or dots.
+//
 // This route can only be used by authenticated users.
 // responses:
 //   201: createExpressionResponseWrapper
 //   400: createExpressionBadRequest
 //   401: createExpressionUnauthorized
+//   409: createExpressionConflict
 //   500: createExpressionInternalServerError
 //
 //     Security:
@@ -29,15 +34,18 @@ type createExpressionParamsWrapper struct {
 // The response body contains the information of the created expression.
 // swagger:response
 type createExpressionResponseWrapper struct {
+	// The entity tag of the expression, to send in the If-Match header of updates.
+	ETag string `json:"ETag"`
 	// in:body
 	Body expmodel.CreateExpressionResponse
 }
 
-// Error response when the request body is not well formatted.
+// Error response when the request body is not well formatted or the expression is invalid.
+// Invalid expressions include the list of syntax diagnostics found.
 // swagger:response
 type createExpressionBadRequest struct {
 	// in:body
-	Body experrors.ErrorResponse
+	Body experrors.InvalidExpressionResponse
 }
 
 // Error response when the user does not provide authorization information to perform the request.
@@ -47,6 +55,13 @@ type createExpressionUnauthorized struct {
 	Body experrors.ErrorResponse
 }
 
+// Error response when another expression of the user already has the given name.
+// swagger:response
+type createExpressionConflict struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
 // Error response when there is an internal server error.
 // swagger:response
 type createExpressionInternalServerError struct {
@@ -78,6 +93,8 @@ type getExpressionParamsWrapper struct {
 // The response body contains the information of the created expression.
 // swagger:response
 type getExpressionResponseWrapper struct {
+	// The entity tag of the expression, to send in the If-Match header of updates.
+	ETag string `json:"ETag"`
 	// in:body
 	Body expmodel.CreateExpressionResponse
 }
@@ -110,11 +127,63 @@ type getExpressionInternalServerError struct {
 	Body experrors.ErrorResponse
 }
 
+// swagger:route GET /v1/expressions/by-name/{name} Expressions getExpressionByNameParams
+// Retrieves an expression of the user by its name.
+//
+// This route can only be used by authenticated users, and only finds expressions that the user created.
+// responses:
+//   200: getExpressionByNameResponseWrapper
+//   401: getExpressionByNameUnauthorized
+//   404: getExpressionByNameNotFound
+//   500: getExpressionByNameInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters getExpressionByNameParams
+type getExpressionByNameParamsWrapper struct {
+	// The name of the expression.
+	// in:path
+	Name string `json:"name"`
+}
+
+// The response body contains the information of the expression.
+// swagger:response
+type getExpressionByNameResponseWrapper struct {
+	// in:body
+	Body expmodel.GetExpressionResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type getExpressionByNameUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user has no expression with the given name.
+// swagger:response
+type getExpressionByNameNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type getExpressionByNameInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
 // swagger:route GET /v1/expressions Expressions listExpressionsParams
 // Retrieves a list of expressions.
 //
 // Pagination parameters shall be sent together to query paginated data or not sent at all to query all rows.
 //
+// A page size sent with a cursor, empty for the first page, pages through the expressions instead, in any sort: the body is then an object with the expressions in items and the cursor of the next page in next_cursor. The cursor is also returned in the x-pagination-next-cursor header and the URL of the next page in the Link header, and all are left out on the last page. A page size sent without a page ID nor a cursor is rejected.
+//
+// Filters are combined, and the pagination headers count the expressions matching them. Expressions are listed in creation order unless sorted otherwise.
+//
 // This route can only be used by authenticated users.
 // responses:
 //   200: listExpressionsResponseWrapper
@@ -136,16 +205,70 @@ type listExpressionsParamsWrapper struct {
 	// in:query
 	// min: 1
 	PageSize int64 `json:"page_size"`
+
+	// The cursor returned by the previous page, in next_cursor, or empty for the first page. It must be sent with the sort and order of the page that returned it. Cannot be sent with a page ID.
+	// in:query
+	Cursor string `json:"cursor"`
+
+	// Counts the expressions in the pagination headers when paging by cursor. Pages by ID are always counted.
+	// in:query
+	Total bool `json:"total"`
+
+	// Lists only the expressions of the given user.
+	// in:query
+	Username string `json:"username"`
+
+	// Lists only the expressions created at or after the given date, in RFC 3339.
+	// in:query
+	CreatedAfter string `json:"created_after"`
+
+	// Lists only the expressions created before the given date, in RFC 3339.
+	// in:query
+	CreatedBefore string `json:"created_before"`
+
+	// Lists only the expressions updated at or after the given date, in RFC 3339.
+	// in:query
+	UpdatedAfter string `json:"updated_after"`
+
+	// Lists only the expressions updated before the given date, in RFC 3339.
+	// in:query
+	UpdatedBefore string `json:"updated_before"`
+
+	// Lists only the expressions containing the given text, ignoring case.
+	// in:query
+	Contains string `json:"contains"`
+
+	// Lists only the expressions matching the given words, as a full-text search.
+	// in:query
+	Search string `json:"search"`
+
+	// Lists only the expressions using the given variable. Variables of referenced expressions are not considered.
+	// in:query
+	Variable string `json:"variable"`
+
+	// The field to sort the expressions by.
+	// in:query
+	// enum: created_at,updated_at,name
+	Sort string `json:"sort"`
+
+	// The direction of the sort, ascending by default.
+	// in:query
+	// enum: asc,desc
+	Order string `json:"order"`
 }
 
-// The response body contains the information of the created expression.
+// The response body contains the listed expressions. When paging by cursor, it is an object with the expressions in items and the cursor of the next page in next_cursor, left out on the last page.
 // swagger:response
 type listExpressionsResponseWrapper struct {
+	// The cursor of the next page when paging by cursor, left out on the last page.
+	NextCursor string `json:"x-pagination-next-cursor"`
+	// The link to the next page when paging by cursor, with rel="next", left out on the last page.
+	Link string `json:"Link"`
 	// in:body
-	Body []expmodel.CreateExpressionResponse
+	Body []expmodel.ListExpressionsResponse
 }
 
-// Error response when the request body is not well formatted.
+// Error response when the pagination data, the cursor, a date or the sort parameters are invalid, or when the cursor was returned for another sort or order.
 // swagger:response
 type listExpressionsBadRequest struct {
 	// in:body
@@ -169,12 +292,18 @@ type listExpressionsInternalServerError struct {
 // swagger:route PATCH /v1/expressions Expressions updateExpressionParams
 // Updates an expression and returns the updated expression to the user.
 //
+// Tags and name are kept unless they are sent. An expression referenced by name cannot be renamed nor lose its name, the response lists the expressions referencing it.
+//
+// Send the entity tag returned by the last retrieval, creation or update of the expression in the If-Match header to make sure the update does not overwrite changes made in the meantime. The update fails with 412 when the expression has changed since. If-Match is optional: updates without it always go through.
+//
 // This route can only be used by authenticated users and a user can only retrieve expressions that he/she created.
 // responses:
 //   200: updateExpressionResponseWrapper
 //   400: updateExpressionBadRequest
 //   401: updateExpressionUnauthorized
 //   403: updateExpressionForbidden
+//   409: updateExpressionConflict
+//   412: updateExpressionPreconditionFailed
 //   500: updateExpressionInternalServerError
 //
 //     Security:
@@ -182,6 +311,9 @@ type listExpressionsInternalServerError struct {
 
 // swagger:parameters updateExpressionParams
 type updateExpressionParamsWrapper struct {
+	// The entity tags the expression is expected to have, such as "3".
+	// in:header
+	IfMatch string `json:"If-Match"`
 	// The request body contains the information to update an expression.
 	// in:body
 	// required: true
@@ -191,15 +323,18 @@ type updateExpressionParamsWrapper struct {
 // The response body contains the information of the created expression.
 // swagger:response
 type updateExpressionResponseWrapper struct {
+	// The entity tag of the expression, to send in the If-Match header of updates.
+	ETag string `json:"ETag"`
 	// in:body
 	Body expmodel.UpdateExpressionResponse
 }
 
-// Error response when the request body is not well formatted.
+// Error response when the request body is not well formatted or the expression is invalid.
+// Invalid expressions include the list of syntax diagnostics found.
 // swagger:response
 type updateExpressionBadRequest struct {
 	// in:body
-	Body experrors.ErrorResponse
+	Body experrors.InvalidExpressionResponse
 }
 
 // Error response when the user does not provide authorization information to perform the request.
@@ -216,6 +351,23 @@ type updateExpressionForbidden struct {
 	Body experrors.ErrorResponse
 }
 
+// Error response when another expression of the user already has the given name, or when renaming an expression referenced by name.
+// swagger:response
+type updateExpressionConflict struct {
+	// in:body
+	Body experrors.ExpressionInUseResponse
+}
+
+// Error response when the expression has changed since the entity tag sent in the If-Match header was returned.
+// The ETag header contains the current entity tag of the expression.
+// swagger:response
+type updateExpressionPreconditionFailed struct {
+	// The current entity tag of the expression.
+	ETag string `json:"ETag"`
+	// in:body
+	Body experrors.ErrorResponse
+}
+
 // Error response when there is an internal server error.
 // swagger:response
 type updateExpressionInternalServerError struct {
@@ -226,12 +378,21 @@ type updateExpressionInternalServerError struct {
 // swagger:route DELETE /v1/expressions/{id} Expressions deleteExpressionParams
 // Deletes an expression.
 //
+// Deleted expressions are no longer listed nor evaluated, and their names can be reused. They can be restored until they are permanently removed once the retention period of the server has passed.
+//
+// Expressions referenced by other expressions cannot be deleted, the response lists the expressions referencing it.
+//
+// Send the entity tag of the expression in the If-Match header to make sure it has not changed since it was retrieved. The deletion fails with 412 when it has. If-Match is optional: without it, the deletion only fails with 404 when the expression was deleted in the meantime.
+//
 // This route can only be used by authenticated users and a user can only delete expressions that he/she created.
 // responses:
 //   204: deleteExpressionResponseWrapper
 //   400: deleteExpressionBadRequest
 //   401: deleteExpressionUnauthorized
 //   403: deleteExpressionForbidden
+//   404: deleteExpressionNotFound
+//   409: deleteExpressionConflict
+//   412: updateExpressionPreconditionFailed
 //   500: deleteExpressionInternalServerError
 //
 //     Security:
@@ -242,6 +403,9 @@ type deleteExpressionParamsWrapper struct {
 	// The ID of the expression.
 	// in:path
 	ID string `json:"id"`
+	// The entity tags the expression is expected to have, such as "3".
+	// in:header
+	IfMatch string `json:"If-Match"`
 }
 
 // The response body contains the information of the created expression.
@@ -271,6 +435,20 @@ type deleteExpressionForbidden struct {
 	Body experrors.ErrorResponse
 }
 
+// Error response when the expression does not exist or is already deleted.
+// swagger:response
+type deleteExpressionNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when other expressions reference the expression.
+// swagger:response
+type deleteExpressionConflict struct {
+	// in:body
+	Body experrors.ExpressionInUseResponse
+}
+
 // Error response when there is an internal server error.
 // swagger:response
 type deleteExpressionInternalServerError struct {
@@ -278,60 +456,1075 @@ type deleteExpressionInternalServerError struct {
 	Body experrors.ErrorResponse
 }
 
-// swagger:route GET /v1/evaluate/{id} Expressions evaluateExpressionParams
-// Evaluates an expression and returns its value.
+// swagger:route POST /v1/expressions/{id}/restore Expressions restoreExpressionParams
+// Restores a deleted expression.
 //
-// All parameters required by the given expression must be sent as query parameters to perform the evaluation. If at least one parameter is missing, an error will be returned to the user.
+// The expression is validated again since the expressions it references may have been deleted in the meantime.
 //
-// This route can only be used by authenticated users.
+// Like updates, restorations take the entity tag of the deleted expression in the optional If-Match header and fail with 412 when the expression has changed since.
+//
+// This route can only be used by authenticated users and a user can only restore expressions that he/she created.
 // responses:
-//   200: evaluateExpressionResponseWrapper
-//   400: evaluateExpressionBadRequest
-//   401: evaluateExpressionUnauthorized
-//   500: evaluateExpressionInternalServerError
+//   200: restoreExpressionResponseWrapper
+//   400: restoreExpressionBadRequest
+//   401: restoreExpressionUnauthorized
+//   404: restoreExpressionNotFound
+//   409: restoreExpressionConflict
+//   412: updateExpressionPreconditionFailed
+//   500: restoreExpressionInternalServerError
 //
 //     Security:
 //       bearer-normal:
 
-// swagger:parameters evaluateExpressionParams
-type evaluateExpressionParamsWrapper struct {
+// swagger:parameters restoreExpressionParams
+type restoreExpressionParamsWrapper struct {
 	// The ID of the expression.
 	// in:path
 	ID string `json:"id"`
+	// The entity tags the expression is expected to have, such as "3".
+	// in:header
+	IfMatch string `json:"If-Match"`
+}
 
-	// Some X variable parameter
+// The response body contains the restored expression.
+// swagger:response
+type restoreExpressionResponseWrapper struct {
+	// in:body
+	Body expmodel.GetExpressionResponse
+}
+
+// Error response when the expression ID is invalid or the expression references expressions that no longer exist.
+// swagger:response
+type restoreExpressionBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type restoreExpressionUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression is not deleted, was purged, or was not created by the user.
+// swagger:response
+type restoreExpressionNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when another expression of the user took the name of the expression.
+// swagger:response
+type restoreExpressionConflict struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type restoreExpressionInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/expressions/{id}/dependents Expressions dependentsParams
+// Lists the expressions that reference an expression.
+//
+// By default only the expressions referencing it directly are listed. With transitive=true, the expressions referencing those are listed as well, and so on.
+//
+// This route can only be used by authenticated users and a user can only list dependents of expressions that he/she created.
+// responses:
+//   200: dependentsResponseWrapper
+//   400: dependentsBadRequest
+//   401: dependentsUnauthorized
+//   404: dependentsNotFound
+//   500: dependentsInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters dependentsParams
+type dependentsParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+	// Whether to also list the expressions that reference the dependents.
 	// in:query
-	X string `json:"x"`
+	Transitive bool `json:"transitive"`
+}
 
-	// Some Y variable parameter
+// The response body contains the expressions that reference the expression.
+// swagger:response
+type dependentsResponseWrapper struct {
+	// in:body
+	Body expmodel.DependentsResponse
+}
+
+// Error response when the expression ID or the query parameters are invalid.
+// swagger:response
+type dependentsBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type dependentsUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression does not exist or was not created by the user.
+// swagger:response
+type dependentsNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type dependentsInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/expressions/{id}/versions Expressions expressionVersionsParams
+// Lists the versions of an expression, oldest first.
+//
+// A version is recorded when the expression is created, and every time an update, a simplification or a rollback changes its text.
+//
+// This route can only be used by authenticated users and a user can only list versions of expressions that he/she created.
+// responses:
+//   200: expressionVersionsResponseWrapper
+//   400: versionsBadRequest
+//   401: versionsUnauthorized
+//   404: versionsNotFound
+//   500: versionsInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters expressionVersionsParams
+type expressionVersionsParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+}
+
+// The response body contains the versions of the expression.
+// swagger:response
+type expressionVersionsResponseWrapper struct {
+	// in:body
+	Body []expmodel.ExpressionVersionResponse
+}
+
+// swagger:route GET /v1/expressions/{id}/versions/{version} Expressions expressionVersionParams
+// Retrieves a version of an expression.
+//
+// This route can only be used by authenticated users and a user can only retrieve versions of expressions that he/she created.
+// responses:
+//   200: expressionVersionResponseWrapper
+//   400: versionsBadRequest
+//   401: versionsUnauthorized
+//   404: versionsNotFound
+//   500: versionsInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters expressionVersionParams
+type expressionVersionParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+	// The number of the version, starting at 1.
+	// in:path
+	Version int32 `json:"version"`
+}
+
+// The response body contains the version of the expression.
+// swagger:response
+type expressionVersionResponseWrapper struct {
+	// in:body
+	Body expmodel.ExpressionVersionResponse
+}
+
+// swagger:route GET /v1/expressions/{id}/diff Expressions diffVersionsParams
+// Compares two versions of an expression.
+//
+// Expressions are compared token by token, so changes in whitespace alone are not reported. The response lists the runs of tokens that are equal, inserted or deleted, in order.
+//
+// This route can only be used by authenticated users and a user can only compare versions of expressions that he/she created.
+// responses:
+//   200: diffVersionsResponseWrapper
+//   400: versionsBadRequest
+//   401: versionsUnauthorized
+//   404: versionsNotFound
+//   500: versionsInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters diffVersionsParams
+type diffVersionsParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+	// The version to compare from.
 	// in:query
-	Y string `json:"y"`
+	From int32 `json:"from"`
+	// The version to compare to.
+	// in:query
+	To int32 `json:"to"`
 }
 
-// The response body contains the information of the created expression.
+// The response body contains the changes between the two versions.
 // swagger:response
-type evaluateExpressionResponseWrapper struct {
+type diffVersionsResponseWrapper struct {
 	// in:body
-	Body expmodel.CreateExpressionResponse
+	Body expmodel.DiffVersionsResponse
 }
 
-// Error response when the request body is not well formatted.
+// swagger:route POST /v1/expressions/{id}/rollback Expressions rollbackExpressionParams
+// Restores a previous version of an expression.
+//
+// The restored text is recorded as a new version, and is validated like an update since the expressions it references may have changed. The name and the tags of the expression are kept.
+//
+// Like updates, rollbacks take the entity tag of the expression in the optional If-Match header and fail with 412 when the expression has changed since.
+//
+// This route can only be used by authenticated users and a user can only roll back expressions that he/she created.
+// responses:
+//   200: rollbackExpressionResponseWrapper
+//   400: versionsBadRequest
+//   401: versionsUnauthorized
+//   404: versionsNotFound
+//   412: updateExpressionPreconditionFailed
+//   500: versionsInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters rollbackExpressionParams
+type rollbackExpressionParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+	// The entity tags the expression is expected to have, such as "3".
+	// in:header
+	IfMatch string `json:"If-Match"`
+	// in:body
+	Body expmodel.RollbackExpressionBody
+}
+
+// The response body contains the restored expression.
 // swagger:response
-type evaluateExpressionBadRequest struct {
+type rollbackExpressionResponseWrapper struct {
+	// The entity tag of the expression, to send in the If-Match header of updates.
+	ETag string `json:"ETag"`
+	// in:body
+	Body expmodel.UpdateExpressionResponse
+}
+
+// Error response when the expression ID, the version or the request body is invalid.
+// swagger:response
+type versionsBadRequest struct {
 	// in:body
 	Body experrors.ErrorResponse
 }
 
 // Error response when the user does not provide authorization information to perform the request.
 // swagger:response
-type evaluateExpressionUnauthorized struct {
+type versionsUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression or the version does not exist, or the expression was not created by the user.
+// swagger:response
+type versionsNotFound struct {
 	// in:body
 	Body experrors.ErrorResponse
 }
 
 // Error response when there is an internal server error.
 // swagger:response
-type evaluateExpressionInternalServerError struct {
+type versionsInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/evaluate/{id} Expressions evaluateExpressionParams
+// Evaluates an expression and returns its value.
+//
+// All variables used by the given expression must be sent as query parameters to perform the evaluation. Variables used on their own take 0 or 1, and variables used in comparisons take a number or a string, matching the type of the constants they are compared to. Repeating a query key sends a list, which IN compares element by element. Query keys are matched by exact name, falling back to their lowercase form. If at least one variable is missing, an error will be returned to the user.
+//
+// With explain=true, the response also contains the evaluated tree annotated with the value of every node, the nodes that short-circuited and a minimal set of variables that justifies the result. Only the user who created the expression can request an explanation. A variable named explain takes precedence over the parameter.
+//
+// With mode=kleene, variables that are not sent are unknown, while values of the wrong type are rejected like in the default mode, and the expression is evaluated with the three-valued logic of Kleene. The body is then an object with the result, true, false or unknown, in result, and an unknown result comes with the unknown variables it still depends on in deciding. This mode does not support explain. A variable named mode takes precedence over the parameter.
+//
+// With version=N, the given version of the expression is evaluated instead of the current one. The expressions it references are evaluated as they currently are. Only the user who created the expression can request a previous version. A variable named version takes precedence over the parameter.
+//
+// This route can only be used by authenticated users.
+// responses:
+//   200: evaluateExpressionResponseWrapper
+//   400: evaluateExpressionBadRequest
+//   401: evaluateExpressionUnauthorized
+//   404: evaluateExpressionNotFound
+//   500: evaluateExpressionInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters evaluateExpressionParams
+type evaluateExpressionParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+
+	// Some X variable parameter
+	// in:query
+	X string `json:"x"`
+
+	// Some Y variable parameter
+	// in:query
+	Y string `json:"y"`
+
+	// Whether to explain how the result was reached
+	// in:query
+	Explain bool `json:"explain"`
+
+	// The logic of the evaluation, either boolean (default) or kleene
+	// in:query
+	Mode string `json:"mode"`
+
+	// The version of the expression to evaluate, the current one by default
+	// in:query
+	Version int32 `json:"version"`
+}
+
+// The response body contains the information of the created expression.
+// swagger:response
+type evaluateExpressionResponseWrapper struct {
+	// in:body
+	Body expmodel.CreateExpressionResponse
+}
+
+// Error response when the request body is not well formatted.
+// swagger:response
+type evaluateExpressionBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type evaluateExpressionUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression or the requested version does not exist.
+// swagger:response
+type evaluateExpressionNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type evaluateExpressionInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/evaluate/by-name/{name} Expressions evaluateExpressionByNameParams
+// Evaluates an expression of the user, addressed by its name, and returns its value.
+//
+// Variables and options are sent as query parameters, as when evaluating an expression by ID.
+//
+// This route can only be used by authenticated users, and only finds expressions that the user created.
+// responses:
+//   200: evaluateExpressionByNameResponseWrapper
+//   400: evaluateExpressionByNameBadRequest
+//   401: evaluateExpressionByNameUnauthorized
+//   404: evaluateExpressionByNameNotFound
+//   500: evaluateExpressionByNameInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters evaluateExpressionByNameParams
+type evaluateExpressionByNameParamsWrapper struct {
+	// The name of the expression.
+	// in:path
+	Name string `json:"name"`
+
+	// Whether to explain how the result was reached
+	// in:query
+	Explain bool `json:"explain"`
+
+	// The logic of the evaluation, either boolean (default) or kleene
+	// in:query
+	Mode string `json:"mode"`
+
+	// The version of the expression to evaluate, the current one by default
+	// in:query
+	Version int32 `json:"version"`
+}
+
+// The response body contains the result of the evaluation.
+// swagger:response
+type evaluateExpressionByNameResponseWrapper struct {
+	// in:body
+	Body expmodel.EvaluateExpressionResponse
+}
+
+// Error response when variables are missing or invalid.
+// swagger:response
+type evaluateExpressionByNameBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type evaluateExpressionByNameUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user has no expression with the given name.
+// swagger:response
+type evaluateExpressionByNameNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type evaluateExpressionByNameInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route POST /v1/evaluate/{id} Expressions evaluateExpressionJSONParams
+// Evaluates an expression with the variable values sent as a JSON object.
+//
+// Variables used on their own take true, false, 0 or 1, either as JSON values or strings. Variables used in comparisons take a number or a string, matching the type of the constants they are compared to, or a list of them, which IN compares element by element. Numeric strings are accepted as numbers. Variables the expression does not use are ignored, unless strict is set, in which case they are rejected. Every missing, invalid or unknown variable is listed in a single error response.
+//
+// With explain=true, the response also contains the evaluated tree annotated with the value of every node, the nodes that short-circuited and a minimal set of variables that justifies the result. Only the user who created the expression can request an explanation.
+//
+// This route can only be used by authenticated users.
+// responses:
+//   200: evaluateExpressionJSONResponseWrapper
+//   400: evaluateExpressionJSONBadRequest
+//   401: evaluateExpressionJSONUnauthorized
+//   404: evaluateExpressionJSONNotFound
+//   500: evaluateExpressionJSONInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters evaluateExpressionJSONParams
+type evaluateExpressionJSONParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+
+	// Whether to explain how the result was reached
+	// in:query
+	Explain bool `json:"explain"`
+
+	// The variable values and whether unknown variables are rejected.
+	// in:body
+	Body expmodel.EvaluateExpressionBody
+}
+
+// The response body contains the result of the evaluation.
+// swagger:response
+type evaluateExpressionJSONResponseWrapper struct {
+	// in:body
+	Body expmodel.EvaluateExpressionResponse
+}
+
+// Error response listing every missing, invalid or unknown variable.
+// swagger:response
+type evaluateExpressionJSONBadRequest struct {
+	// in:body
+	Body experrors.InvalidVariablesResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type evaluateExpressionJSONUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression does not exist.
+// swagger:response
+type evaluateExpressionJSONNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type evaluateExpressionJSONInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route POST /v1/evaluate Expressions evaluateAdHocParams
+// Evaluates an expression that is not stored.
+//
+// The expression is validated like on creation, with the same diagnostics, and is never saved. Its references are resolved to the expressions of the user. Variable values follow the rules of POST /v1/evaluate/{id}.
+//
+// With explain=true, the response also contains the evaluated tree annotated with the value of every node, the nodes that short-circuited and a minimal set of variables that justifies the result.
+//
+// This route can only be used by authenticated users.
+// responses:
+//   200: evaluateAdHocResponseWrapper
+//   400: evaluateAdHocBadRequest
+//   401: evaluateAdHocUnauthorized
+//   500: evaluateAdHocInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters evaluateAdHocParams
+type evaluateAdHocParamsWrapper struct {
+	// Whether to explain how the result was reached
+	// in:query
+	Explain bool `json:"explain"`
+
+	// The expression along with its variable values.
+	// in:body
+	Body expmodel.EvaluateAdHocRequest
+}
+
+// The response body contains the result of the evaluation.
+// swagger:response
+type evaluateAdHocResponseWrapper struct {
+	// in:body
+	Body expmodel.EvaluateExpressionResponse
+}
+
+// Error response when the expression is invalid, with its diagnostics, or when variables are rejected.
+// swagger:response
+type evaluateAdHocBadRequest struct {
+	// in:body
+	Body experrors.InvalidExpressionResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type evaluateAdHocUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type evaluateAdHocInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route POST /v1/evaluate/match Expressions matchExpressionsParams
+// Finds the expressions of the user made true by a set of variable values.
+//
+// Returns the expressions of the authenticated user, optionally restricted to those with the given tag, that the variables make true. Variables an expression uses but that are not given are unknown, so the expression only matches when the given values are enough to make it true under the three-valued logic of Kleene: (x AND y) OR (x AND NOT y) does not match x alone. Expressions are kept in an in-memory reverse index, built on the first match of the user and updated as expressions change, so only the expressions sharing a variable value with the request are looked at. Results are sorted by creation order.
+//
+// Known limitation: the index is local to each instance of the service and only follows the changes made through it. Changes made through other instances, or purges, are only seen once the index of the user is loaded again, MATCH_INDEX_TTL after it was built.
+//
+// This route can only be used by authenticated users.
+// responses:
+//   200: matchExpressionsResponseWrapper
+//   400: matchExpressionsBadRequest
+//   401: matchExpressionsUnauthorized
+//   500: matchExpressionsInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters matchExpressionsParams
+type matchExpressionsParamsWrapper struct {
+	// The variable values and the optional tag.
+	// in:body
+	Body expmodel.MatchExpressionsRequest
+}
+
+// The response body contains the IDs of the matching expressions.
+// swagger:response
+type matchExpressionsResponseWrapper struct {
+	// in:body
+	Body expmodel.MatchExpressionsResponse
+}
+
+// Error response listing the variables with invalid values.
+// swagger:response
+type matchExpressionsBadRequest struct {
+	// in:body
+	Body experrors.InvalidVariablesResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type matchExpressionsUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type matchExpressionsInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route POST /v1/evaluate/{id}/batch Expressions evaluateBatchParams
+// Evaluates an expression against many sets of variable values.
+//
+// The body is either a JSON array of variable objects or, with the application/x-ndjson content type, one variable object per line, in which case the response is NDJSON too. The expression is parsed once and the results keep the order of the input. Items whose variables are missing or invalid, or unknown with strict=true, get their own error without failing the batch.
+//
+// Batches with more items than the configured maximum size or a larger body than the configured maximum bytes are rejected, and so are batches not read and evaluated within the configured timeout.
+// The whole batch is read and evaluated before the response is written, and items that cannot be evaluated get their own error as well.
+//
+// This route can only be used by authenticated users.
+// responses:
+//   200: evaluateBatchResponseWrapper
+//   400: evaluateBatchBadRequest
+//   401: evaluateBatchUnauthorized
+//   404: evaluateBatchNotFound
+//   413: evaluateBatchTooLarge
+//   500: evaluateBatchInternalServerError
+//   503: evaluateBatchTimeout
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters evaluateBatchParams
+type evaluateBatchParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+
+	// Whether to reject variables the expression does not use
+	// in:query
+	Strict bool `json:"strict"`
+
+	// The sets of variable values.
+	// in:body
+	Body []map[string]interface{}
+}
+
+// The response body contains the result of every item, in the order of the input.
+// swagger:response
+type evaluateBatchResponseWrapper struct {
+	// in:body
+	Body expmodel.EvaluateBatchResponse
+}
+
+// Error response when the request body is not well formatted.
+// swagger:response
+type evaluateBatchBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type evaluateBatchUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression does not exist.
+// swagger:response
+type evaluateBatchNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the batch exceeds the maximum size or its body the maximum bytes.
+// swagger:response
+type evaluateBatchTooLarge struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type evaluateBatchInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the batch is not completed within the timeout.
+// swagger:response
+type evaluateBatchTimeout struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/evaluate/cache-stats Expressions cacheStatsParams
+// Retrieves the counters of the compiled expression cache.
+//
+// Stored expressions are parsed once per revision and kept in an in-memory LRU cache, which is invalidated when an expression is updated or deleted.
+//
+// This route can only be used by the admins listed in ADMIN_USER_IDS.
+// responses:
+//   200: cacheStatsResponseWrapper
+//   401: cacheStatsUnauthorized
+//   403: cacheStatsForbidden
+//
+//     Security:
+//       bearer-normal:
+
+// The response body contains the cache hit, miss and eviction counters along with its size and capacity.
+// swagger:response
+type cacheStatsResponseWrapper struct {
+	// in:body
+	Body expmodel.CacheStatsResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type cacheStatsUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user is not an admin.
+// swagger:response
+type cacheStatsForbidden struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/expressions/{id}/truth-table Expressions truthTableParams
+// Generates the truth table of an expression.
+//
+// Every assignment of the expression variables is listed in binary counting order. The table is returned as JSON by default, or as CSV when the Accept header is text/csv.
+// Expressions with more variables than the configured limit (TRUTH_TABLE_MAX_VARIABLES, 12 by default) are rejected.
+//
+// This route can only be used by authenticated users and a user can only retrieve truth tables of expressions that he/she created.
+// produces:
+//   - application/json
+//   - text/csv
+// responses:
+//   200: truthTableResponseWrapper
+//   400: truthTableBadRequest
+//   401: truthTableUnauthorized
+//   404: truthTableNotFound
+//   500: truthTableInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters truthTableParams
+type truthTableParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+}
+
+// The response body contains the expression variables and one row per assignment.
+// swagger:response
+type truthTableResponseWrapper struct {
+	// in:body
+	Body expmodel.TruthTableResponse
+}
+
+// Error response when the expression ID is invalid or the expression has too many variables.
+// swagger:response
+type truthTableBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type truthTableUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression does not exist or was not created by the user.
+// swagger:response
+type truthTableNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type truthTableInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/expressions/{id}/satisfiable Expressions satisfiableParams
+// Checks whether an expression can ever evaluate to true.
+//
+// The response flags tautologies (always true) and contradictions (never true) and, when they exist, includes a witness assignment that makes the expression true and a counterexample that makes it false.
+// The check runs a DPLL search over a CNF encoding of the expression, so it does not enumerate every assignment.
+// Comparisons of a same variable are checked together, so age > 30 AND age < 18 is a contradiction and witnesses never hold results no single value can give.
+//
+// This route can only be used by authenticated users and a user can only check expressions that he/she created.
+// responses:
+//   200: satisfiableResponseWrapper
+//   400: satisfiableBadRequest
+//   401: satisfiableUnauthorized
+//   404: satisfiableNotFound
+//   500: satisfiableInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters satisfiableParams
+type satisfiableParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+}
+
+// The response body contains the satisfiability flags of the expression along with its witness and counterexample.
+// swagger:response
+type satisfiableResponseWrapper struct {
+	// in:body
+	Body expmodel.SatisfiableResponse
+}
+
+// Error response when the expression ID is invalid.
+// swagger:response
+type satisfiableBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type satisfiableUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression does not exist or was not created by the user.
+// swagger:response
+type satisfiableNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type satisfiableInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route POST /v1/expressions/compare Expressions compareExpressionsParams
+// Checks whether two expressions are logically equivalent.
+//
+// Each side of the comparison is either the ID of a stored expression or a raw expression, so an edited expression can be checked against the stored version before it is saved.
+// When the expressions are not equivalent, the response includes a counterexample assignment where they differ.
+// References of raw expressions are resolved to the expressions of the user, like the ones of stored expressions, and comparisons of a same variable are checked together, so a > 5 and NOT (a <= 5) are equivalent.
+//
+// This route can only be used by authenticated users and a user can only compare expressions that he/she created.
+// responses:
+//   200: compareExpressionsResponseWrapper
+//   400: compareExpressionsBadRequest
+//   401: compareExpressionsUnauthorized
+//   404: compareExpressionsNotFound
+//   500: compareExpressionsInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters compareExpressionsParams
+type compareExpressionsParamsWrapper struct {
+	// The expressions to compare. Each side must provide exactly one of expression_id and expression.
+	// in:body
+	Body expmodel.CompareExpressionsRequest
+}
+
+// The response body tells whether the expressions are equivalent, with a counterexample when they are not.
+// swagger:response
+type compareExpressionsResponseWrapper struct {
+	// in:body
+	Body expmodel.CompareExpressionsResponse
+}
+
+// Error response when the request body is not well formatted or a raw expression is invalid.
+// swagger:response
+type compareExpressionsBadRequest struct {
+	// in:body
+	Body experrors.InvalidExpressionResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type compareExpressionsUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when a stored expression does not exist or was not created by the user.
+// swagger:response
+type compareExpressionsNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type compareExpressionsInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route POST /v1/expressions/{id}/simplify Expressions simplifyExpressionParams
+// Simplifies an expression.
+//
+// The expression is rewritten with algebraic identities (constants, idempotence, complements, absorption, double negation and De Morgan's laws).
+// With minimize, a minimal sum of products is also computed with the Quine–McCluskey method and kept when it is smaller; it is bound by the truth table variable limit.
+// With save, the simplified form replaces the stored expression when it differs from it. Saving takes the entity tag of the expression in the optional If-Match header and fails with 412 when the expression has changed since.
+//
+// This route can only be used by authenticated users and a user can only simplify expressions that he/she created.
+// responses:
+//   200: simplifyExpressionResponseWrapper
+//   400: simplifyExpressionBadRequest
+//   401: simplifyExpressionUnauthorized
+//   404: simplifyExpressionNotFound
+//   412: updateExpressionPreconditionFailed
+//   500: simplifyExpressionInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters simplifyExpressionParams
+type simplifyExpressionParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+
+	// Whether to replace the stored expression with its simplified form.
+	// in:query
+	Save bool `json:"save"`
+
+	// Whether to minimize the expression into a sum of products.
+	// in:query
+	Minimize bool `json:"minimize"`
+
+	// The entity tags the expression is expected to have when saving, such as "3".
+	// in:header
+	IfMatch string `json:"If-Match"`
+}
+
+// The response body contains the original and simplified expressions.
+// swagger:response
+type simplifyExpressionResponseWrapper struct {
+	// The entity tag of the expression, to send in the If-Match header of updates.
+	ETag string `json:"ETag"`
+	// in:body
+	Body expmodel.SimplifyExpressionResponse
+}
+
+// Error response when the expression ID or the flags are invalid, or the expression has too many variables to minimize.
+// swagger:response
+type simplifyExpressionBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type simplifyExpressionUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression does not exist or was not created by the user.
+// swagger:response
+type simplifyExpressionNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type simplifyExpressionInternalServerError struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// swagger:route GET /v1/expressions/{id}/normal-form Expressions normalFormParams
+// Converts an expression to conjunctive (cnf) or disjunctive (dnf) normal form.
+//
+// The result is returned both as an expression and as a list of clauses (CNF) or terms (DNF), each a list of possibly negated variables.
+// Conversions by distribution are limited to 4096 clauses. With tseitin, the CNF is built with the Tseitin encoding instead: it grows linearly with the expression and is equisatisfiable with it, using the auxiliary variables listed in the response.
+//
+// This route can only be used by authenticated users and a user can only convert expressions that he/she created.
+// responses:
+//   200: normalFormResponseWrapper
+//   400: normalFormBadRequest
+//   401: normalFormUnauthorized
+//   404: normalFormNotFound
+//   500: normalFormInternalServerError
+//
+//     Security:
+//       bearer-normal:
+
+// swagger:parameters normalFormParams
+type normalFormParamsWrapper struct {
+	// The ID of the expression.
+	// in:path
+	ID string `json:"id"`
+
+	// The normal form: cnf or dnf.
+	// in:query
+	// required: true
+	Form string `json:"form"`
+
+	// Whether to build the CNF with the Tseitin encoding.
+	// in:query
+	Tseitin bool `json:"tseitin"`
+}
+
+// The response body contains the expression in normal form along with its clauses.
+// swagger:response
+type normalFormResponseWrapper struct {
+	// in:body
+	Body expmodel.NormalFormResponse
+}
+
+// Error response when the expression ID or form is invalid, or the normal form is too large.
+// swagger:response
+type normalFormBadRequest struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the user does not provide authorization information to perform the request.
+// swagger:response
+type normalFormUnauthorized struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when the expression does not exist or was not created by the user.
+// swagger:response
+type normalFormNotFound struct {
+	// in:body
+	Body experrors.ErrorResponse
+}
+
+// Error response when there is an internal server error.
+// swagger:response
+type normalFormInternalServerError struct {
 	// in:body
 	Body experrors.ErrorResponse
 }
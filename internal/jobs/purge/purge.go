@@ -0,0 +1,71 @@
+package purgejob
+
+import (
+	"context"
+	"log"
+	"time"
+
+	expstore "github.com/gmaschi/log-exp-eval/internal/services/datastore/postgresql/exp"
+)
+
+// DefaultInterval is the time between two purges when no interval is configured.
+const DefaultInterval = time.Hour
+
+type (
+	// Config holds the settings of the purge job. Zero values fall back to the defaults.
+	Config struct {
+		// Retention is how long deleted expressions can be restored before they are purged.
+		Retention time.Duration
+		// Interval is the time between two purges.
+		Interval time.Duration
+	}
+
+	// Job hard-deletes the expressions that were soft deleted longer than the retention period ago, along with
+	// their versions.
+	Job struct {
+		store     expstore.Store
+		retention time.Duration
+		interval  time.Duration
+	}
+)
+
+// New instantiates a purge job.
+func New(store expstore.Store, cfg Config) *Job {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Job{
+		store:     store,
+		retention: cfg.Retention,
+		interval:  interval,
+	}
+}
+
+// Run purges the expressions once, then every interval until ctx is done. Failures are logged and retried on the
+// next run.
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := j.Purge(ctx)
+		if err != nil {
+			log.Printf("failed to purge deleted expressions: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d deleted expressions", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge hard-deletes the expressions deleted before the retention period and returns how many were removed.
+func (j *Job) Purge(ctx context.Context) (int64, error) {
+	return j.store.PurgeDeletedExpressions(ctx, time.Now().Add(-j.retention))
+}
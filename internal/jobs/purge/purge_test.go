@@ -0,0 +1,94 @@
+package purgejob_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	purgejob "github.com/gmaschi/log-exp-eval/internal/jobs/purge"
+	mockedexpstore "github.com/gmaschi/log-exp-eval/internal/services/datastore/postgresql/exp/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_Purge(t *testing.T) {
+	retention := 30 * 24 * time.Hour
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockedexpstore.MockStore)
+		purged     int64
+		wantErr    bool
+	}{
+		{
+			name: "Purges expressions deleted before the retention period",
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().
+					PurgeDeletedExpressions(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, deletedBefore time.Time) (int64, error) {
+						require.WithinDuration(t, time.Now().Add(-retention), deletedBefore, time.Second)
+						return 3, nil
+					})
+			},
+			purged: 3,
+		},
+		{
+			name: "Error - database",
+			buildStubs: func(store *mockedexpstore.MockStore) {
+				store.EXPECT().PurgeDeletedExpressions(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockedexpstore.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			job := purgejob.New(store, purgejob.Config{Retention: retention})
+			purged, err := job.Purge(context.Background())
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.purged, purged)
+		})
+	}
+}
+
+func TestJob_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	store := mockedexpstore.NewMockStore(ctrl)
+	// the job purges as soon as it starts and keeps going after failures
+	gomock.InOrder(
+		store.EXPECT().PurgeDeletedExpressions(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone),
+		store.EXPECT().PurgeDeletedExpressions(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+			func(context.Context, time.Time) (int64, error) {
+				cancel()
+				return 1, nil
+			},
+		),
+	)
+
+	done := make(chan struct{})
+	go func() {
+		purgejob.New(store, purgejob.Config{Interval: time.Millisecond}).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job did not stop after its context was canceled")
+	}
+}
@@ -1,6 +1,10 @@
 package experrors
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
 
 type (
 	ExpressionError string
@@ -8,6 +12,40 @@ type (
 	ErrorResponse struct {
 		Error string `json:"error"`
 	}
+
+	// DiagnosticResponse describes a single syntax problem found in an expression.
+	DiagnosticResponse struct {
+		Offset  int    `json:"offset"`
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	// InvalidExpressionResponse is returned when an expression fails validation.
+	InvalidExpressionResponse struct {
+		Error       string               `json:"error"`
+		Diagnostics []DiagnosticResponse `json:"diagnostics"`
+	}
+
+	// VariableErrorResponse describes a variable that could not be bound to an expression.
+	VariableErrorResponse struct {
+		Name    string `json:"name"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	// InvalidVariablesResponse is returned when the variables given to evaluate an expression are rejected.
+	InvalidVariablesResponse struct {
+		Error     string                  `json:"error"`
+		Variables []VariableErrorResponse `json:"variables"`
+	}
+
+	// ExpressionInUseResponse is returned when deleting an expression that other expressions reference.
+	ExpressionInUseResponse struct {
+		Error      string      `json:"error"`
+		Dependents []uuid.UUID `json:"dependents"`
+	}
 )
 
 const (
@@ -19,13 +57,37 @@ const (
 	ErrRetrievingExpression      ExpressionError = "failed to retrieve expression"
 	ErrUpdatingExpression        ExpressionError = "failed to update expression"
 	ErrDeletingExpression        ExpressionError = "failed to delete expression"
+	ErrRestoringExpression       ExpressionError = "failed to restore expression"
 	ErrInvalidEvaluateExpression ExpressionError = "failed to evaluate expression"
+	ErrGeneratingTruthTable      ExpressionError = "failed to generate truth table"
+	ErrCheckingSatisfiability    ExpressionError = "failed to check expression satisfiability"
+	ErrComparingExpressions      ExpressionError = "failed to compare expressions"
+	ErrSimplifyingExpression     ExpressionError = "failed to simplify expression"
+	ErrConvertingExpression      ExpressionError = "failed to convert expression to normal form"
+	ErrInvalidLeftOperand        ExpressionError = "invalid left expression"
+	ErrInvalidRightOperand       ExpressionError = "invalid right expression"
+	ErrInvalidVariables          ExpressionError = "invalid variables"
+	ErrInvalidTags               ExpressionError = "tags must not be empty"
+	ErrListingDependents         ExpressionError = "failed to list dependent expressions"
+	ErrExpressionInUse           ExpressionError = "expression is referenced by other expressions"
+	ErrInvalidName               ExpressionError = "invalid expression name"
+	ErrNameTaken                 ExpressionError = "expression name already in use"
+	ErrListingVersions           ExpressionError = "failed to list expression versions"
+	ErrRetrievingVersion         ExpressionError = "failed to retrieve expression version"
+	ErrVersionNotFound           ExpressionError = "expression version not found"
+	ErrRollingBackExpression     ExpressionError = "failed to roll back expression"
+	ErrExpressionModified        ExpressionError = "expression was modified since it was retrieved"
 
 	ErrRecordNotFound ExpressionError = "record not found"
 	ErrInternalServer ExpressionError = "internal error"
 	ErrBadRequest     ExpressionError = "bad request"
 
 	ErrRequiredPaginationData ExpressionError = "list paginated requires both page_id and page_size"
+	ErrRequiredCursorPageSize ExpressionError = "list with cursor requires page_size"
+	ErrConflictingPagination  ExpressionError = "page_id and cursor cannot be used together"
+	ErrInvalidCursor          ExpressionError = "invalid pagination cursor"
+	ErrCursorSort             ExpressionError = "pagination cursor was returned for another sort or order"
+	ErrRequiredCompareOperand ExpressionError = "exactly one of expression_id and expression must be provided"
 )
 
 func (se ExpressionError) Error() error {
@@ -1,14 +1,19 @@
 package expmodel
 
 import (
-	"github.com/google/uuid"
 	"time"
+
+	experrors "github.com/gmaschi/log-exp-eval/internal/models/expressions/errors"
+	"github.com/google/uuid"
 )
 
 type (
 	// CreateExpressionRequest describes the request to create an expression.
+	// Name is optional and must be unique among the expressions of the user.
 	CreateExpressionRequest struct {
-		Expression string `json:"expression" binding:"required"`
+		Expression string   `json:"expression" binding:"required"`
+		Tags       []string `json:"tags"`
+		Name       string   `json:"name"`
 	}
 
 	// CreateExpressionResponse describes the response when creating an expression.
@@ -19,6 +24,8 @@ type (
 		Username     string    `json:"username"`
 		CreatedAt    time.Time `json:"createdAt"`
 		UpdatedAt    time.Time `json:"updatedAt"`
+		Tags         []string  `json:"tags"`
+		Name         string    `json:"name"`
 	}
 
 	// GetExpressionRequest describes the request to retrieve an expression.
@@ -26,6 +33,11 @@ type (
 		ID string `uri:"id" binding:"required"`
 	}
 
+	// GetExpressionByNameRequest describes the request to retrieve an expression of the user by its name.
+	GetExpressionByNameRequest struct {
+		Name string `uri:"name" binding:"required"`
+	}
+
 	// GetExpressionResponse describes the response when getting an expression.
 	GetExpressionResponse struct {
 		RowID        int64     `json:"-"`
@@ -34,15 +46,33 @@ type (
 		Username     string    `json:"username"`
 		CreatedAt    time.Time `json:"createdAt"`
 		UpdatedAt    time.Time `json:"updatedAt"`
+		Tags         []string  `json:"tags"`
+		Name         string    `json:"name"`
 	}
 
-	// ListExpressionsRequest describes the request to list expressions.
+	// ListExpressionsRequest describes the request to list expressions. Dates follow RFC 3339, lower bounds are
+	// inclusive and upper bounds exclusive. Expressions are listed in creation order unless sorted otherwise.
+	// A page size with a cursor pages through the expressions with the cursor returned by the previous page, or an
+	// empty cursor for the first page.
 	ListExpressionsRequest struct {
-		PageID   int32 `form:"page_id"`
-		PageSize int32 `form:"page_size"`
+		PageID        int32     `form:"page_id"`
+		PageSize      int32     `form:"page_size"`
+		Cursor        string    `form:"cursor"`
+		Total         bool      `form:"total"`
+		Username      string    `form:"username"`
+		CreatedAfter  time.Time `form:"created_after"`
+		CreatedBefore time.Time `form:"created_before"`
+		UpdatedAfter  time.Time `form:"updated_after"`
+		UpdatedBefore time.Time `form:"updated_before"`
+		Contains      string    `form:"contains"`
+		Search        string    `form:"search"`
+		Variable      string    `form:"variable"`
+		Sort          string    `form:"sort" binding:"omitempty,oneof=created_at updated_at name"`
+		Order         string    `form:"order" binding:"omitempty,oneof=asc desc"`
 	}
 
-	// ListExpressionsResponse describes the response when listing expressions.
+	// ListExpressionsResponse describes the response when listing expressions. Version is the row version that
+	// the entity tag of the expression is made of, so listed expressions can be updated with If-Match.
 	ListExpressionsResponse struct {
 		RowID        int64     `json:"-"`
 		ExpressionID uuid.UUID `json:"expressionID"`
@@ -50,12 +80,26 @@ type (
 		Username     string    `json:"username"`
 		CreatedAt    time.Time `json:"createdAt"`
 		UpdatedAt    time.Time `json:"updatedAt"`
+		Tags         []string  `json:"tags"`
+		Name         string    `json:"name"`
+		Version      int32     `json:"version"`
+	}
+
+	// ListExpressionsPageResponse describes the response when listing expressions by cursor. NextCursor is the
+	// cursor of the next page, left out on the last page.
+	ListExpressionsPageResponse struct {
+		Items      []ListExpressionsResponse `json:"items"`
+		NextCursor string                    `json:"next_cursor,omitempty"`
 	}
 
 	// UpdateExpressionRequest describes the request to update an expression.
+	// Tags replace the tags of the expression when given, an empty list removes them all. Likewise, the name
+	// replaces the name of the expression when given, and an empty name removes it.
 	UpdateExpressionRequest struct {
-		ExpressionID string `json:"expression_id" binding:"required"`
-		Expression   string `json:"expression"`
+		ExpressionID string   `json:"expression_id" binding:"required"`
+		Expression   string   `json:"expression"`
+		Tags         []string `json:"tags"`
+		Name         *string  `json:"name"`
 	}
 
 	// UpdateExpressionResponse describes the response when updating an expression.
@@ -66,6 +110,8 @@ type (
 		Username     string    `json:"username"`
 		CreatedAt    time.Time `json:"createdAt"`
 		UpdatedAt    time.Time `json:"updatedAt"`
+		Tags         []string  `json:"tags"`
+		Name         string    `json:"name"`
 	}
 
 	// DeleteExpressionRequest describes the request to delete an expression.
@@ -73,13 +119,279 @@ type (
 		ID string `uri:"id" binding:"required"`
 	}
 
+	// RestoreExpressionRequest describes the request to restore a deleted expression.
+	RestoreExpressionRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
 	// EvaluateExpressionRequest describes the request to evaluate an expression.
 	EvaluateExpressionRequest struct {
 		ID string `uri:"id" binding:"required"`
 	}
 
+	// EvaluateExpressionByNameRequest describes the request to evaluate an expression of the user by its name.
+	EvaluateExpressionByNameRequest struct {
+		Name string `uri:"name" binding:"required"`
+	}
+
+	// EvaluateExpressionBody describes the body of the request to evaluate an expression with JSON variables.
+	// Variables used on their own take true, false, 0 or 1, as JSON values or strings. Variables used in comparisons
+	// take a number or a string, matching the constants they are compared to, or a list of them for IN. Strict
+	// rejects variables the expression does not use.
+	EvaluateExpressionBody struct {
+		Variables map[string]interface{} `json:"variables"`
+		Strict    bool                   `json:"strict"`
+	}
+
+	// EvaluateAdHocRequest describes the request to evaluate an expression that is not stored.
+	EvaluateAdHocRequest struct {
+		Expression string                 `json:"expression" binding:"required"`
+		Variables  map[string]interface{} `json:"variables"`
+		Strict     bool                   `json:"strict"`
+	}
+
+	// MatchExpressionsRequest describes the request to find the expressions made true by a set of variable values.
+	MatchExpressionsRequest struct {
+		Variables map[string]interface{} `json:"variables"`
+		// Tag restricts the search to the expressions with the given tag.
+		Tag string `json:"tag"`
+	}
+
+	// MatchExpressionsResponse lists the matching expressions in creation order.
+	MatchExpressionsResponse struct {
+		ExpressionIDs []uuid.UUID `json:"expressionIDs"`
+	}
+
+	// EvaluateBatchResult is the outcome of a single item of a batch evaluation. Items whose variables could
+	// not be bound have no result and list the offending variables instead.
+	EvaluateBatchResult struct {
+		Index     int                               `json:"index"`
+		Result    *bool                             `json:"result,omitempty"`
+		Error     string                            `json:"error,omitempty"`
+		Variables []experrors.VariableErrorResponse `json:"variables,omitempty"`
+	}
+
+	// EvaluateBatchResponse holds the results of a batch evaluation in the order of the input.
+	EvaluateBatchResponse struct {
+		Results []EvaluateBatchResult `json:"results"`
+	}
+
 	// EvaluateExpressionResponse describes the request to evaluate an expression.
 	EvaluateExpressionResponse struct {
-		Result bool `json:"result"`
+		Result      bool                 `json:"result"`
+		Explanation *ExplanationResponse `json:"explanation,omitempty"`
+	}
+
+	// EvaluateKleeneResponse describes the three-valued result of an evaluation, which is true, false or unknown.
+	// Deciding lists the variables without a value the result still depends on when it is unknown.
+	EvaluateKleeneResponse struct {
+		Result   string   `json:"result"`
+		Deciding []string `json:"deciding,omitempty"`
+	}
+
+	// ExplanationResponse describes how an evaluation reached its result. Justification is a minimal set of
+	// variables, with the values they were given, that decides the result regardless of the values of the other
+	// variables.
+	ExplanationResponse struct {
+		Tree          ExplainNodeResponse    `json:"tree"`
+		Justification map[string]interface{} `json:"justification"`
+	}
+
+	// ExplainNodeResponse describes a node of an evaluated expression. Nodes skipped by a short-circuit are
+	// not evaluated and have no meaningful value.
+	ExplainNodeResponse struct {
+		Expression     string                `json:"expression"`
+		Operator       string                `json:"operator,omitempty"`
+		Variable       string                `json:"variable,omitempty"`
+		Offset         int                   `json:"offset"`
+		Evaluated      bool                  `json:"evaluated"`
+		Value          bool                  `json:"value"`
+		ShortCircuited bool                  `json:"shortCircuited,omitempty"`
+		Children       []ExplainNodeResponse `json:"children,omitempty"`
+	}
+
+	// CacheStatsResponse describes the counters of the compiled expression cache.
+	CacheStatsResponse struct {
+		Hits      uint64 `json:"hits"`
+		Misses    uint64 `json:"misses"`
+		Evictions uint64 `json:"evictions"`
+		Size      int    `json:"size"`
+		Capacity  int    `json:"capacity"`
+	}
+
+	// TruthTableRequest describes the request to generate the truth table of an expression.
+	TruthTableRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// TruthTableResponse describes the truth table of an expression. The values of each row follow the order
+	// of Variables.
+	TruthTableResponse struct {
+		Variables []string                `json:"variables"`
+		Rows      []TruthTableRowResponse `json:"rows"`
+	}
+
+	// TruthTableRowResponse describes a single row of a truth table.
+	TruthTableRowResponse struct {
+		Values []bool `json:"values"`
+		Result bool   `json:"result"`
+	}
+
+	// SatisfiableRequest describes the request to check the satisfiability of an expression.
+	SatisfiableRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// SatisfiableResponse describes the satisfiability of an expression. Witness is an assignment that makes the
+	// expression true and Counterexample one that makes it false, when they exist.
+	SatisfiableResponse struct {
+		Satisfiable    bool            `json:"satisfiable"`
+		Tautology      bool            `json:"tautology"`
+		Contradiction  bool            `json:"contradiction"`
+		Witness        map[string]bool `json:"witness,omitempty"`
+		Counterexample map[string]bool `json:"counterexample,omitempty"`
+	}
+
+	// CompareOperand describes one side of a comparison: either a stored expression ID or a raw expression.
+	CompareOperand struct {
+		ExpressionID string `json:"expression_id"`
+		Expression   string `json:"expression"`
+	}
+
+	// CompareExpressionsRequest describes the request to check whether two expressions are equivalent.
+	CompareExpressionsRequest struct {
+		Left  CompareOperand `json:"left"`
+		Right CompareOperand `json:"right"`
+	}
+
+	// CompareExpressionsResponse describes the response when comparing two expressions. When they are not
+	// equivalent, Counterexample is an assignment where they differ and LeftResult and RightResult are the
+	// results of each expression under it.
+	CompareExpressionsResponse struct {
+		Equivalent     bool            `json:"equivalent"`
+		Counterexample map[string]bool `json:"counterexample,omitempty"`
+		LeftResult     bool            `json:"leftResult"`
+		RightResult    bool            `json:"rightResult"`
+	}
+
+	// SimplifyExpressionRequest describes the request to simplify an expression.
+	SimplifyExpressionRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// SimplifyExpressionOptions describes the query flags of the request to simplify an expression.
+	SimplifyExpressionOptions struct {
+		Save     bool `form:"save"`
+		Minimize bool `form:"minimize"`
+	}
+
+	// SimplifyExpressionResponse describes the response when simplifying an expression.
+	SimplifyExpressionResponse struct {
+		ExpressionID uuid.UUID `json:"expressionID"`
+		Original     string    `json:"original"`
+		Simplified   string    `json:"simplified"`
+		Changed      bool      `json:"changed"`
+		Saved        bool      `json:"saved"`
+	}
+
+	// NormalFormRequest describes the request to convert an expression to a normal form.
+	NormalFormRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// NormalFormOptions describes the query parameters of the request to convert an expression to a normal form.
+	NormalFormOptions struct {
+		Form    string `form:"form" binding:"required"`
+		Tseitin bool   `form:"tseitin"`
+	}
+
+	// NormalFormResponse describes an expression in normal form. Clauses holds the clauses of a CNF or the terms
+	// of a DNF, and Auxiliary the variables introduced by the Tseitin encoding.
+	NormalFormResponse struct {
+		ExpressionID uuid.UUID                     `json:"expressionID"`
+		Form         string                        `json:"form"`
+		Expression   string                        `json:"expression"`
+		Clauses      [][]NormalFormLiteralResponse `json:"clauses"`
+		Auxiliary    []string                      `json:"auxiliary,omitempty"`
+	}
+
+	// NormalFormLiteralResponse describes a possibly negated variable of a normal form.
+	NormalFormLiteralResponse struct {
+		Variable string `json:"variable"`
+		Negated  bool   `json:"negated"`
+	}
+
+	// DependentsRequest describes the request to list the expressions that reference an expression.
+	DependentsRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// DependentsOptions describes the query parameters of the request to list dependents. Transitive also lists
+	// the expressions that reference the expression through other expressions.
+	DependentsOptions struct {
+		Transitive bool `form:"transitive"`
+	}
+
+	// ExpressionVersionsRequest describes the request to list the versions of an expression.
+	ExpressionVersionsRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// ExpressionVersionRequest describes the request to retrieve a version of an expression.
+	ExpressionVersionRequest struct {
+		ID      string `uri:"id" binding:"required"`
+		Version int32  `uri:"version" binding:"required,min=1"`
+	}
+
+	// ExpressionVersionResponse describes a version of an expression: its text as of a create, an update or a
+	// rollback, and the user that made it.
+	ExpressionVersionResponse struct {
+		ExpressionID uuid.UUID `json:"expressionID"`
+		Version      int32     `json:"version"`
+		Expression   string    `json:"expression"`
+		Author       string    `json:"author"`
+		CreatedAt    time.Time `json:"createdAt"`
+	}
+
+	// DiffVersionsRequest describes the request to compare two versions of an expression.
+	DiffVersionsRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// DiffVersionsOptions describes the query parameters of the request to compare two versions of an expression.
+	DiffVersionsOptions struct {
+		From int32 `form:"from" binding:"required,min=1"`
+		To   int32 `form:"to" binding:"required,min=1"`
+	}
+
+	// DiffVersionsResponse describes the changes from one version of an expression to another. Chunks are runs of
+	// tokens that are equal, inserted or deleted, in the order of the expressions.
+	DiffVersionsResponse struct {
+		ExpressionID uuid.UUID           `json:"expressionID"`
+		From         int32               `json:"from"`
+		To           int32               `json:"to"`
+		Chunks       []DiffChunkResponse `json:"chunks"`
+	}
+
+	// DiffChunkResponse describes a run of tokens of a diff. Op is equal, insert or delete.
+	DiffChunkResponse struct {
+		Op   string `json:"op"`
+		Text string `json:"text"`
+	}
+
+	// RollbackExpressionRequest describes the request to restore a version of an expression.
+	RollbackExpressionRequest struct {
+		ID string `uri:"id" binding:"required"`
+	}
+
+	// RollbackExpressionBody describes the body of the request to restore a version of an expression.
+	RollbackExpressionBody struct {
+		Version int32 `json:"version" binding:"required,min=1"`
+	}
+
+	// DependentsResponse describes the expressions that reference an expression.
+	DependentsResponse struct {
+		ExpressionID uuid.UUID                 `json:"expressionID"`
+		Dependents   []ListExpressionsResponse `json:"dependents"`
 	}
 )
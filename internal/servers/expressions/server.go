@@ -24,10 +24,18 @@ type (
 // New instantiates a Server. While initializing, it calls the setupRoutes method.
 func New(config env.Config, store expstore.Store, ev eval.Evaluator) (*Server, error) {
 	// TODO: implement any required validations.
+	ctrlCfg := expcontroller.Config{
+		MaxBatchSize:  config.EvalBatchMaxSize,
+		MaxBatchBytes: int64(config.EvalBatchMaxBytes),
+		BatchTimeout:  config.EvalBatchTimeout,
+		MatchIndexTTL: config.MatchIndexTTL,
+		LinkCacheSize: config.EvalCacheSize,
+		LinkTTL:       config.EvalLinkTTL,
+	}
 	srv := &Server{
 		store:         store,
 		evaluator:     ev,
-		expController: expcontroller.New(store, ev),
+		expController: expcontroller.New(store, ev, ctrlCfg),
 		Config:        config,
 	}
 	router := gin.Default()
@@ -47,14 +55,37 @@ func (f *Server) setupRoutes(router *gin.Engine) {
 	{
 		expGroup.POST("", authmid.AuthMiddleware(), f.expController.Create)
 		expGroup.GET("/:id", authmid.AuthMiddleware(), f.expController.Get)
+		expGroup.GET("/by-name/:name", authmid.AuthMiddleware(), f.expController.GetByName)
 		expGroup.DELETE("/:id", authmid.AuthMiddleware(), f.expController.Delete)
+		expGroup.POST("/:id/restore", authmid.AuthMiddleware(), f.expController.Restore)
 		expGroup.PATCH("", authmid.AuthMiddleware(), f.expController.Update)
 		expGroup.GET("", authmid.AuthMiddleware(), f.expController.List)
+		expGroup.POST("/compare", authmid.AuthMiddleware(), f.expController.Compare)
+		expGroup.GET("/:id/truth-table", authmid.AuthMiddleware(), f.expController.TruthTable)
+		expGroup.GET("/:id/satisfiable", authmid.AuthMiddleware(), f.expController.Satisfiable)
+		expGroup.POST("/:id/simplify", authmid.AuthMiddleware(), f.expController.Simplify)
+		expGroup.GET("/:id/normal-form", authmid.AuthMiddleware(), f.expController.NormalForm)
+		expGroup.GET("/:id/dependents", authmid.AuthMiddleware(), f.expController.Dependents)
+		expGroup.GET("/:id/versions", authmid.AuthMiddleware(), f.expController.Versions)
+		expGroup.GET("/:id/versions/:version", authmid.AuthMiddleware(), f.expController.Version)
+		expGroup.GET("/:id/diff", authmid.AuthMiddleware(), f.expController.DiffVersions)
+		expGroup.POST("/:id/rollback", authmid.AuthMiddleware(), f.expController.Rollback)
 	}
 
 	evalGroup := v1.Group("/evaluate")
 	{
+		evalGroup.POST("", authmid.AuthMiddleware(), f.expController.EvaluateAdHoc)
+		evalGroup.POST("/match", authmid.AuthMiddleware(), f.expController.Match)
 		evalGroup.GET("/:id", authmid.AuthMiddleware(), f.expController.Evaluate)
+		evalGroup.GET("/by-name/:name", authmid.AuthMiddleware(), f.expController.EvaluateByName)
+		evalGroup.POST("/:id", authmid.AuthMiddleware(), f.expController.EvaluateJSON)
+		evalGroup.POST("/:id/batch", authmid.AuthMiddleware(), f.expController.EvaluateBatch)
+		evalGroup.GET(
+			"/cache-stats",
+			authmid.AuthMiddleware(),
+			authmid.AdminMiddleware(f.Config.AdminUserIDs),
+			f.expController.CacheStats,
+		)
 	}
 }
 
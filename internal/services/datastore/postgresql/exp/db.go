@@ -27,17 +27,38 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createExpressionStmt, err = db.PrepareContext(ctx, createExpression); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateExpression: %w", err)
 	}
+	if q.createExpressionVersionStmt, err = db.PrepareContext(ctx, createExpressionVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateExpressionVersion: %w", err)
+	}
 	if q.deleteExpressionByIDStmt, err = db.PrepareContext(ctx, deleteExpressionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteExpressionByID: %w", err)
 	}
+	if q.getDeletedExpressionByIDStmt, err = db.PrepareContext(ctx, getDeletedExpressionByID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeletedExpressionByID: %w", err)
+	}
 	if q.getExpressionByIDStmt, err = db.PrepareContext(ctx, getExpressionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetExpressionByID: %w", err)
 	}
-	if q.listExpressionsStmt, err = db.PrepareContext(ctx, listExpressions); err != nil {
-		return nil, fmt.Errorf("error preparing query ListExpressions: %w", err)
+	if q.getExpressionByNameStmt, err = db.PrepareContext(ctx, getExpressionByName); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExpressionByName: %w", err)
+	}
+	if q.getExpressionVersionStmt, err = db.PrepareContext(ctx, getExpressionVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExpressionVersion: %w", err)
+	}
+	if q.getLatestExpressionVersionStmt, err = db.PrepareContext(ctx, getLatestExpressionVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestExpressionVersion: %w", err)
+	}
+	if q.listExpressionVersionsStmt, err = db.PrepareContext(ctx, listExpressionVersions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListExpressionVersions: %w", err)
+	}
+	if q.listExpressionsByUsernameStmt, err = db.PrepareContext(ctx, listExpressionsByUsername); err != nil {
+		return nil, fmt.Errorf("error preparing query ListExpressionsByUsername: %w", err)
 	}
-	if q.listPaginatedExpressionsStmt, err = db.PrepareContext(ctx, listPaginatedExpressions); err != nil {
-		return nil, fmt.Errorf("error preparing query ListPaginatedExpressions: %w", err)
+	if q.purgeDeletedExpressionsStmt, err = db.PrepareContext(ctx, purgeDeletedExpressions); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeDeletedExpressions: %w", err)
+	}
+	if q.restoreExpressionByIDStmt, err = db.PrepareContext(ctx, restoreExpressionByID); err != nil {
+		return nil, fmt.Errorf("error preparing query RestoreExpressionByID: %w", err)
 	}
 	if q.updateExpressionStmt, err = db.PrepareContext(ctx, updateExpression); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateExpression: %w", err)
@@ -52,24 +73,59 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createExpressionStmt: %w", cerr)
 		}
 	}
+	if q.createExpressionVersionStmt != nil {
+		if cerr := q.createExpressionVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createExpressionVersionStmt: %w", cerr)
+		}
+	}
 	if q.deleteExpressionByIDStmt != nil {
 		if cerr := q.deleteExpressionByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteExpressionByIDStmt: %w", cerr)
 		}
 	}
+	if q.getDeletedExpressionByIDStmt != nil {
+		if cerr := q.getDeletedExpressionByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeletedExpressionByIDStmt: %w", cerr)
+		}
+	}
 	if q.getExpressionByIDStmt != nil {
 		if cerr := q.getExpressionByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getExpressionByIDStmt: %w", cerr)
 		}
 	}
-	if q.listExpressionsStmt != nil {
-		if cerr := q.listExpressionsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing listExpressionsStmt: %w", cerr)
+	if q.getExpressionByNameStmt != nil {
+		if cerr := q.getExpressionByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExpressionByNameStmt: %w", cerr)
+		}
+	}
+	if q.getExpressionVersionStmt != nil {
+		if cerr := q.getExpressionVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExpressionVersionStmt: %w", cerr)
+		}
+	}
+	if q.getLatestExpressionVersionStmt != nil {
+		if cerr := q.getLatestExpressionVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestExpressionVersionStmt: %w", cerr)
+		}
+	}
+	if q.listExpressionVersionsStmt != nil {
+		if cerr := q.listExpressionVersionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listExpressionVersionsStmt: %w", cerr)
+		}
+	}
+	if q.listExpressionsByUsernameStmt != nil {
+		if cerr := q.listExpressionsByUsernameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listExpressionsByUsernameStmt: %w", cerr)
+		}
+	}
+	if q.purgeDeletedExpressionsStmt != nil {
+		if cerr := q.purgeDeletedExpressionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeDeletedExpressionsStmt: %w", cerr)
 		}
 	}
-	if q.listPaginatedExpressionsStmt != nil {
-		if cerr := q.listPaginatedExpressionsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing listPaginatedExpressionsStmt: %w", cerr)
+	if q.restoreExpressionByIDStmt != nil {
+		if cerr := q.restoreExpressionByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restoreExpressionByIDStmt: %w", cerr)
 		}
 	}
 	if q.updateExpressionStmt != nil {
@@ -114,25 +170,39 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                           DBTX
-	tx                           *sql.Tx
-	createExpressionStmt         *sql.Stmt
-	deleteExpressionByIDStmt     *sql.Stmt
-	getExpressionByIDStmt        *sql.Stmt
-	listExpressionsStmt          *sql.Stmt
-	listPaginatedExpressionsStmt *sql.Stmt
-	updateExpressionStmt         *sql.Stmt
+	db                             DBTX
+	tx                             *sql.Tx
+	createExpressionStmt           *sql.Stmt
+	createExpressionVersionStmt    *sql.Stmt
+	deleteExpressionByIDStmt       *sql.Stmt
+	getDeletedExpressionByIDStmt   *sql.Stmt
+	getExpressionByIDStmt          *sql.Stmt
+	getExpressionByNameStmt        *sql.Stmt
+	getExpressionVersionStmt       *sql.Stmt
+	getLatestExpressionVersionStmt *sql.Stmt
+	listExpressionVersionsStmt     *sql.Stmt
+	listExpressionsByUsernameStmt  *sql.Stmt
+	purgeDeletedExpressionsStmt    *sql.Stmt
+	restoreExpressionByIDStmt      *sql.Stmt
+	updateExpressionStmt           *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                           tx,
-		tx:                           tx,
-		createExpressionStmt:         q.createExpressionStmt,
-		deleteExpressionByIDStmt:     q.deleteExpressionByIDStmt,
-		getExpressionByIDStmt:        q.getExpressionByIDStmt,
-		listExpressionsStmt:          q.listExpressionsStmt,
-		listPaginatedExpressionsStmt: q.listPaginatedExpressionsStmt,
-		updateExpressionStmt:         q.updateExpressionStmt,
+		db:                             tx,
+		tx:                             tx,
+		createExpressionStmt:           q.createExpressionStmt,
+		createExpressionVersionStmt:    q.createExpressionVersionStmt,
+		deleteExpressionByIDStmt:       q.deleteExpressionByIDStmt,
+		getDeletedExpressionByIDStmt:   q.getDeletedExpressionByIDStmt,
+		getExpressionByIDStmt:          q.getExpressionByIDStmt,
+		getExpressionByNameStmt:        q.getExpressionByNameStmt,
+		getExpressionVersionStmt:       q.getExpressionVersionStmt,
+		getLatestExpressionVersionStmt: q.getLatestExpressionVersionStmt,
+		listExpressionVersionsStmt:     q.listExpressionVersionsStmt,
+		listExpressionsByUsernameStmt:  q.listExpressionsByUsernameStmt,
+		purgeDeletedExpressionsStmt:    q.purgeDeletedExpressionsStmt,
+		restoreExpressionByIDStmt:      q.restoreExpressionByIDStmt,
+		updateExpressionStmt:           q.updateExpressionStmt,
 	}
 }
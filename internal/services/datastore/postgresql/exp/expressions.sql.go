@@ -10,12 +10,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createExpression = `-- name: CreateExpression :one
-INSERT INTO expressions (expression_id, expression, username, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5)
-    RETURNING row_id, expression_id, expression, username, created_at, updated_at
+INSERT INTO expressions (expression_id, expression, username, created_at, updated_at, tags, name, variables)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    RETURNING row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables
 `
 
 type CreateExpressionParams struct {
@@ -24,6 +25,9 @@ type CreateExpressionParams struct {
 	Username     string    `json:"username"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
+	Tags         []string  `json:"tags"`
+	Name         string    `json:"name"`
+	Variables    []string  `json:"variables"`
 }
 
 func (q *Queries) CreateExpression(ctx context.Context, arg CreateExpressionParams) (Expressions, error) {
@@ -33,6 +37,9 @@ func (q *Queries) CreateExpression(ctx context.Context, arg CreateExpressionPara
 		arg.Username,
 		arg.CreatedAt,
 		arg.UpdatedAt,
+		pq.Array(arg.Tags),
+		arg.Name,
+		pq.Array(arg.Variables),
 	)
 	var i Expressions
 	err := row.Scan(
@@ -42,25 +49,102 @@ func (q *Queries) CreateExpression(ctx context.Context, arg CreateExpressionPara
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		pq.Array(&i.Tags),
+		&i.Name,
+		&i.DeletedAt,
+		&i.Version,
+		pq.Array(&i.Variables),
 	)
 	return i, err
 }
 
-const deleteExpressionByID = `-- name: DeleteExpressionByID :exec
-DELETE
+const createExpressionVersion = `-- name: CreateExpressionVersion :one
+INSERT INTO expression_versions (expression_id, version, expression, author, created_at)
+SELECT $1::uuid, COALESCE(MAX(version), 0) + 1, $2::TEXT, $3::TEXT,
+       $4::timestamptz
+FROM expression_versions
+WHERE expression_id = $1
+    RETURNING expression_id, version, expression, author, created_at
+`
+
+type CreateExpressionVersionParams struct {
+	ExpressionID uuid.UUID `json:"expressionID"`
+	Expression   string    `json:"expression"`
+	Author       string    `json:"author"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (q *Queries) CreateExpressionVersion(ctx context.Context, arg CreateExpressionVersionParams) (ExpressionVersions, error) {
+	row := q.queryRow(ctx, q.createExpressionVersionStmt, createExpressionVersion,
+		arg.ExpressionID,
+		arg.Expression,
+		arg.Author,
+		arg.CreatedAt,
+	)
+	var i ExpressionVersions
+	err := row.Scan(
+		&i.ExpressionID,
+		&i.Version,
+		&i.Expression,
+		&i.Author,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteExpressionByID = `-- name: DeleteExpressionByID :execrows
+UPDATE expressions
+SET deleted_at = now()
+WHERE expression_id = $1
+  AND deleted_at IS NULL
+  AND ($2::INT = 0 OR version = $2::INT)
+`
+
+type DeleteExpressionByIDParams struct {
+	ExpressionID    uuid.UUID `json:"expressionID"`
+	ExpectedVersion int32     `json:"expectedVersion"`
+}
+
+func (q *Queries) DeleteExpressionByID(ctx context.Context, arg DeleteExpressionByIDParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpressionByIDStmt, deleteExpressionByID, arg.ExpressionID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDeletedExpressionByID = `-- name: GetDeletedExpressionByID :one
+SELECT row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables
 FROM expressions
 WHERE expression_id = $1
+  AND deleted_at IS NOT NULL
+    LIMIT 1
 `
 
-func (q *Queries) DeleteExpressionByID(ctx context.Context, expressionID uuid.UUID) error {
-	_, err := q.exec(ctx, q.deleteExpressionByIDStmt, deleteExpressionByID, expressionID)
-	return err
+func (q *Queries) GetDeletedExpressionByID(ctx context.Context, expressionID uuid.UUID) (Expressions, error) {
+	row := q.queryRow(ctx, q.getDeletedExpressionByIDStmt, getDeletedExpressionByID, expressionID)
+	var i Expressions
+	err := row.Scan(
+		&i.RowID,
+		&i.ExpressionID,
+		&i.Expression,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		pq.Array(&i.Tags),
+		&i.Name,
+		&i.DeletedAt,
+		&i.Version,
+		pq.Array(&i.Variables),
+	)
+	return i, err
 }
 
 const getExpressionByID = `-- name: GetExpressionByID :one
-SELECT row_id, expression_id, expression, username, created_at, updated_at
+SELECT row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables
 FROM expressions
 WHERE expression_id = $1
+  AND deleted_at IS NULL
     LIMIT 1
 `
 
@@ -74,32 +158,117 @@ func (q *Queries) GetExpressionByID(ctx context.Context, expressionID uuid.UUID)
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		pq.Array(&i.Tags),
+		&i.Name,
+		&i.DeletedAt,
+		&i.Version,
+		pq.Array(&i.Variables),
 	)
 	return i, err
 }
 
-const listExpressions = `-- name: ListExpressions :many
-SELECT row_id, expression_id, expression, username, created_at, updated_at
+const getExpressionByName = `-- name: GetExpressionByName :one
+SELECT row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables
 FROM expressions
-ORDER BY row_id
+WHERE username = $1
+  AND name = $2
+  AND deleted_at IS NULL
+    LIMIT 1
+`
+
+type GetExpressionByNameParams struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+func (q *Queries) GetExpressionByName(ctx context.Context, arg GetExpressionByNameParams) (Expressions, error) {
+	row := q.queryRow(ctx, q.getExpressionByNameStmt, getExpressionByName, arg.Username, arg.Name)
+	var i Expressions
+	err := row.Scan(
+		&i.RowID,
+		&i.ExpressionID,
+		&i.Expression,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		pq.Array(&i.Tags),
+		&i.Name,
+		&i.DeletedAt,
+		&i.Version,
+		pq.Array(&i.Variables),
+	)
+	return i, err
+}
+
+const getExpressionVersion = `-- name: GetExpressionVersion :one
+SELECT expression_id, version, expression, author, created_at
+FROM expression_versions
+WHERE expression_id = $1
+  AND version = $2
+    LIMIT 1
 `
 
-func (q *Queries) ListExpressions(ctx context.Context) ([]Expressions, error) {
-	rows, err := q.query(ctx, q.listExpressionsStmt, listExpressions)
+type GetExpressionVersionParams struct {
+	ExpressionID uuid.UUID `json:"expressionID"`
+	Version      int32     `json:"version"`
+}
+
+func (q *Queries) GetExpressionVersion(ctx context.Context, arg GetExpressionVersionParams) (ExpressionVersions, error) {
+	row := q.queryRow(ctx, q.getExpressionVersionStmt, getExpressionVersion, arg.ExpressionID, arg.Version)
+	var i ExpressionVersions
+	err := row.Scan(
+		&i.ExpressionID,
+		&i.Version,
+		&i.Expression,
+		&i.Author,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestExpressionVersion = `-- name: GetLatestExpressionVersion :one
+SELECT expression_id, version, expression, author, created_at
+FROM expression_versions
+WHERE expression_id = $1
+ORDER BY version DESC
+    LIMIT 1
+`
+
+func (q *Queries) GetLatestExpressionVersion(ctx context.Context, expressionID uuid.UUID) (ExpressionVersions, error) {
+	row := q.queryRow(ctx, q.getLatestExpressionVersionStmt, getLatestExpressionVersion, expressionID)
+	var i ExpressionVersions
+	err := row.Scan(
+		&i.ExpressionID,
+		&i.Version,
+		&i.Expression,
+		&i.Author,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listExpressionVersions = `-- name: ListExpressionVersions :many
+SELECT expression_id, version, expression, author, created_at
+FROM expression_versions
+WHERE expression_id = $1
+ORDER BY version
+`
+
+func (q *Queries) ListExpressionVersions(ctx context.Context, expressionID uuid.UUID) ([]ExpressionVersions, error) {
+	rows, err := q.query(ctx, q.listExpressionVersionsStmt, listExpressionVersions, expressionID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Expressions{}
+	items := []ExpressionVersions{}
 	for rows.Next() {
-		var i Expressions
+		var i ExpressionVersions
 		if err := rows.Scan(
-			&i.RowID,
 			&i.ExpressionID,
+			&i.Version,
 			&i.Expression,
-			&i.Username,
+			&i.Author,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -114,20 +283,22 @@ func (q *Queries) ListExpressions(ctx context.Context) ([]Expressions, error) {
 	return items, nil
 }
 
-const listPaginatedExpressions = `-- name: ListPaginatedExpressions :many
-SELECT row_id, expression_id, expression, username, created_at, updated_at
+const listExpressionsByUsername = `-- name: ListExpressionsByUsername :many
+SELECT row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables
 FROM expressions
+WHERE username = $1
+  AND ($2::TEXT = '' OR $2::TEXT = ANY (tags))
+  AND deleted_at IS NULL
 ORDER BY row_id
-    LIMIT $1 OFFSET $2
 `
 
-type ListPaginatedExpressionsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListExpressionsByUsernameParams struct {
+	Username string `json:"username"`
+	Tag      string `json:"tag"`
 }
 
-func (q *Queries) ListPaginatedExpressions(ctx context.Context, arg ListPaginatedExpressionsParams) ([]Expressions, error) {
-	rows, err := q.query(ctx, q.listPaginatedExpressionsStmt, listPaginatedExpressions, arg.Limit, arg.Offset)
+func (q *Queries) ListExpressionsByUsername(ctx context.Context, arg ListExpressionsByUsernameParams) ([]Expressions, error) {
+	rows, err := q.query(ctx, q.listExpressionsByUsernameStmt, listExpressionsByUsername, arg.Username, arg.Tag)
 	if err != nil {
 		return nil, err
 	}
@@ -142,6 +313,11 @@ func (q *Queries) ListPaginatedExpressions(ctx context.Context, arg ListPaginate
 			&i.Username,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			pq.Array(&i.Tags),
+			&i.Name,
+			&i.DeletedAt,
+			&i.Version,
+			pq.Array(&i.Variables),
 		); err != nil {
 			return nil, err
 		}
@@ -156,21 +332,83 @@ func (q *Queries) ListPaginatedExpressions(ctx context.Context, arg ListPaginate
 	return items, nil
 }
 
-const updateExpression = `-- name: UpdateExpression :one
+const purgeDeletedExpressions = `-- name: PurgeDeletedExpressions :execrows
+DELETE
+FROM expressions
+WHERE deleted_at < $1::timestamptz
+`
+
+func (q *Queries) PurgeDeletedExpressions(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.purgeDeletedExpressionsStmt, purgeDeletedExpressions, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreExpressionByID = `-- name: RestoreExpressionByID :one
 UPDATE expressions
-SET (expression, updated_at) = ($2, $3)
+SET deleted_at = NULL
 WHERE expression_id = $1
-    RETURNING row_id, expression_id, expression, username, created_at, updated_at
+  AND deleted_at IS NOT NULL
+  AND ($2::INT = 0 OR version = $2::INT)
+    RETURNING row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables
+`
+
+type RestoreExpressionByIDParams struct {
+	ExpressionID    uuid.UUID `json:"expressionID"`
+	ExpectedVersion int32     `json:"expectedVersion"`
+}
+
+func (q *Queries) RestoreExpressionByID(ctx context.Context, arg RestoreExpressionByIDParams) (Expressions, error) {
+	row := q.queryRow(ctx, q.restoreExpressionByIDStmt, restoreExpressionByID, arg.ExpressionID, arg.ExpectedVersion)
+	var i Expressions
+	err := row.Scan(
+		&i.RowID,
+		&i.ExpressionID,
+		&i.Expression,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		pq.Array(&i.Tags),
+		&i.Name,
+		&i.DeletedAt,
+		&i.Version,
+		pq.Array(&i.Variables),
+	)
+	return i, err
+}
+
+const updateExpression = `-- name: UpdateExpression :one
+UPDATE expressions
+SET (expression, name, tags, variables, updated_at, version) = ($1, $2, $3,
+                                                                $4, $5, version + 1)
+WHERE expression_id = $6
+  AND deleted_at IS NULL
+  AND ($7::INT = 0 OR version = $7::INT)
+    RETURNING row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables
 `
 
 type UpdateExpressionParams struct {
-	ExpressionID uuid.UUID `json:"expressionID"`
-	Expression   string    `json:"expression"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	Expression      string    `json:"expression"`
+	Name            string    `json:"name"`
+	Tags            []string  `json:"tags"`
+	Variables       []string  `json:"variables"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	ExpressionID    uuid.UUID `json:"expressionID"`
+	ExpectedVersion int32     `json:"expectedVersion"`
 }
 
 func (q *Queries) UpdateExpression(ctx context.Context, arg UpdateExpressionParams) (Expressions, error) {
-	row := q.queryRow(ctx, q.updateExpressionStmt, updateExpression, arg.ExpressionID, arg.Expression, arg.UpdatedAt)
+	row := q.queryRow(ctx, q.updateExpressionStmt, updateExpression,
+		arg.Expression,
+		arg.Name,
+		pq.Array(arg.Tags),
+		pq.Array(arg.Variables),
+		arg.UpdatedAt,
+		arg.ExpressionID,
+		arg.ExpectedVersion,
+	)
 	var i Expressions
 	err := row.Scan(
 		&i.RowID,
@@ -179,6 +417,11 @@ func (q *Queries) UpdateExpression(ctx context.Context, arg UpdateExpressionPara
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		pq.Array(&i.Tags),
+		&i.Name,
+		&i.DeletedAt,
+		&i.Version,
+		pq.Array(&i.Variables),
 	)
 	return i, err
 }
@@ -0,0 +1,200 @@
+package expstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Columns expressions can be sorted by.
+const (
+	SortCreatedAt ListSort = "created_at"
+	SortUpdatedAt ListSort = "updated_at"
+	SortName      ListSort = "name"
+)
+
+// sortTypes holds the SQL type of each sort column, which the keys of keyset pagination are cast to.
+var sortTypes = map[ListSort]string{
+	SortCreatedAt: "timestamptz",
+	SortUpdatedAt: "timestamptz",
+	SortName:      "TEXT",
+}
+
+// expressionColumns lists the columns of the expressions table in the order Expressions is scanned in.
+const expressionColumns = "row_id, expression_id, expression, username, created_at, updated_at, tags, name, deleted_at, version, variables"
+
+type (
+	// ListSort is a column expressions can be sorted by. Expressions with the same value are sorted by row ID.
+	ListSort string
+
+	// ListFilters selects the expressions that are not deleted and match every filter that is set.
+	ListFilters struct {
+		Username      string
+		CreatedAfter  sql.NullTime
+		CreatedBefore sql.NullTime
+		UpdatedAfter  sql.NullTime
+		UpdatedBefore sql.NullTime
+		// Contains is a LIKE pattern, without the surrounding wildcards, matched against the expression text
+		// regardless of case.
+		Contains string
+		// Search is a full-text search of the expression text.
+		Search   string
+		Variable string
+	}
+
+	// ListExpressionsParams selects, sorts and pages the expressions of a list. Every expression is listed when
+	// Limit is not positive.
+	ListExpressionsParams struct {
+		ListFilters
+		Sort       ListSort
+		Descending bool
+		// After, when set, restricts the list to the expressions that come after the given key in the list
+		// order, for keyset pagination.
+		After  *ListKey
+		Limit  int32
+		Offset int32
+	}
+
+	// ListKey is the position of an expression in a sorted list: the value of its sort column along with its row
+	// ID, which breaks ties.
+	ListKey struct {
+		Value interface{}
+		RowID int64
+	}
+
+	// queryArgs collects the arguments of a query built on the fly.
+	queryArgs []interface{}
+)
+
+// ListExpressions lists the expressions matching the filters, sorted and paged as requested. The query is built
+// from the filters that are set, so that each list only pays for the conditions it uses and the ORDER BY can be
+// served by an index.
+func (q *Queries) ListExpressions(ctx context.Context, arg ListExpressionsParams) ([]Expressions, error) {
+	query, args, err := listQuery(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.query(ctx, nil, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Expressions{}
+	for rows.Next() {
+		var i Expressions
+		if err := rows.Scan(
+			&i.RowID,
+			&i.ExpressionID,
+			&i.Expression,
+			&i.Username,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			pq.Array(&i.Tags),
+			&i.Name,
+			&i.DeletedAt,
+			&i.Version,
+			pq.Array(&i.Variables),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CountExpressions counts the expressions matching the filters.
+func (q *Queries) CountExpressions(ctx context.Context, arg ListFilters) (int64, error) {
+	var args queryArgs
+	query := "SELECT COUNT(*)\nFROM expressions\nWHERE " + arg.where(&args)
+
+	var count int64
+	err := q.queryRow(ctx, nil, query, args...).Scan(&count)
+	return count, err
+}
+
+// listQuery builds the query of a list along with its arguments.
+func listQuery(arg ListExpressionsParams) (string, []interface{}, error) {
+	sort := arg.Sort
+	if sort == "" {
+		sort = SortCreatedAt
+	}
+	if !sort.Valid() {
+		return "", nil, fmt.Errorf("unsupported sort column %q", sort)
+	}
+
+	order, cmp := "", ">"
+	if arg.Descending {
+		order, cmp = " DESC", "<"
+	}
+
+	var args queryArgs
+	var b strings.Builder
+	b.WriteString("SELECT " + expressionColumns + "\nFROM expressions\nWHERE " + arg.where(&args))
+	if arg.After != nil {
+		fmt.Fprintf(&b, "\n  AND (%s, row_id) %s (%s::%s, %s::BIGINT)",
+			sort, cmp, args.add(arg.After.Value), sortTypes[sort], args.add(arg.After.RowID))
+	}
+	fmt.Fprintf(&b, "\nORDER BY %s%s, row_id%s", sort, order, order)
+	if arg.Limit > 0 {
+		b.WriteString("\nLIMIT " + args.add(arg.Limit))
+	}
+	if arg.Offset > 0 {
+		b.WriteString("\nOFFSET " + args.add(arg.Offset))
+	}
+
+	return b.String(), args, nil
+}
+
+// where returns the condition selecting the expressions that match the filters, adding its arguments to args.
+func (f ListFilters) where(args *queryArgs) string {
+	conds := []string{"deleted_at IS NULL"}
+	if f.Username != "" {
+		conds = append(conds, "username = "+args.add(f.Username))
+	}
+	if f.CreatedAfter.Valid {
+		conds = append(conds, "created_at >= "+args.add(f.CreatedAfter.Time))
+	}
+	if f.CreatedBefore.Valid {
+		conds = append(conds, "created_at < "+args.add(f.CreatedBefore.Time))
+	}
+	if f.UpdatedAfter.Valid {
+		conds = append(conds, "updated_at >= "+args.add(f.UpdatedAfter.Time))
+	}
+	if f.UpdatedBefore.Valid {
+		conds = append(conds, "updated_at < "+args.add(f.UpdatedBefore.Time))
+	}
+	if f.Contains != "" {
+		conds = append(conds, "expression ILIKE '%' || "+args.add(f.Contains)+"::TEXT || '%'")
+	}
+	if f.Search != "" {
+		conds = append(conds, "to_tsvector('simple', expression) @@ plainto_tsquery('simple', "+args.add(f.Search)+"::TEXT)")
+	}
+	if f.Variable != "" {
+		conds = append(conds, "variables @> ARRAY ["+args.add(f.Variable)+"::TEXT]")
+	}
+
+	return strings.Join(conds, "\n  AND ")
+}
+
+// Valid reports whether expressions can be sorted by the column.
+func (s ListSort) Valid() bool {
+	_, ok := sortTypes[s]
+	return ok
+}
+
+// add appends an argument and returns its placeholder.
+func (a *queryArgs) add(v interface{}) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
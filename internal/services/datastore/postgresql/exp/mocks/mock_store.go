@@ -7,6 +7,7 @@ package mockedexpstore
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	expstore "github.com/gmaschi/log-exp-eval/internal/services/datastore/postgresql/exp"
 	gomock "github.com/golang/mock/gomock"
@@ -36,6 +37,21 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// CountExpressions mocks base method.
+func (m *MockStore) CountExpressions(arg0 context.Context, arg1 expstore.ListFilters) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountExpressions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountExpressions indicates an expected call of CountExpressions.
+func (mr *MockStoreMockRecorder) CountExpressions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountExpressions", reflect.TypeOf((*MockStore)(nil).CountExpressions), arg0, arg1)
+}
+
 // CreateExpression mocks base method.
 func (m *MockStore) CreateExpression(arg0 context.Context, arg1 expstore.CreateExpressionParams) (expstore.Expressions, error) {
 	m.ctrl.T.Helper()
@@ -51,12 +67,43 @@ func (mr *MockStoreMockRecorder) CreateExpression(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExpression", reflect.TypeOf((*MockStore)(nil).CreateExpression), arg0, arg1)
 }
 
+// CreateExpressionTx mocks base method.
+func (m *MockStore) CreateExpressionTx(arg0 context.Context, arg1 expstore.CreateExpressionParams) (expstore.Expressions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExpressionTx", arg0, arg1)
+	ret0, _ := ret[0].(expstore.Expressions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExpressionTx indicates an expected call of CreateExpressionTx.
+func (mr *MockStoreMockRecorder) CreateExpressionTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExpressionTx", reflect.TypeOf((*MockStore)(nil).CreateExpressionTx), arg0, arg1)
+}
+
+// CreateExpressionVersion mocks base method.
+func (m *MockStore) CreateExpressionVersion(arg0 context.Context, arg1 expstore.CreateExpressionVersionParams) (expstore.ExpressionVersions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExpressionVersion", arg0, arg1)
+	ret0, _ := ret[0].(expstore.ExpressionVersions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExpressionVersion indicates an expected call of CreateExpressionVersion.
+func (mr *MockStoreMockRecorder) CreateExpressionVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExpressionVersion", reflect.TypeOf((*MockStore)(nil).CreateExpressionVersion), arg0, arg1)
+}
+
 // DeleteExpressionByID mocks base method.
-func (m *MockStore) DeleteExpressionByID(arg0 context.Context, arg1 uuid.UUID) error {
+func (m *MockStore) DeleteExpressionByID(arg0 context.Context, arg1 expstore.DeleteExpressionByIDParams) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteExpressionByID", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteExpressionByID indicates an expected call of DeleteExpressionByID.
@@ -65,6 +112,21 @@ func (mr *MockStoreMockRecorder) DeleteExpressionByID(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpressionByID", reflect.TypeOf((*MockStore)(nil).DeleteExpressionByID), arg0, arg1)
 }
 
+// GetDeletedExpressionByID mocks base method.
+func (m *MockStore) GetDeletedExpressionByID(arg0 context.Context, arg1 uuid.UUID) (expstore.Expressions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedExpressionByID", arg0, arg1)
+	ret0, _ := ret[0].(expstore.Expressions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedExpressionByID indicates an expected call of GetDeletedExpressionByID.
+func (mr *MockStoreMockRecorder) GetDeletedExpressionByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedExpressionByID", reflect.TypeOf((*MockStore)(nil).GetDeletedExpressionByID), arg0, arg1)
+}
+
 // GetExpressionByID mocks base method.
 func (m *MockStore) GetExpressionByID(arg0 context.Context, arg1 uuid.UUID) (expstore.Expressions, error) {
 	m.ctrl.T.Helper()
@@ -80,34 +142,124 @@ func (mr *MockStoreMockRecorder) GetExpressionByID(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpressionByID", reflect.TypeOf((*MockStore)(nil).GetExpressionByID), arg0, arg1)
 }
 
+// GetExpressionByName mocks base method.
+func (m *MockStore) GetExpressionByName(arg0 context.Context, arg1 expstore.GetExpressionByNameParams) (expstore.Expressions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpressionByName", arg0, arg1)
+	ret0, _ := ret[0].(expstore.Expressions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpressionByName indicates an expected call of GetExpressionByName.
+func (mr *MockStoreMockRecorder) GetExpressionByName(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpressionByName", reflect.TypeOf((*MockStore)(nil).GetExpressionByName), arg0, arg1)
+}
+
+// GetExpressionVersion mocks base method.
+func (m *MockStore) GetExpressionVersion(arg0 context.Context, arg1 expstore.GetExpressionVersionParams) (expstore.ExpressionVersions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpressionVersion", arg0, arg1)
+	ret0, _ := ret[0].(expstore.ExpressionVersions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpressionVersion indicates an expected call of GetExpressionVersion.
+func (mr *MockStoreMockRecorder) GetExpressionVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpressionVersion", reflect.TypeOf((*MockStore)(nil).GetExpressionVersion), arg0, arg1)
+}
+
+// GetLatestExpressionVersion mocks base method.
+func (m *MockStore) GetLatestExpressionVersion(arg0 context.Context, arg1 uuid.UUID) (expstore.ExpressionVersions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestExpressionVersion", arg0, arg1)
+	ret0, _ := ret[0].(expstore.ExpressionVersions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestExpressionVersion indicates an expected call of GetLatestExpressionVersion.
+func (mr *MockStoreMockRecorder) GetLatestExpressionVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestExpressionVersion", reflect.TypeOf((*MockStore)(nil).GetLatestExpressionVersion), arg0, arg1)
+}
+
+// ListExpressionVersions mocks base method.
+func (m *MockStore) ListExpressionVersions(arg0 context.Context, arg1 uuid.UUID) ([]expstore.ExpressionVersions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpressionVersions", arg0, arg1)
+	ret0, _ := ret[0].([]expstore.ExpressionVersions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpressionVersions indicates an expected call of ListExpressionVersions.
+func (mr *MockStoreMockRecorder) ListExpressionVersions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpressionVersions", reflect.TypeOf((*MockStore)(nil).ListExpressionVersions), arg0, arg1)
+}
+
 // ListExpressions mocks base method.
-func (m *MockStore) ListExpressions(arg0 context.Context) ([]expstore.Expressions, error) {
+func (m *MockStore) ListExpressions(arg0 context.Context, arg1 expstore.ListExpressionsParams) ([]expstore.Expressions, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListExpressions", arg0)
+	ret := m.ctrl.Call(m, "ListExpressions", arg0, arg1)
 	ret0, _ := ret[0].([]expstore.Expressions)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListExpressions indicates an expected call of ListExpressions.
-func (mr *MockStoreMockRecorder) ListExpressions(arg0 interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) ListExpressions(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpressions", reflect.TypeOf((*MockStore)(nil).ListExpressions), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpressions", reflect.TypeOf((*MockStore)(nil).ListExpressions), arg0, arg1)
 }
 
-// ListPaginatedExpressions mocks base method.
-func (m *MockStore) ListPaginatedExpressions(arg0 context.Context, arg1 expstore.ListPaginatedExpressionsParams) ([]expstore.Expressions, error) {
+// ListExpressionsByUsername mocks base method.
+func (m *MockStore) ListExpressionsByUsername(arg0 context.Context, arg1 expstore.ListExpressionsByUsernameParams) ([]expstore.Expressions, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPaginatedExpressions", arg0, arg1)
+	ret := m.ctrl.Call(m, "ListExpressionsByUsername", arg0, arg1)
 	ret0, _ := ret[0].([]expstore.Expressions)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListPaginatedExpressions indicates an expected call of ListPaginatedExpressions.
-func (mr *MockStoreMockRecorder) ListPaginatedExpressions(arg0, arg1 interface{}) *gomock.Call {
+// ListExpressionsByUsername indicates an expected call of ListExpressionsByUsername.
+func (mr *MockStoreMockRecorder) ListExpressionsByUsername(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaginatedExpressions", reflect.TypeOf((*MockStore)(nil).ListPaginatedExpressions), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpressionsByUsername", reflect.TypeOf((*MockStore)(nil).ListExpressionsByUsername), arg0, arg1)
+}
+
+// PurgeDeletedExpressions mocks base method.
+func (m *MockStore) PurgeDeletedExpressions(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedExpressions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedExpressions indicates an expected call of PurgeDeletedExpressions.
+func (mr *MockStoreMockRecorder) PurgeDeletedExpressions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedExpressions", reflect.TypeOf((*MockStore)(nil).PurgeDeletedExpressions), arg0, arg1)
+}
+
+// RestoreExpressionByID mocks base method.
+func (m *MockStore) RestoreExpressionByID(arg0 context.Context, arg1 expstore.RestoreExpressionByIDParams) (expstore.Expressions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreExpressionByID", arg0, arg1)
+	ret0, _ := ret[0].(expstore.Expressions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreExpressionByID indicates an expected call of RestoreExpressionByID.
+func (mr *MockStoreMockRecorder) RestoreExpressionByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreExpressionByID", reflect.TypeOf((*MockStore)(nil).RestoreExpressionByID), arg0, arg1)
 }
 
 // UpdateExpression mocks base method.
@@ -124,3 +276,18 @@ func (mr *MockStoreMockRecorder) UpdateExpression(arg0, arg1 interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpression", reflect.TypeOf((*MockStore)(nil).UpdateExpression), arg0, arg1)
 }
+
+// UpdateExpressionTx mocks base method.
+func (m *MockStore) UpdateExpressionTx(arg0 context.Context, arg1 expstore.UpdateExpressionTxParams) (expstore.Expressions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExpressionTx", arg0, arg1)
+	ret0, _ := ret[0].(expstore.Expressions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateExpressionTx indicates an expected call of UpdateExpressionTx.
+func (mr *MockStoreMockRecorder) UpdateExpressionTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpressionTx", reflect.TypeOf((*MockStore)(nil).UpdateExpressionTx), arg0, arg1)
+}
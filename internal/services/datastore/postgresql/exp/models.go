@@ -5,16 +5,30 @@
 package expstore
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Expressions struct {
-	RowID        int64     `json:"rowID"`
+type ExpressionVersions struct {
 	ExpressionID uuid.UUID `json:"expressionID"`
+	Version      int32     `json:"version"`
 	Expression   string    `json:"expression"`
-	Username     string    `json:"username"`
+	Author       string    `json:"author"`
 	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type Expressions struct {
+	RowID        int64        `json:"rowID"`
+	ExpressionID uuid.UUID    `json:"expressionID"`
+	Expression   string       `json:"expression"`
+	Username     string       `json:"username"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	UpdatedAt    time.Time    `json:"updatedAt"`
+	Tags         []string     `json:"tags"`
+	Name         string       `json:"name"`
+	DeletedAt    sql.NullTime `json:"deletedAt"`
+	Version      int32        `json:"version"`
+	Variables    []string     `json:"variables"`
 }
@@ -6,16 +6,24 @@ package expstore
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
 	CreateExpression(ctx context.Context, arg CreateExpressionParams) (Expressions, error)
-	DeleteExpressionByID(ctx context.Context, expressionID uuid.UUID) error
+	CreateExpressionVersion(ctx context.Context, arg CreateExpressionVersionParams) (ExpressionVersions, error)
+	DeleteExpressionByID(ctx context.Context, arg DeleteExpressionByIDParams) (int64, error)
+	GetDeletedExpressionByID(ctx context.Context, expressionID uuid.UUID) (Expressions, error)
 	GetExpressionByID(ctx context.Context, expressionID uuid.UUID) (Expressions, error)
-	ListExpressions(ctx context.Context) ([]Expressions, error)
-	ListPaginatedExpressions(ctx context.Context, arg ListPaginatedExpressionsParams) ([]Expressions, error)
+	GetExpressionByName(ctx context.Context, arg GetExpressionByNameParams) (Expressions, error)
+	GetExpressionVersion(ctx context.Context, arg GetExpressionVersionParams) (ExpressionVersions, error)
+	GetLatestExpressionVersion(ctx context.Context, expressionID uuid.UUID) (ExpressionVersions, error)
+	ListExpressionVersions(ctx context.Context, expressionID uuid.UUID) ([]ExpressionVersions, error)
+	ListExpressionsByUsername(ctx context.Context, arg ListExpressionsByUsernameParams) ([]Expressions, error)
+	PurgeDeletedExpressions(ctx context.Context, deletedBefore time.Time) (int64, error)
+	RestoreExpressionByID(ctx context.Context, arg RestoreExpressionByIDParams) (Expressions, error)
 	UpdateExpression(ctx context.Context, arg UpdateExpressionParams) (Expressions, error)
 }
 
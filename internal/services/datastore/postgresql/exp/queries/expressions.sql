@@ -2,31 +2,90 @@
 SELECT *
 FROM expressions
 WHERE expression_id = $1
+  AND deleted_at IS NULL
     LIMIT 1;
 
--- name: ListExpressions :many
+-- name: GetDeletedExpressionByID :one
 SELECT *
 FROM expressions
-ORDER BY row_id;
+WHERE expression_id = $1
+  AND deleted_at IS NOT NULL
+    LIMIT 1;
+
+-- name: GetExpressionByName :one
+SELECT *
+FROM expressions
+WHERE username = $1
+  AND name = $2
+  AND deleted_at IS NULL
+    LIMIT 1;
 
--- name: ListPaginatedExpressions :many
+-- name: ListExpressionsByUsername :many
 SELECT *
 FROM expressions
-ORDER BY row_id
-    LIMIT $1 OFFSET $2;
+WHERE username = sqlc.arg(username)
+  AND (sqlc.arg(tag)::TEXT = '' OR sqlc.arg(tag)::TEXT = ANY (tags))
+  AND deleted_at IS NULL
+ORDER BY row_id;
 
 -- name: CreateExpression :one
-INSERT INTO expressions (expression_id, expression, username, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO expressions (expression_id, expression, username, created_at, updated_at, tags, name, variables)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     RETURNING *;
 
 -- name: UpdateExpression :one
 UPDATE expressions
-SET (expression, updated_at) = ($2, $3)
-WHERE expression_id = $1
+SET (expression, name, tags, variables, updated_at, version) = (sqlc.arg(expression), sqlc.arg(name), sqlc.arg(tags),
+                                                                sqlc.arg(variables), sqlc.arg(updated_at), version + 1)
+WHERE expression_id = sqlc.arg(expression_id)
+  AND deleted_at IS NULL
+  AND (sqlc.arg(expected_version)::INT = 0 OR version = sqlc.arg(expected_version)::INT)
+    RETURNING *;
+
+-- name: DeleteExpressionByID :execrows
+UPDATE expressions
+SET deleted_at = now()
+WHERE expression_id = sqlc.arg(expression_id)
+  AND deleted_at IS NULL
+  AND (sqlc.arg(expected_version)::INT = 0 OR version = sqlc.arg(expected_version)::INT);
+
+-- name: RestoreExpressionByID :one
+UPDATE expressions
+SET deleted_at = NULL
+WHERE expression_id = sqlc.arg(expression_id)
+  AND deleted_at IS NOT NULL
+  AND (sqlc.arg(expected_version)::INT = 0 OR version = sqlc.arg(expected_version)::INT)
     RETURNING *;
 
--- name: DeleteExpressionByID :exec
+-- name: PurgeDeletedExpressions :execrows
 DELETE
 FROM expressions
-WHERE expression_id = $1;
\ No newline at end of file
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamptz;
+
+-- name: CreateExpressionVersion :one
+INSERT INTO expression_versions (expression_id, version, expression, author, created_at)
+SELECT sqlc.arg(expression_id)::uuid, COALESCE(MAX(version), 0) + 1, sqlc.arg(expression)::TEXT, sqlc.arg(author)::TEXT,
+       sqlc.arg(created_at)::timestamptz
+FROM expression_versions
+WHERE expression_id = sqlc.arg(expression_id)
+    RETURNING *;
+
+-- name: GetExpressionVersion :one
+SELECT *
+FROM expression_versions
+WHERE expression_id = $1
+  AND version = $2
+    LIMIT 1;
+
+-- name: GetLatestExpressionVersion :one
+SELECT *
+FROM expression_versions
+WHERE expression_id = $1
+ORDER BY version DESC
+    LIMIT 1;
+
+-- name: ListExpressionVersions :many
+SELECT *
+FROM expression_versions
+WHERE expression_id = $1
+ORDER BY version;
\ No newline at end of file
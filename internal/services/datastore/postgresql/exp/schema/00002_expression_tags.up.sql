@@ -0,0 +1,4 @@
+ALTER TABLE expressions ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS expressions_username_idx ON expressions (username);
+CREATE INDEX IF NOT EXISTS expressions_tags_idx ON expressions USING GIN (tags);
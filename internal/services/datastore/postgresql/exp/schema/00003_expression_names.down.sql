@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS expressions_username_name_idx;
+ALTER TABLE expressions DROP COLUMN IF EXISTS name;
\ No newline at end of file
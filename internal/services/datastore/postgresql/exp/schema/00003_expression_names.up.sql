@@ -0,0 +1,4 @@
+ALTER TABLE expressions ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';
+
+-- names are optional, only the expressions that have one must not share it with another expression of their user
+CREATE UNIQUE INDEX IF NOT EXISTS expressions_username_name_idx ON expressions (username, name) WHERE name <> '';
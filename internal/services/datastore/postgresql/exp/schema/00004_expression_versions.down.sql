@@ -0,0 +1 @@
+DROP TABLE IF EXISTS expression_versions;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS expression_versions
+(
+    expression_id uuid        NOT NULL REFERENCES expressions (expression_id) ON DELETE CASCADE,
+    version       INT         NOT NULL,
+    expression    TEXT        NOT NULL,
+    author        TEXT        NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT now(),
+
+    CONSTRAINT expression_versions_pk PRIMARY KEY (expression_id, version)
+);
+
+-- expressions stored before versions were recorded start their history at their current text
+INSERT INTO expression_versions (expression_id, version, expression, author, created_at)
+SELECT expression_id, 1, expression, username, updated_at
+FROM expressions
+ON CONFLICT DO NOTHING;
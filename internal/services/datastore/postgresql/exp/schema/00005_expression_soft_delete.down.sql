@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS expressions_deleted_at_idx;
+DROP INDEX IF EXISTS expressions_username_name_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS expressions_username_name_idx ON expressions (username, name) WHERE name <> '';
+ALTER TABLE expressions DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
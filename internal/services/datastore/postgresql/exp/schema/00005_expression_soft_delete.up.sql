@@ -0,0 +1,7 @@
+ALTER TABLE expressions ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+-- deleted expressions give their name back until they are restored
+DROP INDEX IF EXISTS expressions_username_name_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS expressions_username_name_idx ON expressions (username, name) WHERE name <> '' AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS expressions_deleted_at_idx ON expressions (deleted_at) WHERE deleted_at IS NOT NULL;
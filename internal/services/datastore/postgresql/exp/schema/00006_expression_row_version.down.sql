@@ -0,0 +1 @@
+ALTER TABLE expressions DROP COLUMN IF EXISTS version;
\ No newline at end of file
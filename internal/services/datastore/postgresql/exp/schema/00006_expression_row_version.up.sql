@@ -0,0 +1,2 @@
+-- counts the updates of an expression, compared against the If-Match header of updates
+ALTER TABLE expressions ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS expressions_variables_idx;
+DROP INDEX IF EXISTS expressions_expression_fts_idx;
+DROP INDEX IF EXISTS expressions_expression_trgm_idx;
+DROP INDEX IF EXISTS expressions_name_idx;
+DROP INDEX IF EXISTS expressions_updated_at_idx;
+DROP INDEX IF EXISTS expressions_created_at_idx;
+DROP INDEX IF EXISTS expressions_username_created_at_idx;
+ALTER TABLE expressions DROP COLUMN IF EXISTS variables;
\ No newline at end of file
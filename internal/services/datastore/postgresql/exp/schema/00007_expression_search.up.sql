@@ -0,0 +1,25 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- names of the variables used by the expression, kept in sync by the application
+ALTER TABLE expressions ADD COLUMN IF NOT EXISTS variables TEXT[] NOT NULL DEFAULT '{}';
+
+-- existing expressions get the identifiers left once strings and references are removed, keywords aside
+UPDATE expressions
+SET variables = ARRAY(
+        SELECT DISTINCT m[1]
+        FROM regexp_matches(
+                     regexp_replace(
+                             regexp_replace(expression, '"([^"\\]|\\.)*"', ' ', 'g'),
+                             '@(\{[^}]*\}|[[:alpha:]_][[:alnum:]_.]*)', ' ', 'g'),
+                     '[[:alpha:]_][[:alnum:]_.]*', 'g') AS m
+        WHERE m[1] NOT IN ('NOT', 'AND', 'XOR', 'OR', 'IMPLIES', 'IFF', 'IN')
+        ORDER BY m[1])
+WHERE variables = '{}';
+
+CREATE INDEX IF NOT EXISTS expressions_username_created_at_idx ON expressions (username, created_at, row_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS expressions_created_at_idx ON expressions (created_at, row_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS expressions_updated_at_idx ON expressions (updated_at, row_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS expressions_name_idx ON expressions (name, row_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS expressions_expression_trgm_idx ON expressions USING GIN (expression gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS expressions_expression_fts_idx ON expressions USING GIN (to_tsvector('simple', expression));
+CREATE INDEX IF NOT EXISTS expressions_variables_idx ON expressions USING GIN (variables);
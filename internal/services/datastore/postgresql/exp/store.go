@@ -1,9 +1,30 @@
 package expstore
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the code of the errors raised by PostgreSQL when a row breaks a unique constraint.
+const uniqueViolation = "23505"
 
 type Store interface {
 	Querier
+	ListExpressions(ctx context.Context, arg ListExpressionsParams) ([]Expressions, error)
+	CountExpressions(ctx context.Context, arg ListFilters) (int64, error)
+	CreateExpressionTx(ctx context.Context, arg CreateExpressionParams) (Expressions, error)
+	UpdateExpressionTx(ctx context.Context, arg UpdateExpressionTxParams) (Expressions, error)
+}
+
+// UpdateExpressionTxParams holds the update of an expression along with the user recorded as the author of the
+// version it creates.
+type UpdateExpressionTxParams struct {
+	UpdateExpressionParams
+	Author string
 }
 
 type exp struct {
@@ -20,3 +41,81 @@ func NewStore(db *sql.DB) Store {
 
 	return store
 }
+
+// CreateExpressionTx creates an expression along with its first version, authored by its user.
+func (store *exp) CreateExpressionTx(ctx context.Context, arg CreateExpressionParams) (Expressions, error) {
+	var created Expressions
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		created, err = q.CreateExpression(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateExpressionVersion(ctx, CreateExpressionVersionParams{
+			ExpressionID: created.ExpressionID,
+			Expression:   created.Expression,
+			Author:       created.Username,
+			CreatedAt:    created.UpdatedAt,
+		})
+		return err
+	})
+
+	return created, err
+}
+
+// UpdateExpressionTx updates an expression and records its text as a new version when it changed. Updates that
+// only change the name or the tags do not create a version.
+func (store *exp) UpdateExpressionTx(ctx context.Context, arg UpdateExpressionTxParams) (Expressions, error) {
+	var updated Expressions
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		// the update locks the expression, which serializes the numbering of its versions
+		updated, err = q.UpdateExpression(ctx, arg.UpdateExpressionParams)
+		if err != nil {
+			return err
+		}
+
+		latest, err := q.GetLatestExpressionVersion(ctx, updated.ExpressionID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && latest.Expression == updated.Expression {
+			return nil
+		}
+
+		_, err = q.CreateExpressionVersion(ctx, CreateExpressionVersionParams{
+			ExpressionID: updated.ExpressionID,
+			Expression:   updated.Expression,
+			Author:       arg.Author,
+			CreatedAt:    updated.UpdatedAt,
+		})
+		return err
+	})
+
+	return updated, err
+}
+
+// execTx runs fn within a transaction, which is rolled back when fn fails.
+func (store *exp) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(store.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w: rolling back: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsUniqueViolation reports whether err was raised because a row breaks a unique constraint, such as an
+// expression named like another expression of its user.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,157 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// Node is a node of the abstract syntax tree produced by Parse.
+	Node interface {
+		// Pos returns the byte offset of the node in the parsed expression.
+		Pos() int
+		// String returns the fully parenthesized textual representation of the node.
+		String() string
+	}
+
+	// Operator identifies a logical operator.
+	Operator int
+
+	// Literal is a boolean constant: 1 (true) or 0 (false).
+	Literal struct {
+		Value  bool
+		Offset int
+	}
+
+	// Ident is a variable reference.
+	Ident struct {
+		Name   string
+		Offset int
+	}
+
+	// Unary is a prefix operation over a single operand.
+	Unary struct {
+		Op     Operator
+		X      Node
+		Offset int
+	}
+
+	// Binary is an infix operation between two operands.
+	Binary struct {
+		Op          Operator
+		Left, Right Node
+		Offset      int
+	}
+
+	// Compare is a comparison predicate between a variable and constants, such as age >= 18 or
+	// role IN ("admin", "ops"). Values holds a single constant, or the listed ones for IN.
+	Compare struct {
+		Op     Operator
+		Name   string
+		Values []Value
+		Offset int
+	}
+
+	// Reference points to another stored expression of the same owner, either by name as in @is_premium or by
+	// ID as in @{6f1c...}. Exactly one of Name and ID is set.
+	Reference struct {
+		Name   string
+		ID     uuid.UUID
+		Offset int
+	}
+)
+
+const (
+	OpNot Operator = iota
+	OpAnd
+	OpXor
+	OpOr
+	OpImplies
+	OpIff
+	OpEq
+	OpNe
+	OpLt
+	OpLe
+	OpGt
+	OpGe
+	OpIn
+)
+
+// String returns the keyword of the operator.
+func (op Operator) String() string {
+	switch op {
+	case OpNot:
+		return "NOT"
+	case OpAnd:
+		return "AND"
+	case OpXor:
+		return "XOR"
+	case OpOr:
+		return "OR"
+	case OpImplies:
+		return "IMPLIES"
+	case OpIff:
+		return "IFF"
+	case OpEq:
+		return "=="
+	case OpNe:
+		return "!="
+	case OpLt:
+		return "<"
+	case OpLe:
+		return "<="
+	case OpGt:
+		return ">"
+	case OpGe:
+		return ">="
+	case OpIn:
+		return "IN"
+	default:
+		return fmt.Sprintf("Operator(%d)", int(op))
+	}
+}
+
+func (n *Literal) Pos() int   { return n.Offset }
+func (n *Ident) Pos() int     { return n.Offset }
+func (n *Unary) Pos() int     { return n.Offset }
+func (n *Binary) Pos() int    { return n.Offset }
+func (n *Compare) Pos() int   { return n.Offset }
+func (n *Reference) Pos() int { return n.Offset }
+
+func (n *Literal) String() string {
+	if n.Value {
+		return "1"
+	}
+	return "0"
+}
+
+func (n *Ident) String() string { return n.Name }
+
+func (n *Unary) String() string {
+	return fmt.Sprintf("%s %s", n.Op, n.X)
+}
+
+func (n *Binary) String() string {
+	return fmt.Sprintf("(%s %s %s)", n.Left, n.Op, n.Right)
+}
+
+func (n *Compare) String() string {
+	if n.Op != OpIn {
+		return fmt.Sprintf("%s %s %s", n.Name, n.Op, n.Values[0])
+	}
+
+	values := make([]string, 0, len(n.Values))
+	for _, v := range n.Values {
+		values = append(values, v.String())
+	}
+	return fmt.Sprintf("%s %s (%s)", n.Name, n.Op, strings.Join(values, ", "))
+}
+
+func (n *Reference) String() string {
+	if n.Name != "" {
+		return "@" + n.Name
+	}
+	return "@{" + n.ID.String() + "}"
+}
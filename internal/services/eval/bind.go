@@ -0,0 +1,133 @@
+package eval
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	CodeMissingVariable ErrorCode = "missing_variable"
+	CodeInvalidValue    ErrorCode = "invalid_value"
+	CodeUnknownVariable ErrorCode = "unknown_variable"
+)
+
+// VariableError describes a variable that could not be bound to a program.
+type VariableError struct {
+	Name    string    `json:"name"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// Bind converts decoded JSON values into the bindings of the program, following the type the program uses each
+// variable as. Booleans accept true, false, the numbers 0 and 1 and the strings "true", "false", "0" and "1",
+// numbers accept numeric strings, and compared variables accept lists of their type, which IN compares element
+// by element. Every variable of the program must have a value, and in strict mode values for variables the
+// program does not use are rejected. All problems are reported at once, ordered by variable name.
+func (p *Program) Bind(values map[string]interface{}, strict bool) (Bindings, []VariableError) {
+	used := make(map[string]struct{}, len(p.vars))
+	for _, name := range p.vars {
+		used[name] = struct{}{}
+	}
+
+	var errs []VariableError
+	vars := make(Bindings, len(p.vars))
+	for name, raw := range values {
+		if _, ok := used[name]; !ok {
+			if strict {
+				errs = append(errs, VariableError{
+					Name:    name,
+					Code:    CodeUnknownVariable,
+					Message: "variable is not used by the expression",
+				})
+			}
+			continue
+		}
+
+		v, err := p.Convert(name, raw)
+		if err != nil {
+			errs = append(errs, *err)
+			continue
+		}
+		vars[name] = v
+	}
+
+	for _, name := range p.vars {
+		if _, ok := values[name]; !ok {
+			errs = append(errs, VariableError{
+				Name:    name,
+				Code:    CodeMissingVariable,
+				Message: "variable has no value",
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		sortVariableErrors(errs)
+		return nil, errs
+	}
+
+	return vars, nil
+}
+
+// Convert converts a decoded JSON value, or a query string, into a value of the type the program uses the
+// variable as, accepting the same values as Bind.
+func (p *Program) Convert(name string, raw interface{}) (Value, *VariableError) {
+	typ := p.Type(name)
+	if v, ok := ValueOf(raw); ok {
+		if c, ok := coerce(v, typ); ok {
+			return c, nil
+		}
+	}
+
+	return Value{}, &VariableError{
+		Name:    name,
+		Code:    CodeInvalidValue,
+		Message: fmt.Sprintf("expected %s, received %v", expectedValues(typ), raw),
+	}
+}
+
+// ParseBindings converts decoded JSON values into bindings, keeping the type of every value. Since no program is
+// involved, no variable is missing or unknown, and values are converted when evaluated, accepting the same ones as
+// Program.Bind. Values that are not a boolean, a number, a string or a list of numbers or strings are reported
+// ordered by name.
+func ParseBindings(values map[string]interface{}) (Bindings, []VariableError) {
+	var errs []VariableError
+	vars := make(Bindings, len(values))
+	for name, raw := range values {
+		v, ok := ValueOf(raw)
+		if !ok {
+			errs = append(errs, VariableError{
+				Name:    name,
+				Code:    CodeInvalidValue,
+				Message: fmt.Sprintf("expected a boolean, a number, a string or a list, received %v", raw),
+			})
+			continue
+		}
+		vars[name] = v
+	}
+
+	if len(errs) > 0 {
+		sortVariableErrors(errs)
+		return nil, errs
+	}
+
+	return vars, nil
+}
+
+// expectedValues describes the values accepted for a variable of the given type.
+func expectedValues(typ Type) string {
+	switch typ {
+	case TypeNumber:
+		return "a number or a list of numbers"
+	case TypeString:
+		return "a string or a list of strings"
+	default:
+		return "true, false, 0 or 1"
+	}
+}
+
+func sortVariableErrors(errs []VariableError) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Name < errs[j].Name
+	})
+}
@@ -0,0 +1,153 @@
+package eval_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgram_Bind(t *testing.T) {
+	prog, err := eval.Compile("a AND (b OR c)")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		values   string
+		strict   bool
+		expected eval.Bindings
+		errors   []eval.VariableError
+	}{
+		{
+			name:     "Booleans",
+			values:   `{"a": true, "b": false, "c": true}`,
+			expected: eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(false), "c": eval.Bool(true)},
+		},
+		{
+			name:     "Numbers and strings",
+			values:   `{"a": 1, "b": "0", "c": "TRUE"}`,
+			expected: eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(false), "c": eval.Bool(true)},
+		},
+		{
+			name:     "Unknown variables are ignored when not strict",
+			values:   `{"a": 1, "b": 0, "c": 1, "d": "anything"}`,
+			expected: eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(false), "c": eval.Bool(true)},
+		},
+		{
+			name:   "Unknown variables are rejected when strict",
+			values: `{"a": 1, "b": 0, "c": 1, "d": true}`,
+			strict: true,
+			errors: []eval.VariableError{
+				{Name: "d", Code: eval.CodeUnknownVariable},
+			},
+		},
+		{
+			name:   "Every problem is reported in name order",
+			values: `{"c": 0.5, "a": null, "d": 1}`,
+			strict: true,
+			errors: []eval.VariableError{
+				{Name: "a", Code: eval.CodeInvalidValue},
+				{Name: "b", Code: eval.CodeMissingVariable},
+				{Name: "c", Code: eval.CodeInvalidValue},
+				{Name: "d", Code: eval.CodeUnknownVariable},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var values map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.values), &values))
+
+			vars, errs := prog.Bind(values, tc.strict)
+			require.Equal(t, tc.expected, vars)
+			require.Len(t, errs, len(tc.errors))
+			for i, e := range tc.errors {
+				require.Equal(t, e.Name, errs[i].Name)
+				require.Equal(t, e.Code, errs[i].Code)
+				require.NotEmpty(t, errs[i].Message)
+			}
+		})
+	}
+}
+
+func TestProgram_BindTyped(t *testing.T) {
+	prog, err := eval.Compile(`admin OR (age >= 18 AND country IN ("BR", "PT"))`)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		values   string
+		expected eval.Bindings
+		errors   []eval.VariableError
+	}{
+		{
+			name:   "Values of every type",
+			values: `{"admin": 0, "age": 21, "country": "BR"}`,
+			expected: eval.Bindings{
+				"admin":   eval.Bool(false),
+				"age":     eval.Number(21),
+				"country": eval.String("BR"),
+			},
+		},
+		{
+			name:   "Numeric strings and lists",
+			values: `{"admin": "false", "age": "17.5", "country": ["US", "PT"]}`,
+			expected: eval.Bindings{
+				"admin":   eval.Bool(false),
+				"age":     eval.Number(17.5),
+				"country": eval.List(eval.String("US"), eval.String("PT")),
+			},
+		},
+		{
+			name:   "Values of another type",
+			values: `{"admin": "yes", "age": "old", "country": 42}`,
+			errors: []eval.VariableError{
+				{Name: "admin", Code: eval.CodeInvalidValue},
+				{Name: "age", Code: eval.CodeInvalidValue},
+				{Name: "country", Code: eval.CodeInvalidValue},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var values map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.values), &values))
+
+			vars, errs := prog.Bind(values, true)
+			require.Equal(t, tc.expected, vars)
+			require.Len(t, errs, len(tc.errors))
+			for i, e := range tc.errors {
+				require.Equal(t, e.Name, errs[i].Name)
+				require.Equal(t, e.Code, errs[i].Code)
+				require.NotEmpty(t, errs[i].Message)
+			}
+		})
+	}
+}
+
+func TestParseBindings(t *testing.T) {
+	var values map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a": true, "b": 0, "c": "1", "d": ["x", 2]}`), &values))
+
+	vars, errs := eval.ParseBindings(values)
+	require.Empty(t, errs)
+	require.Equal(t, eval.Bindings{
+		"a": eval.Bool(true),
+		"b": eval.Number(0),
+		"c": eval.String("1"),
+		"d": eval.List(eval.String("x"), eval.Number(2)),
+	}, vars)
+
+	var invalid map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a": true, "c": {"v": 1}, "b": [true]}`), &invalid))
+
+	vars, errs = eval.ParseBindings(invalid)
+	require.Nil(t, vars)
+	require.Len(t, errs, 2)
+	require.Equal(t, "b", errs[0].Name)
+	require.Equal(t, "c", errs[1].Name)
+	require.Equal(t, eval.CodeInvalidValue, errs[1].Code)
+}
@@ -0,0 +1,193 @@
+package eval
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCacheSize is the number of compiled programs kept when no cache size is configured.
+const DefaultCacheSize = 4096
+
+type (
+	// CacheKey identifies a stored expression revision. A cached program is only reused while the
+	// expression's UpdatedAt matches the one it was compiled from.
+	CacheKey struct {
+		ID        uuid.UUID
+		UpdatedAt time.Time
+	}
+
+	// CacheStats holds the counters of the compiled program cache.
+	CacheStats struct {
+		Hits      uint64 `json:"hits"`
+		Misses    uint64 `json:"misses"`
+		Evictions uint64 `json:"evictions"`
+		Size      int    `json:"size"`
+		Capacity  int    `json:"capacity"`
+	}
+
+	// LinkCache is a concurrency-safe LRU of the linked programs of stored expressions, so that evaluating an
+	// expression does not resolve its references again. A linked program is reused while the expression keeps
+	// the revision it was linked from, none of the expressions it references is invalidated and it is younger
+	// than the max age, which bounds how long changes made by other instances of the service are missed.
+	LinkCache struct {
+		cache *programCache
+	}
+
+	// programCache is a concurrency-safe LRU of compiled programs indexed by expression ID. Entries may
+	// depend on other expressions, in which case removing any of them removes the entry too.
+	programCache struct {
+		mu         sync.Mutex
+		capacity   int
+		maxAge     time.Duration
+		order      *list.List
+		entries    map[uuid.UUID]*list.Element
+		dependents map[uuid.UUID]map[uuid.UUID]struct{}
+		hits       uint64
+		misses     uint64
+		evictions  uint64
+	}
+
+	cacheEntry struct {
+		key      CacheKey
+		prog     *Program
+		deps     []uuid.UUID
+		storedAt time.Time
+	}
+)
+
+// newProgramCache instantiates an empty cache of the given capacity whose entries expire maxAge after they
+// were stored. Entries never expire when maxAge is not positive.
+func newProgramCache(capacity int, maxAge time.Duration) *programCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+
+	return &programCache{
+		capacity:   capacity,
+		maxAge:     maxAge,
+		order:      list.New(),
+		entries:    make(map[uuid.UUID]*list.Element, capacity),
+		dependents: make(map[uuid.UUID]map[uuid.UUID]struct{}),
+	}
+}
+
+// NewLinkCache instantiates an empty LinkCache of the given capacity whose programs expire maxAge after they
+// were linked. Programs never expire when maxAge is not positive.
+func NewLinkCache(capacity int, maxAge time.Duration) *LinkCache {
+	return &LinkCache{cache: newProgramCache(capacity, maxAge)}
+}
+
+// Get returns the program linked for the given expression revision.
+func (c *LinkCache) Get(key CacheKey) (*Program, bool) {
+	return c.cache.get(key)
+}
+
+// Put stores the program linked for the given expression revision along with the IDs of the expressions it
+// references, directly or not.
+func (c *LinkCache) Put(key CacheKey, prog *Program, refs []uuid.UUID) {
+	c.cache.put(key, prog, refs...)
+}
+
+// Invalidate drops the program linked for the given expression and every program referencing it.
+func (c *LinkCache) Invalidate(id uuid.UUID) {
+	c.cache.remove(id)
+}
+
+// get returns the program cached for the given key. Entries compiled from another revision of the
+// expression, or older than the max age, are dropped and reported as a miss.
+func (c *programCache) get(key CacheKey) (*Program, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key.ID]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !entry.key.UpdatedAt.Equal(key.UpdatedAt) || (c.maxAge > 0 && time.Since(entry.storedAt) >= c.maxAge) {
+		c.drop(elem)
+		c.misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return entry.prog, true
+}
+
+// put stores the program under the given key, evicting the least recently used entry when full. The entry
+// is removed along with any of the expressions it depends on.
+func (c *programCache) put(key CacheKey, prog *Program, deps ...uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key.ID]; ok {
+		c.drop(elem)
+	}
+
+	c.entries[key.ID] = c.order.PushFront(&cacheEntry{key: key, prog: prog, deps: deps, storedAt: time.Now()})
+	for _, dep := range deps {
+		if c.dependents[dep] == nil {
+			c.dependents[dep] = make(map[uuid.UUID]struct{})
+		}
+		c.dependents[dep][key.ID] = struct{}{}
+	}
+
+	if c.order.Len() > c.capacity {
+		c.drop(c.order.Back())
+		c.evictions++
+	}
+}
+
+// remove drops the program cached for the given expression ID, if any, and every entry depending on it.
+func (c *programCache) remove(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeLocked(id)
+}
+
+func (c *programCache) removeLocked(id uuid.UUID) {
+	if elem, ok := c.entries[id]; ok {
+		c.drop(elem)
+	}
+
+	dependents := c.dependents[id]
+	delete(c.dependents, id)
+	for dependent := range dependents {
+		c.removeLocked(dependent)
+	}
+}
+
+// drop removes the given element and the dependencies it recorded. It must be called with the lock held.
+func (c *programCache) drop(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.key.ID)
+
+	for _, dep := range entry.deps {
+		delete(c.dependents[dep], entry.key.ID)
+		if len(c.dependents[dep]) == 0 {
+			delete(c.dependents, dep)
+		}
+	}
+}
+
+// stats returns a snapshot of the cache counters.
+func (c *programCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.order.Len(),
+		Capacity:  c.capacity,
+	}
+}
@@ -0,0 +1,129 @@
+package eval_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_CompileCached(t *testing.T) {
+	evaluator := eval.New(eval.Config{CacheSize: 2})
+
+	now := time.Now()
+	key1 := eval.CacheKey{ID: uuid.New(), UpdatedAt: now}
+	key2 := eval.CacheKey{ID: uuid.New(), UpdatedAt: now}
+	key3 := eval.CacheKey{ID: uuid.New(), UpdatedAt: now}
+
+	t.Run("Miss compiles and hit reuses the program", func(t *testing.T) {
+		prog, err := evaluator.CompileCached(key1, "x AND y")
+		require.NoError(t, err)
+
+		cachedProg, err := evaluator.CompileCached(key1, "x AND y")
+		require.NoError(t, err)
+		require.Same(t, prog, cachedProg)
+		require.Equal(t, eval.CacheStats{Hits: 1, Misses: 1, Size: 1, Capacity: 2}, evaluator.CacheStats())
+	})
+
+	t.Run("Newer revision is recompiled", func(t *testing.T) {
+		updatedKey := eval.CacheKey{ID: key1.ID, UpdatedAt: now.Add(time.Second)}
+		prog, err := evaluator.CompileCached(updatedKey, "x OR y")
+		require.NoError(t, err)
+		require.Equal(t, "(x OR y)", prog.String())
+		require.Equal(t, eval.CacheStats{Hits: 1, Misses: 2, Size: 1, Capacity: 2}, evaluator.CacheStats())
+		key1 = updatedKey
+	})
+
+	t.Run("Least recently used program is evicted", func(t *testing.T) {
+		_, err := evaluator.CompileCached(key2, "x")
+		require.NoError(t, err)
+		_, err = evaluator.CompileCached(key1, "x OR y")
+		require.NoError(t, err)
+		_, err = evaluator.CompileCached(key3, "y")
+		require.NoError(t, err)
+
+		stats := evaluator.CacheStats()
+		require.Equal(t, uint64(1), stats.Evictions)
+		require.Equal(t, 2, stats.Size)
+
+		_, err = evaluator.CompileCached(key1, "x OR y")
+		require.NoError(t, err)
+		require.Equal(t, stats.Hits+1, evaluator.CacheStats().Hits)
+
+		_, err = evaluator.CompileCached(key2, "x")
+		require.NoError(t, err)
+		require.Equal(t, stats.Misses+1, evaluator.CacheStats().Misses)
+	})
+
+	t.Run("Invalidated program is recompiled", func(t *testing.T) {
+		evaluator.Invalidate(key2.ID)
+		misses := evaluator.CacheStats().Misses
+
+		_, err := evaluator.CompileCached(key2, "x")
+		require.NoError(t, err)
+		require.Equal(t, misses+1, evaluator.CacheStats().Misses)
+	})
+
+	t.Run("Invalid expressions are not cached", func(t *testing.T) {
+		key := eval.CacheKey{ID: uuid.New(), UpdatedAt: now}
+		_, err := evaluator.CompileCached(key, "x AND")
+		require.Error(t, err)
+		require.LessOrEqual(t, evaluator.CacheStats().Size, 2)
+	})
+}
+
+func TestLinkCache(t *testing.T) {
+	now := time.Now()
+	leaf := eval.CacheKey{ID: uuid.New(), UpdatedAt: now}
+	middle := eval.CacheKey{ID: uuid.New(), UpdatedAt: now}
+	root := eval.CacheKey{ID: uuid.New(), UpdatedAt: now}
+
+	newProg := func(t *testing.T, exp string) *eval.Program {
+		prog, err := eval.New(eval.Config{}).Compile(exp)
+		require.NoError(t, err)
+		return prog
+	}
+
+	t.Run("Invalidating a reference drops its dependents", func(t *testing.T) {
+		links := eval.NewLinkCache(4, 0)
+		rootProg := newProg(t, "x AND y")
+		links.Put(middle, newProg(t, "y"), []uuid.UUID{leaf.ID})
+		links.Put(root, rootProg, []uuid.UUID{middle.ID, leaf.ID})
+
+		got, ok := links.Get(root)
+		require.True(t, ok)
+		require.Same(t, rootProg, got)
+
+		links.Invalidate(leaf.ID)
+		_, ok = links.Get(middle)
+		require.False(t, ok)
+		_, ok = links.Get(root)
+		require.False(t, ok)
+	})
+
+	t.Run("Other revisions and unrelated expressions are not reused", func(t *testing.T) {
+		links := eval.NewLinkCache(4, 0)
+		links.Put(root, newProg(t, "x"), []uuid.UUID{middle.ID})
+
+		_, ok := links.Get(eval.CacheKey{ID: root.ID, UpdatedAt: now.Add(time.Second)})
+		require.False(t, ok)
+
+		links.Put(root, newProg(t, "x"), []uuid.UUID{middle.ID})
+		links.Invalidate(leaf.ID)
+		_, ok = links.Get(root)
+		require.True(t, ok)
+	})
+
+	t.Run("Programs expire after the max age", func(t *testing.T) {
+		links := eval.NewLinkCache(4, 10*time.Millisecond)
+		links.Put(root, newProg(t, "x"), []uuid.UUID{leaf.ID})
+		_, ok := links.Get(root)
+		require.True(t, ok)
+
+		time.Sleep(20 * time.Millisecond)
+		_, ok = links.Get(root)
+		require.False(t, ok, "expired programs are linked again")
+	})
+}
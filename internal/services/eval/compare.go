@@ -0,0 +1,201 @@
+package eval
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Assignment maps the atoms of an expression to boolean values. Atoms are the variables used on their own
+// and the comparisons, keyed by their textual form such as "age >= 18". Truth tables and normal forms treat
+// every comparison as an opaque boolean variable, so their results are expressed over atoms. Satisfiability
+// and equivalence also express their results over atoms, but only consider the ones some values of the
+// variables produce.
+type Assignment map[string]bool
+
+// evalCompare returns the result of the comparison for the given value of its variable.
+func evalCompare(n *Compare, v Value) (bool, error) {
+	typ := n.Values[0].Type()
+	if v.Type() == TypeList {
+		if n.Op != OpIn {
+			return false, fmt.Errorf("%w: %s holds a list, which only IN accepts", ErrInvalidValue, n.Name)
+		}
+
+		for _, item := range v.list {
+			found, err := evalCompare(n, item)
+			if err != nil || found {
+				return found, err
+			}
+		}
+		return false, nil
+	}
+
+	c, ok := coerceScalar(v, typ)
+	if !ok {
+		return false, fmt.Errorf("%w: %s expects a %s, received %s", ErrInvalidValue, n.Name, typ, v)
+	}
+	v = c
+
+	switch n.Op {
+	case OpEq:
+		return sameScalar(v, n.Values[0]), nil
+	case OpNe:
+		return !sameScalar(v, n.Values[0]), nil
+	case OpLt:
+		return v.n < n.Values[0].n, nil
+	case OpLe:
+		return v.n <= n.Values[0].n, nil
+	case OpGt:
+		return v.n > n.Values[0].n, nil
+	case OpGe:
+		return v.n >= n.Values[0].n, nil
+	case OpIn:
+		for _, c := range n.Values {
+			if sameScalar(v, c) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported operator %s", n.Op)
+	}
+}
+
+// sameScalar reports whether two numbers, or two strings, are equal.
+func sameScalar(a, b Value) bool {
+	return a.typ == b.typ && a.n == b.n && a.s == b.s
+}
+
+// variableTypes returns the type of every variable of the tree, the type of its first use when uses conflict.
+func variableTypes(root Node) map[string]Type {
+	types := make(map[string]Type)
+	walk(root, func(n Node) {
+		name, typ, ok := variableUse(n)
+		if _, seen := types[name]; ok && !seen {
+			types[name] = typ
+		}
+	})
+
+	return types
+}
+
+// typeErrors returns, ordered by position, the comparisons whose constants do not fit the operator and the
+// variables used with conflicting types, such as both on their own and compared to a number.
+func typeErrors(root Node) []*SyntaxError {
+	var errs []*SyntaxError
+	types := make(map[string]Type)
+	walk(root, func(n Node) {
+		if cmp, ok := n.(*Compare); ok {
+			if err := checkComparison(cmp); err != nil {
+				errs = append(errs, err)
+				return
+			}
+		}
+
+		name, typ, ok := variableUse(n)
+		if !ok {
+			return
+		}
+		first, seen := types[name]
+		if !seen {
+			types[name] = typ
+			return
+		}
+		if first != typ {
+			errs = append(errs, newSyntaxError(n.Pos(), CodeTypeMismatch, fmt.Sprintf("variable %s is used as %s and as %s", name, first, typ)))
+		}
+	})
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Offset < errs[j].Offset
+	})
+	return errs
+}
+
+// checkComparison checks that ordering operators compare numbers and that the constants of IN share a type.
+func checkComparison(n *Compare) *SyntaxError {
+	typ := n.Values[0].Type()
+	for _, v := range n.Values[1:] {
+		if v.Type() != typ {
+			return newSyntaxError(n.Pos(), CodeTypeMismatch, fmt.Sprintf("list of %s mixes %ss and %ss", n.Name, typ, v.Type()))
+		}
+	}
+
+	switch n.Op {
+	case OpLt, OpLe, OpGt, OpGe:
+		if typ != TypeNumber {
+			return newSyntaxError(n.Pos(), CodeTypeMismatch, fmt.Sprintf("operator %s compares numbers, found %s %s", n.Op, typ, n.Values[0]))
+		}
+	}
+
+	return nil
+}
+
+// variableUse returns the variable referenced by n, if any, along with the type n uses it as.
+func variableUse(n Node) (string, Type, bool) {
+	switch n := n.(type) {
+	case *Ident:
+		return n.Name, TypeBool, true
+	case *Compare:
+		return n.Name, n.Values[0].Type(), true
+	default:
+		return "", 0, false
+	}
+}
+
+// atomKey returns the key of an atom: the name of a variable used on its own or the textual form of a
+// comparison or of a reference that is not linked.
+func atomKey(n Node) (string, bool) {
+	switch n := n.(type) {
+	case *Ident:
+		return n.Name, true
+	case *Compare, *Reference:
+		return n.String(), true
+	default:
+		return "", false
+	}
+}
+
+// Atoms returns the sorted, de-duplicated keys of the atoms under the given node.
+func Atoms(root Node) []string {
+	nodes := atomNodes(root)
+	keys := make([]string, 0, len(nodes))
+	for key := range nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// atomNodes maps the keys of the atoms under the given node to the atoms.
+func atomNodes(root Node) map[string]Node {
+	nodes := make(map[string]Node)
+	walk(root, func(n Node) {
+		if key, ok := atomKey(n); ok {
+			nodes[key] = n
+		}
+	})
+
+	return nodes
+}
+
+// atomNode returns the atom of the given key, or a variable of that name when the key is unknown, such as
+// the auxiliary variables of the Tseitin encoding.
+func atomNode(nodes map[string]Node, key string) Node {
+	if n, ok := nodes[key]; ok {
+		return n
+	}
+	return &Ident{Name: key}
+}
+
+// evalAssignment evaluates n with the atoms set to the values of the assignment.
+func evalAssignment(n Node, assign Assignment) (bool, error) {
+	return evalTree(n, func(atom Node) (bool, error) {
+		key, _ := atomKey(atom)
+		v, ok := assign[key]
+		if !ok {
+			return false, fmt.Errorf("%w: %s", ErrUnboundVariable, key)
+		}
+		return v, nil
+	})
+}
@@ -0,0 +1,114 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_EvalComparisons(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		expression string
+		vars       eval.Bindings
+		expRes     bool
+	}{
+		{
+			name:       "Number ordering",
+			expression: "age >= 18 AND age < 65",
+			vars:       eval.Bindings{"age": eval.Number(18)},
+			expRes:     true,
+		},
+		{
+			name:       "Negative and fractional numbers",
+			expression: "balance > -0.5",
+			vars:       eval.Bindings{"balance": eval.Number(-1)},
+			expRes:     false,
+		},
+		{
+			name:       "Numeric strings are numbers",
+			expression: "age == 21",
+			vars:       eval.Bindings{"age": eval.String(" 21.0")},
+			expRes:     true,
+		},
+		{
+			name:       "String equality is case sensitive",
+			expression: `country != "br"`,
+			vars:       eval.Bindings{"country": eval.String("BR")},
+			expRes:     true,
+		},
+		{
+			name:       "IN with a single value",
+			expression: `country IN ("BR", "PT")`,
+			vars:       eval.Bindings{"country": eval.String("PT")},
+			expRes:     true,
+		},
+		{
+			name:       "IN with a list matches any element",
+			expression: "code IN (1, 2, 3)",
+			vars:       eval.Bindings{"code": eval.List(eval.Number(7), eval.String("3"))},
+			expRes:     true,
+		},
+		{
+			name:       "IN with a list without common elements",
+			expression: `country IN ("BR", "PT")`,
+			vars:       eval.Bindings{"country": eval.List(eval.String("US"), eval.String("UK"))},
+			expRes:     false,
+		},
+		{
+			name:       "Comparisons combine with boolean variables",
+			expression: `admin OR (age >= 18 AND country == "BR")`,
+			vars:       eval.Bindings{"admin": eval.Number(0), "age": eval.Number(30), "country": eval.String("BR")},
+			expRes:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := evaluator.EvalLogicExp(tc.expression, tc.vars)
+			require.NoError(t, err)
+			require.Equal(t, tc.expRes, res, "expected %q to be evaluated to %v", tc.expression, tc.expRes)
+		})
+	}
+}
+
+func TestEvaluator_EvalComparisonsInvalidValue(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		expression string
+		vars       eval.Bindings
+	}{
+		{
+			name:       "String compared to a number",
+			expression: "age > 18",
+			vars:       eval.Bindings{"age": eval.String("old")},
+		},
+		{
+			name:       "Number compared to a string",
+			expression: `country == "BR"`,
+			vars:       eval.Bindings{"country": eval.Number(55)},
+		},
+		{
+			name:       "List outside of IN",
+			expression: "age > 18",
+			vars:       eval.Bindings{"age": eval.List(eval.Number(20))},
+		},
+		{
+			name:       "Number used as a boolean",
+			expression: "admin",
+			vars:       eval.Bindings{"admin": eval.Number(2)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := evaluator.EvalLogicExp(tc.expression, tc.vars)
+			require.ErrorIs(t, err, eval.ErrInvalidValue)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Diagnostic describes a single problem found in an expression. Line and Column are 1-based,
+// and Column counts characters rather than bytes.
+type Diagnostic struct {
+	Offset  int       `json:"offset"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// Validate checks the syntax of the given expression, and the types of its variables and comparisons, and
+// returns every problem found, ordered by position. An empty result means the expression is valid.
+func Validate(exp string) []Diagnostic {
+	tokens := lex(exp)
+	if tokens[0].kind == tokEOF {
+		return []Diagnostic{newDiagnostic(exp, newSyntaxError(0, CodeEmptyExpression, "empty expression"))}
+	}
+
+	var (
+		diags         []Diagnostic
+		openParens    []token
+		expectOperand = true
+		prev          = token{kind: tokEOF}
+	)
+	report := func(offset int, code ErrorCode, msg string) {
+		diags = append(diags, newDiagnostic(exp, newSyntaxError(offset, code, msg)))
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.kind == tokIllegal || tok.kind == tokNumber:
+			report(tok.offset, CodeUnknownToken, unknownTokenMessage(tok))
+			// recover by treating the unknown token as an operand
+			expectOperand = false
+		case tok.kind == tokNot:
+			if !expectOperand {
+				report(tok.offset, CodeUnexpectedToken, fmt.Sprintf("expected operator, found prefix operator %s", tok.text))
+			}
+			expectOperand = true
+		case tok.kind.isComparison():
+			switch {
+			case expectOperand:
+				report(tok.offset, CodeDanglingOperator, fmt.Sprintf("operator %s is missing its left variable", tok.text))
+			case prev.kind != tokIdent:
+				report(tok.offset, CodeUnexpectedToken, fmt.Sprintf("operator %s must follow a variable", tok.text))
+			}
+			i = validateComparison(tokens, i, report)
+			tok = tokens[i]
+			expectOperand = false
+		case tok.kind == tokString:
+			if expectOperand {
+				report(tok.offset, CodeUnexpectedToken, fmt.Sprintf("string %s must be compared to a variable", tok.text))
+			} else {
+				report(tok.offset, CodeUnexpectedToken, fmt.Sprintf("expected operator, found %s", describeToken(tok)))
+			}
+			expectOperand = false
+		case tok.kind == tokRef:
+			if _, err := referenceOf(tok); err != nil {
+				report(err.Offset, err.Code, err.Message)
+			}
+			if !expectOperand {
+				report(tok.offset, CodeUnexpectedToken, fmt.Sprintf("expected operator, found %s", describeToken(tok)))
+			}
+			expectOperand = false
+		case tok.kind == tokComma:
+			report(tok.offset, CodeUnexpectedToken, "unexpected ',' outside of a list")
+		case tok.kind.isBinaryOperator():
+			if expectOperand {
+				report(tok.offset, CodeDanglingOperator, fmt.Sprintf("operator %s is missing its left operand", tok.text))
+			}
+			expectOperand = true
+		case tok.kind == tokLParen:
+			if !expectOperand {
+				report(tok.offset, CodeUnexpectedToken, "expected operator before '('")
+			}
+			if tokens[i+1].kind == tokRParen {
+				report(tok.offset, CodeEmptyGroup, "empty parentheses")
+			}
+			openParens = append(openParens, tok)
+			expectOperand = true
+		case tok.kind == tokRParen:
+			if len(openParens) == 0 {
+				report(tok.offset, CodeUnbalancedParen, "unexpected ')' without matching '('")
+				continue
+			}
+			if expectOperand && prev.kind.isOperator() {
+				report(prev.offset, CodeDanglingOperator, missingOperandMessage(prev))
+			}
+			openParens = openParens[:len(openParens)-1]
+			expectOperand = false
+		case tok.kind == tokEOF:
+			if expectOperand && prev.kind.isOperator() {
+				report(prev.offset, CodeDanglingOperator, missingOperandMessage(prev))
+			}
+		default:
+			if !expectOperand {
+				report(tok.offset, CodeUnexpectedToken, fmt.Sprintf("expected operator, found %s %q", tok.kind, tok.text))
+			}
+			expectOperand = false
+		}
+		prev = tok
+	}
+
+	for _, open := range openParens {
+		report(open.offset, CodeUnbalancedParen, "'(' is never closed")
+	}
+
+	if len(diags) == 0 {
+		// the token scan above covers every error the parser reports, this is only a safety net
+		root, err := parse(exp)
+		if err != nil {
+			var syntaxErr *SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return []Diagnostic{newDiagnostic(exp, syntaxErr)}
+			}
+			return nil
+		}
+
+		for _, typeErr := range typeErrors(root) {
+			diags = append(diags, newDiagnostic(exp, typeErr))
+		}
+		return diags
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Offset < diags[j].Offset
+	})
+	return diags
+}
+
+// validateComparison checks the constants of the comparison operator at tokens[i], reporting their problems,
+// and returns the index of the last token of the comparison. After a problem inside the list of IN, the rest
+// of the list is skipped.
+func validateComparison(tokens []token, i int, report func(offset int, code ErrorCode, msg string)) int {
+	op := tokens[i]
+	if op.kind != tokIn {
+		switch next := tokens[i+1]; {
+		case next.kind.isValue():
+			return i + 1
+		case next.kind == tokEOF || next.kind == tokRParen || next.kind.isOperator():
+			report(op.offset, CodeDanglingOperator, missingValueMessage(op))
+		case next.kind == tokIdent || next.kind == tokComma:
+			report(next.offset, CodeUnexpectedToken, fmt.Sprintf("expected number or string, found %s", describeToken(next)))
+			return i + 1
+		}
+		// unknown tokens and parentheses are reported by the caller
+		return i
+	}
+
+	open := tokens[i+1]
+	if open.kind != tokLParen {
+		report(open.offset, CodeUnexpectedToken, fmt.Sprintf("expected '(' after IN, found %s", describeToken(open)))
+		if open.kind.isValue() {
+			// a single value is most likely meant as the list, so it is not reported again
+			return i + 1
+		}
+		return i
+	}
+	if tokens[i+2].kind == tokRParen {
+		report(open.offset, CodeEmptyGroup, "empty list")
+		return i + 2
+	}
+
+	for j := i + 2; ; j += 2 {
+		v := tokens[j]
+		if !v.kind.isValue() {
+			if v.kind == tokIllegal {
+				report(v.offset, CodeUnknownToken, unknownTokenMessage(v))
+			} else {
+				report(v.offset, CodeUnexpectedToken, fmt.Sprintf("expected number or string, found %s", describeToken(v)))
+			}
+			return skipList(tokens, open, j, report)
+		}
+
+		switch sep := tokens[j+1]; sep.kind {
+		case tokComma:
+		case tokRParen:
+			return j + 1
+		case tokEOF:
+			return skipList(tokens, open, j+1, report)
+		default:
+			report(sep.offset, CodeUnexpectedToken, fmt.Sprintf("expected ',' or ')' in list, found %s", describeToken(sep)))
+			return skipList(tokens, open, j+1, report)
+		}
+	}
+}
+
+// skipList returns the index of the ')' closing the list opened by open, starting the search at tokens[from].
+// A list that is never closed is reported, and the index of the last token before the end is returned.
+func skipList(tokens []token, open token, from int, report func(offset int, code ErrorCode, msg string)) int {
+	depth := 0
+	for j := from; ; j++ {
+		switch tokens[j].kind {
+		case tokLParen:
+			depth++
+		case tokRParen:
+			if depth == 0 {
+				return j
+			}
+			depth--
+		case tokEOF:
+			report(open.offset, CodeUnbalancedParen, "'(' is never closed")
+			return j - 1
+		}
+	}
+}
+
+// missingOperandMessage describes an operator that is not followed by an operand.
+func missingOperandMessage(op token) string {
+	if op.kind == tokNot {
+		return fmt.Sprintf("operator %s is missing its operand", op.text)
+	}
+	return fmt.Sprintf("operator %s is missing its right operand", op.text)
+}
+
+// newDiagnostic builds a Diagnostic from a syntax error, resolving its line and column in exp.
+func newDiagnostic(exp string, err *SyntaxError) Diagnostic {
+	line, column := 1, 1
+	for _, r := range exp[:err.Offset] {
+		if r == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+
+	return Diagnostic{
+		Offset:  err.Offset,
+		Line:    line,
+		Column:  column,
+		Code:    err.Code,
+		Message: err.Message,
+	}
+}
+
+// String returns the diagnostic formatted as "line:column: code: message".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Code, d.Message)
+}
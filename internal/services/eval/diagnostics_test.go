@@ -0,0 +1,109 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+		expDiags   []eval.Diagnostic
+	}{
+		{
+			name:       "Valid expression",
+			expression: "((x OR y) AND (z OR k) OR j)",
+			expDiags:   nil,
+		},
+		{
+			name:       "Empty expression",
+			expression: "",
+			expDiags: []eval.Diagnostic{
+				{Offset: 0, Line: 1, Column: 1, Code: eval.CodeEmptyExpression, Message: "empty expression"},
+			},
+		},
+		{
+			name:       "Unclosed parenthesis",
+			expression: "(x AND y",
+			expDiags: []eval.Diagnostic{
+				{Offset: 0, Line: 1, Column: 1, Code: eval.CodeUnbalancedParen, Message: "'(' is never closed"},
+			},
+		},
+		{
+			name:       "Unopened parenthesis",
+			expression: "x AND y)",
+			expDiags: []eval.Diagnostic{
+				{Offset: 7, Line: 1, Column: 8, Code: eval.CodeUnbalancedParen, Message: "unexpected ')' without matching '('"},
+			},
+		},
+		{
+			name:       "Dangling operator inside a group",
+			expression: "(x OR) AND y",
+			expDiags: []eval.Diagnostic{
+				{Offset: 3, Line: 1, Column: 4, Code: eval.CodeDanglingOperator, Message: "operator OR is missing its right operand"},
+			},
+		},
+		{
+			name:       "Empty group",
+			expression: "x AND ()",
+			expDiags: []eval.Diagnostic{
+				{Offset: 6, Line: 1, Column: 7, Code: eval.CodeEmptyGroup, Message: "empty parentheses"},
+			},
+		},
+		{
+			name:       "Valid comparisons",
+			expression: `age >= 18 AND country IN ("BR", "PT")`,
+			expDiags:   nil,
+		},
+		{
+			name:       "Comparison problems",
+			expression: "x == AND 2 > 1 AND y IN ()",
+			expDiags: []eval.Diagnostic{
+				{Offset: 2, Line: 1, Column: 3, Code: eval.CodeDanglingOperator, Message: "operator == is missing its value"},
+				{Offset: 9, Line: 1, Column: 10, Code: eval.CodeUnknownToken, Message: `unknown token "2": only 0 and 1 are valid literals`},
+				{Offset: 11, Line: 1, Column: 12, Code: eval.CodeUnexpectedToken, Message: "operator > must follow a variable"},
+				{Offset: 24, Line: 1, Column: 25, Code: eval.CodeEmptyGroup, Message: "empty list"},
+			},
+		},
+		{
+			name:       "Type mismatch",
+			expression: `x AND x > 1`,
+			expDiags: []eval.Diagnostic{
+				{Offset: 6, Line: 1, Column: 7, Code: eval.CodeTypeMismatch, Message: "variable x is used as bool and as number"},
+			},
+		},
+		{
+			name:       "Reference problems",
+			expression: "@{nope} AND x @a AND @ OR @{abc",
+			expDiags: []eval.Diagnostic{
+				{Offset: 0, Line: 1, Column: 1, Code: eval.CodeInvalidReference, Message: `invalid expression ID "nope" in reference @{nope}`},
+				{Offset: 14, Line: 1, Column: 15, Code: eval.CodeUnexpectedToken, Message: `expected operator, found reference "@a"`},
+				{Offset: 21, Line: 1, Column: 22, Code: eval.CodeUnknownToken, Message: "expected an expression name or {ID} after @"},
+				{Offset: 26, Line: 1, Column: 27, Code: eval.CodeUnknownToken, Message: "reference @{abc is never closed"},
+			},
+		},
+		{
+			name:       "Multiple problems are reported in order",
+			expression: "AND x &\n(y OR",
+			expDiags: []eval.Diagnostic{
+				{Offset: 0, Line: 1, Column: 1, Code: eval.CodeDanglingOperator, Message: "operator AND is missing its left operand"},
+				{Offset: 6, Line: 1, Column: 7, Code: eval.CodeUnknownToken, Message: `unknown token "&"`},
+				{Offset: 8, Line: 2, Column: 1, Code: eval.CodeUnexpectedToken, Message: "expected operator before '('"},
+				{Offset: 8, Line: 2, Column: 1, Code: eval.CodeUnbalancedParen, Message: "'(' is never closed"},
+				{Offset: 11, Line: 2, Column: 4, Code: eval.CodeDanglingOperator, Message: "operator OR is missing its right operand"},
+			},
+		},
+	}
+
+	evaluator := eval.New(eval.Config{})
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := evaluator.Validate(tc.expression)
+			require.Equal(t, tc.expDiags, diags)
+			require.Equal(t, len(tc.expDiags) == 0, evaluator.IsValidLogicExp(tc.expression))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package eval
+
+// Operations of the chunks of a diff.
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+type (
+	// DiffOp tells whether a chunk of a diff is kept, inserted or deleted.
+	DiffOp string
+
+	// DiffChunk is a run of tokens kept, inserted or deleted between two expressions. Text is the run as written
+	// in the expression it comes from: the new one for kept and inserted runs, the old one for deleted runs.
+	DiffChunk struct {
+		Op   DiffOp `json:"op"`
+		Text string `json:"text"`
+	}
+
+	// differ merges consecutive tokens of the same operation into chunks.
+	differ struct {
+		chunks []DiffChunk
+		starts []int
+	}
+)
+
+// Diff returns the chunks turning the expression from into the expression to. Expressions are compared token by
+// token, so changes in whitespace alone are not reported.
+func Diff(from, to string) []DiffChunk {
+	a, b := lex(from), lex(to)
+	a, b = a[:len(a)-1], b[:len(b)-1]
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case sameToken(a[i], b[j]):
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var d differ
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && sameToken(a[i], b[j]):
+			d.add(DiffEqual, to, b[j])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			d.add(DiffDelete, from, a[i])
+			i++
+		default:
+			d.add(DiffInsert, to, b[j])
+			j++
+		}
+	}
+
+	return d.chunks
+}
+
+// add appends the token to the last chunk when it has the same operation, or starts a new chunk.
+func (d *differ) add(op DiffOp, src string, tok token) {
+	end := tok.offset + len(tok.text)
+	last := len(d.chunks) - 1
+	if last >= 0 && d.chunks[last].Op == op {
+		d.chunks[last].Text = src[d.starts[last]:end]
+		return
+	}
+
+	d.chunks = append(d.chunks, DiffChunk{Op: op, Text: tok.text})
+	d.starts = append(d.starts, tok.offset)
+}
+
+func sameToken(a, b token) bool {
+	return a.kind == b.kind && a.text == b.text
+}
@@ -0,0 +1,73 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name   string
+		from   string
+		to     string
+		chunks []eval.DiffChunk
+	}{
+		{
+			name: "Same expressions",
+			from: "(x AND y) OR z",
+			to:   "(x  AND y)\tOR z",
+			chunks: []eval.DiffChunk{
+				{Op: eval.DiffEqual, Text: "(x  AND y)\tOR z"},
+			},
+		},
+		{
+			name: "Replaced operator",
+			from: "x AND y",
+			to:   "x OR y",
+			chunks: []eval.DiffChunk{
+				{Op: eval.DiffEqual, Text: "x"},
+				{Op: eval.DiffDelete, Text: "AND"},
+				{Op: eval.DiffInsert, Text: "OR"},
+				{Op: eval.DiffEqual, Text: "y"},
+			},
+		},
+		{
+			name: "Appended operands",
+			from: "x AND y",
+			to:   `x AND y AND country IN ("BR", "PT")`,
+			chunks: []eval.DiffChunk{
+				{Op: eval.DiffEqual, Text: "x AND y"},
+				{Op: eval.DiffInsert, Text: `AND country IN ("BR", "PT")`},
+			},
+		},
+		{
+			name: "Removed operands",
+			from: "(x OR @rule) AND age >= 18",
+			to:   "x AND age >= 18",
+			chunks: []eval.DiffChunk{
+				{Op: eval.DiffDelete, Text: "("},
+				{Op: eval.DiffEqual, Text: "x"},
+				{Op: eval.DiffDelete, Text: "OR @rule)"},
+				{Op: eval.DiffEqual, Text: "AND age >= 18"},
+			},
+		},
+		{
+			name: "Empty expressions",
+			from: "",
+			to:   "x",
+			chunks: []eval.DiffChunk{
+				{Op: eval.DiffInsert, Text: "x"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.chunks, eval.Diff(tc.from, tc.to))
+		})
+	}
+
+	require.Nil(t, eval.Diff("", ""))
+}
@@ -0,0 +1,49 @@
+package eval
+
+type (
+	// Equivalence describes the result of comparing two expressions.
+	Equivalence struct {
+		// Equivalent reports whether both expressions have the same result for every assignment.
+		Equivalent bool `json:"equivalent"`
+		// Counterexample is an assignment where the expressions differ, nil when they are equivalent.
+		Counterexample Assignment `json:"counterexample,omitempty"`
+		// LeftResult and RightResult are the results of each expression under Counterexample.
+		LeftResult  bool `json:"leftResult"`
+		RightResult bool `json:"rightResult"`
+	}
+)
+
+// equivalent checks whether two programs are logically equivalent. They are when their XOR, which is true
+// exactly on the assignments where they differ, is unsatisfiable. Comparisons of a same variable are related
+// by the theory clauses, so age > 18 and NOT age <= 18 are found equivalent and counterexamples only hold
+// results some values of the variables give.
+func equivalent(left, right *Program) (Equivalence, error) {
+	diff := &Binary{Op: OpXor, Left: left.Root(), Right: right.Root()}
+
+	t := newTseitin(Atoms(diff))
+	out, err := t.encode(diff)
+	if err != nil {
+		return Equivalence{}, err
+	}
+	t.theory(atomNodes(diff))
+
+	counterexample, differ := solve(t, out)
+	if !differ {
+		return Equivalence{Equivalent: true}, nil
+	}
+
+	leftResult, err := left.EvalAssignment(counterexample)
+	if err != nil {
+		return Equivalence{}, err
+	}
+	rightResult, err := right.EvalAssignment(counterexample)
+	if err != nil {
+		return Equivalence{}, err
+	}
+
+	return Equivalence{
+		Counterexample: counterexample,
+		LeftResult:     leftResult,
+		RightResult:    rightResult,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_Equivalent(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		left       string
+		right      string
+		equivalent bool
+	}{
+		{
+			name:       "De Morgan",
+			left:       "NOT (a AND b)",
+			right:      "NOT a OR NOT b",
+			equivalent: true,
+		},
+		{
+			name:       "Implication as disjunction",
+			left:       "a IMPLIES b",
+			right:      "NOT a OR b",
+			equivalent: true,
+		},
+		{
+			name:       "Absorption",
+			left:       "(x OR x) AND (x OR y)",
+			right:      "x",
+			equivalent: true,
+		},
+		{
+			name:  "Different operators",
+			left:  "a AND b",
+			right: "a OR b",
+		},
+		{
+			name:  "Different variables",
+			left:  "a OR b",
+			right: "a OR c",
+		},
+		{
+			name:  "Implication is not symmetric",
+			left:  "a IMPLIES b",
+			right: "b IMPLIES a",
+		},
+		{
+			name:       "Negated comparison",
+			left:       "a > 5",
+			right:      "NOT (a <= 5)",
+			equivalent: true,
+		},
+		{
+			name:       "List and equalities",
+			left:       `country IN ("BR", "PT")`,
+			right:      `country == "BR" OR country == "PT"`,
+			equivalent: true,
+		},
+		{
+			name:  "Overlapping ranges",
+			left:  "age >= 18",
+			right: "age > 18",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			left, err := eval.Compile(tc.left)
+			require.NoError(t, err)
+			right, err := eval.Compile(tc.right)
+			require.NoError(t, err)
+
+			res, err := evaluator.Equivalent(left, right)
+			require.NoError(t, err)
+			require.Equal(t, tc.equivalent, res.Equivalent)
+
+			if tc.equivalent {
+				require.Nil(t, res.Counterexample)
+				return
+			}
+
+			leftResult, err := left.EvalAssignment(res.Counterexample)
+			require.NoError(t, err)
+			rightResult, err := right.EvalAssignment(res.Counterexample)
+			require.NoError(t, err)
+			require.NotEqual(t, leftResult, rightResult)
+			require.Equal(t, leftResult, res.LeftResult)
+			require.Equal(t, rightResult, res.RightResult)
+		})
+	}
+}
+
+func TestEvaluator_EquivalentCounterexampleIsConsistent(t *testing.T) {
+	left, err := eval.Compile("age >= 18")
+	require.NoError(t, err)
+	right, err := eval.Compile("age > 18")
+	require.NoError(t, err)
+
+	res, err := eval.New(eval.Config{}).Equivalent(left, right)
+	require.NoError(t, err)
+	require.False(t, res.Equivalent)
+	// only age = 18 tells the expressions apart
+	require.Equal(t, eval.Assignment{"age >= 18": true, "age > 18": false}, res.Counterexample)
+}
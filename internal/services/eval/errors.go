@@ -0,0 +1,49 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+)
+
+type (
+	// ErrorCode is a stable identifier of a syntax error class.
+	ErrorCode string
+
+	// SyntaxError describes why an expression could not be parsed.
+	SyntaxError struct {
+		Offset  int
+		Code    ErrorCode
+		Message string
+	}
+)
+
+const (
+	CodeEmptyExpression  ErrorCode = "empty_expression"
+	CodeUnknownToken     ErrorCode = "unknown_token"
+	CodeUnbalancedParen  ErrorCode = "unbalanced_paren"
+	CodeDanglingOperator ErrorCode = "dangling_operator"
+	CodeEmptyGroup       ErrorCode = "empty_group"
+	CodeUnexpectedToken  ErrorCode = "unexpected_token"
+	CodeTypeMismatch     ErrorCode = "type_mismatch"
+	CodeInvalidReference ErrorCode = "invalid_reference"
+)
+
+var (
+	// ErrUnboundVariable is returned when a variable without a value is found during evaluation.
+	ErrUnboundVariable = errors.New("unbound variable")
+	// ErrInvalidValue is returned when the value of a variable does not have the type the expression uses it as.
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+func newSyntaxError(offset int, code ErrorCode, msg string) *SyntaxError {
+	return &SyntaxError{
+		Offset:  offset,
+		Code:    code,
+		Message: msg,
+	}
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at offset %d: %s", e.Code, e.Offset, e.Message)
+}
@@ -1,71 +1,290 @@
 package eval
 
-import "strings"
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
 
 type (
+	// Compiler turns expressions into programs and caches the programs of stored expressions.
+	Compiler interface {
+		Validate(exp string) []Diagnostic
+		IsValidLogicExp(exp string) bool
+		Compile(exp string) (*Program, error)
+		CompileCached(key CacheKey, exp string) (*Program, error)
+		Invalidate(id uuid.UUID)
+		CacheStats() CacheStats
+	}
+
+	// Runner evaluates programs with variable values.
+	Runner interface {
+		Eval(prog *Program, vars Bindings) (bool, error)
+		EvalBatch(ctx context.Context, prog *Program, batch []Bindings) ([]BatchResult, error)
+		EvalLogicExp(exp string, vars Bindings) (bool, error)
+		EvalKleene(prog *Program, vars Bindings) (KleeneResult, error)
+		Explain(prog *Program, vars Bindings) (Explanation, error)
+	}
+
+	// Analyzer reasons about programs over every assignment of their variables.
+	Analyzer interface {
+		TruthTable(prog *Program) (TruthTable, error)
+		Satisfiable(prog *Program) (SatResult, error)
+		Equivalent(left, right *Program) (Equivalence, error)
+		Simplify(prog *Program, opts SimplifyOptions) (*Program, error)
+		NormalForm(prog *Program, opts NormalFormOptions) (NormalForm, error)
+	}
+
 	// Evaluator defines the method set to evaluate expressions.
 	Evaluator interface {
-		IsValidLogicExp(exp string) bool
-		EvalLogicExp(exp string) bool
+		Compiler
+		Runner
+		Analyzer
 	}
 
-	eval struct{}
+	// BatchResult is the result of one set of variable values of a batch evaluation, or the error evaluating it.
+	BatchResult struct {
+		Value bool
+		Err   error
+	}
+
+	// Config holds the settings of an evaluator. Zero values fall back to the defaults.
+	Config struct {
+		// CacheSize is the maximum number of compiled programs kept by CompileCached.
+		CacheSize int
+		// MaxTruthTableVariables is the maximum number of variables of an expression whose truth
+		// table can be generated.
+		MaxTruthTableVariables int
+	}
+
+	eval struct {
+		cache                  *programCache
+		maxTruthTableVariables int
+	}
 )
 
 // New instantiates a new evaluator
-func New() Evaluator {
-	return &eval{}
+func New(cfg Config) Evaluator {
+	maxTruthTableVariables := cfg.MaxTruthTableVariables
+	if maxTruthTableVariables <= 0 {
+		maxTruthTableVariables = DefaultMaxTruthTableVariables
+	}
+
+	return &eval{
+		cache:                  newProgramCache(cfg.CacheSize, 0),
+		maxTruthTableVariables: maxTruthTableVariables,
+	}
+}
+
+// Validate checks the syntax of a logical expression and returns the problems found, if any.
+func (e *eval) Validate(exp string) []Diagnostic {
+	return Validate(exp)
 }
 
 // IsValidLogicExp evaluates if a given logical expression is valid.
 func (e *eval) IsValidLogicExp(exp string) bool {
-	// TODO: implement me.
-	return true
-}
-
-// EvalLogicExp evaluates a logical expression and returns the result of the expression.
-func (e *eval) EvalLogicExp(exp string) bool {
-	return evalExpression(exp)
-}
-
-// evalExpression is a helper function to evaluate the result of a logical expression.
-// TODO: implement operator's order of precedence when evaluating an expression to cover some edge cases.
-func evalExpression(exp string) bool {
-	evalExp := strings.ReplaceAll(exp, " ", "")
-	stack := make([]bool, 0)
-	for i := 0; i < len(evalExp); i++ {
-		switch evalExp[i] {
-		case '1':
-			stack = append(stack, true)
-		case '0':
-			stack = append(stack, false)
-		case '(':
-		case ')':
-			val2 := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-			val1 := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-
-			operator := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-			switch operator {
-			case true:
-				stack = append(stack, val1 || val2)
-			case false:
-				stack = append(stack, val1 && val2)
+	return len(Validate(exp)) == 0
+}
+
+// Compile parses a logical expression into a Program that can be evaluated many times.
+func (e *eval) Compile(exp string) (*Program, error) {
+	return Compile(exp)
+}
+
+// CompileCached returns the compiled program of a stored expression, parsing it only when the cache
+// has no program for the given expression revision.
+func (e *eval) CompileCached(key CacheKey, exp string) (*Program, error) {
+	if prog, ok := e.cache.get(key); ok {
+		return prog, nil
+	}
+
+	prog, err := Compile(exp)
+	if err != nil {
+		return nil, err
+	}
+	e.cache.put(key, prog)
+
+	return prog, nil
+}
+
+// Invalidate drops the cached program of the given expression. It must be called whenever a stored
+// expression is updated or deleted.
+func (e *eval) Invalidate(id uuid.UUID) {
+	e.cache.remove(id)
+}
+
+// CacheStats returns the counters of the compiled program cache.
+func (e *eval) CacheStats() CacheStats {
+	return e.cache.stats()
+}
+
+// Eval evaluates a compiled program with the given variable values.
+func (e *eval) Eval(prog *Program, vars Bindings) (bool, error) {
+	return prog.Eval(vars)
+}
+
+// EvalBatch evaluates a compiled program with every set of variable values, returning the results in the
+// same order. Items that cannot be evaluated get their own error without failing the rest of the batch. It
+// stops with the context error once the context is done.
+func (e *eval) EvalBatch(ctx context.Context, prog *Program, batch []Bindings) ([]BatchResult, error) {
+	results := make([]BatchResult, len(batch))
+	for i, vars := range batch {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		results[i].Value, results[i].Err = prog.Eval(vars)
+	}
+
+	return results, nil
+}
+
+// EvalLogicExp evaluates a logical expression with the given variable values and returns the result
+// of the expression. Syntax errors are returned as *SyntaxError.
+func (e *eval) EvalLogicExp(exp string, vars Bindings) (bool, error) {
+	prog, err := Compile(exp)
+	if err != nil {
+		return false, err
+	}
+
+	return prog.Eval(vars)
+}
+
+// TruthTable returns the result of the program for every assignment of its variables. An error wrapping
+// ErrTooManyVariables is returned when the program exceeds the configured variable limit.
+func (e *eval) TruthTable(prog *Program) (TruthTable, error) {
+	return truthTable(prog, e.maxTruthTableVariables)
+}
+
+// Satisfiable reports whether some assignment makes the program true, with a witness assignment, and whether
+// it is a tautology or a contradiction. Instead of enumerating assignments, it runs a DPLL search over the
+// Tseitin encoding of the program, so the cost is not bound to the number of variables.
+func (e *eval) Satisfiable(prog *Program) (SatResult, error) {
+	return satisfiable(prog.Root())
+}
+
+// Equivalent reports whether two programs have the same result for every assignment of their variables.
+// When they do not, an assignment where they differ is returned along with the result of each program.
+func (e *eval) Equivalent(left, right *Program) (Equivalence, error) {
+	return equivalent(left, right)
+}
+
+// Simplify returns an equivalent program rewritten with algebraic identities and, when requested, minimized
+// into a sum of products. Format the root of the result to get its textual form. Minimizing an expression
+// with more variables than the truth table limit fails with an error wrapping ErrTooManyVariables.
+func (e *eval) Simplify(prog *Program, opts SimplifyOptions) (*Program, error) {
+	return simplify(prog, opts, e.maxTruthTableVariables)
+}
+
+// NormalForm converts the program to conjunctive or disjunctive normal form. Conversions by distribution that
+// would exceed MaxNormalFormClauses fail with an error wrapping ErrNormalFormTooLarge; the Tseitin option
+// avoids that growth for CNF.
+func (e *eval) NormalForm(prog *Program, opts NormalFormOptions) (NormalForm, error) {
+	return normalForm(prog, opts)
+}
+
+// Explain evaluates a compiled program like Eval and also returns its tree annotated with the value of every
+// node, marking the short-circuited ones, along with a minimal set of variables that justifies the result.
+func (e *eval) Explain(prog *Program, vars Bindings) (Explanation, error) {
+	return explain(prog, vars)
+}
+
+// EvalKleene evaluates a compiled program with the three-valued logic of Kleene, where variables without a value
+// are unknown. When the result is unknown, the unknown variables it still depends on are returned along with it.
+// Variables with a value of the wrong type fail the evaluation with an error wrapping ErrInvalidValue.
+func (e *eval) EvalKleene(prog *Program, vars Bindings) (KleeneResult, error) {
+	return evalKleene(prog, vars)
+}
+
+// evalNode walks the abstract syntax tree and returns the value of the given node.
+func evalNode(n Node, vars Bindings) (bool, error) {
+	return evalTree(n, vars.atom)
+}
+
+// evalTree walks the abstract syntax tree and returns the value of the given node, resolving variables and
+// comparisons with atom. AND, OR and IMPLIES short-circuit, so their right operand is only visited when needed.
+func evalTree(n Node, atom func(Node) (bool, error)) (bool, error) {
+	switch n := n.(type) {
+	case *Literal:
+		return n.Value, nil
+	case *Ident, *Compare, *Reference:
+		return atom(n)
+	case *Unary:
+		x, err := evalTree(n.X, atom)
+		if err != nil {
+			return false, err
+		}
+
+		if n.Op != OpNot {
+			return false, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		return !x, nil
+	case *Binary:
+		left, err := evalTree(n.Left, atom)
+		if err != nil {
+			return false, err
+		}
+
+		switch n.Op {
+		case OpAnd:
+			if !left {
+				return false, nil
 			}
-		case 'A':
-			if i+3 < len(evalExp) && evalExp[i:i+3] == "AND" {
-				i += 2
-				stack = append(stack, false)
+			return evalTree(n.Right, atom)
+		case OpOr:
+			if left {
+				return true, nil
 			}
-		case 'O':
-			if i+2 < len(evalExp) && evalExp[i:i+2] == "OR" {
-				i++
-				stack = append(stack, true)
+			return evalTree(n.Right, atom)
+		case OpImplies:
+			if !left {
+				return true, nil
 			}
+			return evalTree(n.Right, atom)
 		}
+
+		right, err := evalTree(n.Right, atom)
+		if err != nil {
+			return false, err
+		}
+
+		switch n.Op {
+		case OpXor:
+			return left != right, nil
+		case OpIff:
+			return left == right, nil
+		default:
+			return false, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+	default:
+		return false, fmt.Errorf("unsupported node %T", n)
 	}
+}
 
-	return stack[0]
+// atom returns the value of a variable used on its own or of a comparison. An error wrapping ErrUnboundVariable
+// is returned when the variable has no value, and one wrapping ErrInvalidValue when its value has the wrong type.
+func (vars Bindings) atom(n Node) (bool, error) {
+	switch n := n.(type) {
+	case *Ident:
+		v, ok := vars[n.Name]
+		if !ok {
+			return false, fmt.Errorf("%w: %s", ErrUnboundVariable, n.Name)
+		}
+		b, ok := v.asBool()
+		if !ok {
+			return false, fmt.Errorf("%w: %s expects a %s, received %s", ErrInvalidValue, n.Name, TypeBool, v)
+		}
+		return b, nil
+	case *Compare:
+		v, ok := vars[n.Name]
+		if !ok {
+			return false, fmt.Errorf("%w: %s", ErrUnboundVariable, n.Name)
+		}
+		return evalCompare(n, v)
+	case *Reference:
+		return false, fmt.Errorf("%w: %s", ErrUnresolvedReference, n)
+	default:
+		return false, fmt.Errorf("unsupported node %T", n)
+	}
 }
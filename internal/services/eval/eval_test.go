@@ -1,13 +1,15 @@
 package eval_test
 
 import (
+	"context"
+	"testing"
+
 	"github.com/gmaschi/log-exp-eval/internal/services/eval"
 	"github.com/stretchr/testify/require"
-	"testing"
 )
 
 func TestEvaluator_EvalLogicExp(t *testing.T) {
-	evaluator := eval.New()
+	evaluator := eval.New(eval.Config{})
 
 	testCases := []struct {
 		name       string
@@ -29,12 +31,21 @@ func TestEvaluator_EvalLogicExp(t *testing.T) {
 			expression: "((1 OR 0) AND (1 OR 0) OR 1)",
 			expRes:     true,
 		},
-		// TODO: uncomment after operator precedence is implemented
-		//{
-		//	name:       "Nested true expression to evaluate operator precedence",
-		//expression: "((0 OR 0) AND (1 OR 0) OR 1)",
-		//expRes:     true,
-		//},
+		{
+			name:       "Nested true expression to evaluate operator precedence",
+			expression: "((0 OR 0) AND (1 OR 0) OR 1)",
+			expRes:     true,
+		},
+		{
+			name:       "AND binds tighter than OR without parentheses",
+			expression: "1 AND 0 OR 1",
+			expRes:     true,
+		},
+		{
+			name:       "OR on the left of AND without parentheses",
+			expression: "1 OR 0 AND 0",
+			expRes:     true,
+		},
 		{
 			name:       "Simple false expression",
 			expression: "0",
@@ -50,12 +61,279 @@ func TestEvaluator_EvalLogicExp(t *testing.T) {
 			expression: "(((0 OR 0) AND (1 OR 0)) OR 0)",
 			expRes:     false,
 		},
+		{
+			name:       "False expression without parentheses",
+			expression: "0 OR 1 AND 0",
+			expRes:     false,
+		},
+		{
+			name:       "NOT negates its operand",
+			expression: "NOT 0",
+			expRes:     true,
+		},
+		{
+			name:       "! is an alias of NOT",
+			expression: "!1",
+			expRes:     false,
+		},
+		{
+			name:       "NOT binds tighter than AND",
+			expression: "NOT 0 AND 0",
+			expRes:     false,
+		},
+		{
+			name:       "NOT applied to a group",
+			expression: "NOT (0 AND 0)",
+			expRes:     true,
+		},
+		{
+			name:       "Double negation",
+			expression: "NOT NOT 1",
+			expRes:     true,
+		},
+		{
+			name:       "XOR of different values",
+			expression: "1 XOR 0",
+			expRes:     true,
+		},
+		{
+			name:       "XOR of equal values",
+			expression: "1 XOR 1",
+			expRes:     false,
+		},
+		{
+			name:       "XOR binds tighter than OR",
+			expression: "1 OR 1 XOR 1",
+			expRes:     true,
+		},
+		{
+			name:       "AND binds tighter than XOR",
+			expression: "1 XOR 1 AND 0",
+			expRes:     true,
+		},
+		{
+			name:       "False premise implies anything",
+			expression: "0 IMPLIES 0",
+			expRes:     true,
+		},
+		{
+			name:       "True premise with false conclusion",
+			expression: "1 -> 0",
+			expRes:     false,
+		},
+		{
+			name:       "IMPLIES is right associative",
+			expression: "0 -> 0 -> 0",
+			expRes:     true,
+		},
+		{
+			name:       "OR binds tighter than IMPLIES",
+			expression: "1 OR 0 -> 0",
+			expRes:     false,
+		},
+		{
+			name:       "IFF of equal values",
+			expression: "0 IFF 0",
+			expRes:     true,
+		},
+		{
+			name:       "IFF of different values",
+			expression: "1 <-> 0",
+			expRes:     false,
+		},
+		{
+			name:       "IMPLIES binds tighter than IFF",
+			expression: "1 <-> 0 -> 0",
+			expRes:     true,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			res := evaluator.EvalLogicExp(tc.expression)
+			res, err := evaluator.EvalLogicExp(tc.expression, nil)
+			require.NoError(t, err)
 			require.Equal(t, tc.expRes, res, "expected %q to be evaluated to %v", tc.expression, tc.expRes)
 		})
 	}
 }
+
+func TestEvaluator_EvalLogicExpErrors(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		expression string
+		expCode    eval.ErrorCode
+	}{
+		{
+			name:       "Empty expression",
+			expression: "   ",
+			expCode:    eval.CodeEmptyExpression,
+		},
+		{
+			name:       "Missing closing parenthesis",
+			expression: "(1 AND 0",
+			expCode:    eval.CodeUnbalancedParen,
+		},
+		{
+			name:       "Missing opening parenthesis",
+			expression: "1 AND 0)",
+			expCode:    eval.CodeUnbalancedParen,
+		},
+		{
+			name:       "Operator without right operand",
+			expression: "1 AND",
+			expCode:    eval.CodeDanglingOperator,
+		},
+		{
+			name:       "Operator without left operand",
+			expression: "OR 1",
+			expCode:    eval.CodeDanglingOperator,
+		},
+		{
+			name:       "Empty group",
+			expression: "1 AND ()",
+			expCode:    eval.CodeEmptyGroup,
+		},
+		{
+			name:       "Unknown token",
+			expression: "1 & 0",
+			expCode:    eval.CodeUnknownToken,
+		},
+		{
+			name:       "Missing operator between operands",
+			expression: "1 0",
+			expCode:    eval.CodeUnexpectedToken,
+		},
+		{
+			name:       "NOT without operand",
+			expression: "1 AND NOT",
+			expCode:    eval.CodeDanglingOperator,
+		},
+		{
+			name:       "NOT used as an infix operator",
+			expression: "1 NOT 0",
+			expCode:    eval.CodeUnexpectedToken,
+		},
+		{
+			name:       "Incomplete symbolic operator",
+			expression: "1 <- 0",
+			expCode:    eval.CodeUnknownToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := evaluator.EvalLogicExp(tc.expression, nil)
+			require.Error(t, err)
+
+			var syntaxErr *eval.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			require.Equal(t, tc.expCode, syntaxErr.Code)
+		})
+	}
+}
+
+func TestEvaluator_EvalLogicExpUnboundVariable(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	_, err := evaluator.EvalLogicExp("1 AND x", eval.Bindings{"y": eval.Bool(true)})
+	require.ErrorIs(t, err, eval.ErrUnboundVariable)
+}
+
+func TestEvaluator_EvalLogicExpWithVariables(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		expression string
+		vars       eval.Bindings
+		expRes     bool
+	}{
+		{
+			name:       "Single letter variables",
+			expression: "(x AND y) OR z",
+			vars:       eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)},
+			expRes:     true,
+		},
+		{
+			name:       "Variable name prefix of another variable",
+			expression: "x AND xy",
+			vars:       eval.Bindings{"x": eval.Bool(true), "xy": eval.Bool(false)},
+			expRes:     false,
+		},
+		{
+			name:       "Names with underscores, digits and dots",
+			expression: "is_admin OR user.verified AND rule_2",
+			vars:       eval.Bindings{"is_admin": eval.Bool(false), "user.verified": eval.Bool(true), "rule_2": eval.Bool(true)},
+			expRes:     true,
+		},
+		{
+			name:       "Names are case sensitive",
+			expression: "flag AND NOT Flag",
+			vars:       eval.Bindings{"flag": eval.Bool(true), "Flag": eval.Bool(false)},
+			expRes:     true,
+		},
+		{
+			name:       "Short-circuit does not require the unused variable",
+			expression: "x OR y",
+			vars:       eval.Bindings{"x": eval.Bool(true)},
+			expRes:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := evaluator.EvalLogicExp(tc.expression, tc.vars)
+			require.NoError(t, err)
+			require.Equal(t, tc.expRes, res, "expected %q to be evaluated to %v", tc.expression, tc.expRes)
+		})
+	}
+}
+
+func TestEvaluator_EvalBatch(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+	prog, err := eval.Compile("x AND NOT y")
+	require.NoError(t, err)
+
+	batch := []eval.Bindings{
+		{"x": eval.Bool(true), "y": eval.Bool(false)},
+		{"x": eval.Bool(true), "y": eval.Bool(true)},
+		{"x": eval.Bool(false), "y": eval.Bool(false)},
+	}
+
+	t.Run("Results keep the input order", func(t *testing.T) {
+		results, err := evaluator.EvalBatch(context.Background(), prog, batch)
+		require.NoError(t, err)
+		require.Equal(t, []eval.BatchResult{{Value: true}, {Value: false}, {Value: false}}, results)
+	})
+
+	t.Run("Unbound variables only fail their item", func(t *testing.T) {
+		results, err := evaluator.EvalBatch(context.Background(), prog, append(batch, eval.Bindings{"x": eval.Bool(true)}))
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		require.NoError(t, results[0].Err)
+		require.True(t, results[0].Value)
+		require.ErrorIs(t, results[3].Err, eval.ErrUnboundVariable)
+	})
+
+	t.Run("Cancelled context stops the batch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := evaluator.EvalBatch(ctx, prog, batch)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestProgram(t *testing.T) {
+	prog, err := eval.Compile("(is_admin OR x) AND NOT x AND _flag")
+	require.NoError(t, err)
+	require.Equal(t, []string{"_flag", "is_admin", "x"}, prog.Variables())
+	require.Equal(t, []string{"_flag", "is_admin"}, prog.Missing(eval.Bindings{"x": eval.Bool(true)}))
+	require.Empty(t, prog.Missing(eval.Bindings{"x": eval.Bool(true), "is_admin": eval.Bool(true), "_flag": eval.Bool(false)}))
+
+	res, err := prog.Eval(eval.Bindings{"x": eval.Bool(false), "is_admin": eval.Bool(true), "_flag": eval.Bool(true)})
+	require.NoError(t, err)
+	require.True(t, res)
+}
@@ -0,0 +1,246 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+)
+
+type (
+	// ExplainNode is a node of the abstract syntax tree annotated with its evaluation.
+	ExplainNode struct {
+		Expression string `json:"expression"`
+		// Operator is set for operations and comparisons, and Variable for variable references and comparisons.
+		Operator string `json:"operator,omitempty"`
+		Variable string `json:"variable,omitempty"`
+		Offset   int    `json:"offset"`
+		// Evaluated is false for nodes skipped by a short-circuit, which have no value.
+		Evaluated bool `json:"evaluated"`
+		Value     bool `json:"value"`
+		// ShortCircuited is set on AND, OR and IMPLIES nodes whose right operand was not evaluated.
+		ShortCircuited bool          `json:"shortCircuited,omitempty"`
+		Children       []ExplainNode `json:"children,omitempty"`
+	}
+
+	// Explanation describes how a program reached its result.
+	Explanation struct {
+		Result bool        `json:"result"`
+		Tree   ExplainNode `json:"tree"`
+		// Justification is a minimal set of variables, with their values, that decides the result on its
+		// own: the result is the same whatever the values of the other variables are.
+		Justification Bindings `json:"justification"`
+	}
+)
+
+// explain evaluates the program and annotates every node of its tree with its value.
+func explain(prog *Program, vars Bindings) (Explanation, error) {
+	tree, err := explainNode(prog.Root(), vars)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	return Explanation{
+		Result:        tree.Value,
+		Tree:          tree,
+		Justification: justify(prog.Root(), vars, readVariables(tree), tree.Value),
+	}, nil
+}
+
+// explainNode evaluates n like evalNode, recording the value of every evaluated node.
+func explainNode(n Node, vars Bindings) (ExplainNode, error) {
+	node := ExplainNode{
+		Expression: Format(n),
+		Offset:     n.Pos(),
+		Evaluated:  true,
+	}
+
+	switch n := n.(type) {
+	case *Literal:
+		node.Value = n.Value
+	case *Ident, *Compare, *Reference:
+		v, err := vars.atom(n)
+		if err != nil {
+			return ExplainNode{}, err
+		}
+		node.Variable, _, _ = variableUse(n)
+		node.Value = v
+		if cmp, ok := n.(*Compare); ok {
+			node.Operator = cmp.Op.String()
+		}
+	case *Unary:
+		x, err := explainNode(n.X, vars)
+		if err != nil {
+			return ExplainNode{}, err
+		}
+		if n.Op != OpNot {
+			return ExplainNode{}, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+
+		node.Operator = n.Op.String()
+		node.Value = !x.Value
+		node.Children = []ExplainNode{x}
+	case *Binary:
+		left, err := explainNode(n.Left, vars)
+		if err != nil {
+			return ExplainNode{}, err
+		}
+		node.Operator = n.Op.String()
+
+		// AND stops on false, OR and IMPLIES on a left operand that decides the result on its own
+		if (n.Op == OpAnd && !left.Value) || (n.Op == OpOr && left.Value) || (n.Op == OpImplies && !left.Value) {
+			node.Value = n.Op != OpAnd
+			node.ShortCircuited = true
+			node.Children = []ExplainNode{left, skippedNode(n.Right)}
+			return node, nil
+		}
+
+		right, err := explainNode(n.Right, vars)
+		if err != nil {
+			return ExplainNode{}, err
+		}
+		node.Children = []ExplainNode{left, right}
+
+		switch n.Op {
+		case OpAnd, OpOr, OpImplies:
+			node.Value = right.Value
+		case OpXor:
+			node.Value = left.Value != right.Value
+		case OpIff:
+			node.Value = left.Value == right.Value
+		default:
+			return ExplainNode{}, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+	default:
+		return ExplainNode{}, fmt.Errorf("unsupported node %T", n)
+	}
+
+	return node, nil
+}
+
+// skippedNode describes a subtree that was not evaluated.
+func skippedNode(n Node) ExplainNode {
+	node := ExplainNode{
+		Expression: Format(n),
+		Offset:     n.Pos(),
+	}
+
+	switch n := n.(type) {
+	case *Ident:
+		node.Variable = n.Name
+	case *Compare:
+		node.Operator = n.Op.String()
+		node.Variable = n.Name
+	case *Unary:
+		node.Operator = n.Op.String()
+		node.Children = []ExplainNode{skippedNode(n.X)}
+	case *Binary:
+		node.Operator = n.Op.String()
+		node.Children = []ExplainNode{skippedNode(n.Left), skippedNode(n.Right)}
+	}
+
+	return node
+}
+
+// readVariables returns the variables whose value was read while evaluating the tree, in evaluation order.
+func readVariables(tree ExplainNode) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	var visit func(n ExplainNode)
+	visit = func(n ExplainNode) {
+		if !n.Evaluated {
+			return
+		}
+		if n.Variable != "" && !seen[n.Variable] {
+			seen[n.Variable] = true
+			names = append(names, n.Variable)
+		}
+		for _, child := range n.Children {
+			visit(child)
+		}
+	}
+	visit(tree)
+
+	return names
+}
+
+// justify shrinks the variables read by the evaluation, which always decide the result, to a minimal set.
+// Variables are dropped one at a time as long as the remaining ones still decide the result when every
+// other variable is unknown.
+func justify(root Node, vars Bindings, read []string, result bool) Bindings {
+	kept := make(Bindings, len(read))
+	for _, name := range read {
+		kept[name] = vars[name]
+	}
+
+	for _, name := range read {
+		delete(kept, name)
+		// the kept variables were all read by the evaluation, so their values have the right type
+		if v, known, _ := evalPartial(root, kept); !known || v != result {
+			kept[name] = vars[name]
+		}
+	}
+
+	return kept
+}
+
+// evalPartial evaluates n with three-valued logic, where variables without a value are unknown. It returns false as
+// its second value when the result is unknown, and an error when a variable has a value of the wrong type. Like
+// evalTree, AND, OR and IMPLIES skip their right operand when the left one decides them.
+func evalPartial(n Node, vars Bindings) (bool, bool, error) {
+	switch n := n.(type) {
+	case *Literal:
+		return n.Value, true, nil
+	case *Ident, *Compare, *Reference:
+		v, err := vars.atom(n)
+		if errors.Is(err, ErrUnboundVariable) {
+			return false, false, nil
+		}
+		return v, err == nil, err
+	case *Unary:
+		x, known, err := evalPartial(n.X, vars)
+		return !x, known, err
+	case *Binary:
+		left, leftKnown, err := evalPartial(n.Left, vars)
+		if err != nil {
+			return false, false, err
+		}
+
+		switch {
+		case n.Op == OpAnd && leftKnown && !left:
+			return false, true, nil
+		case n.Op == OpOr && leftKnown && left:
+			return true, true, nil
+		case n.Op == OpImplies && leftKnown && !left:
+			return true, true, nil
+		}
+
+		right, rightKnown, err := evalPartial(n.Right, vars)
+		if err != nil {
+			return false, false, err
+		}
+
+		switch n.Op {
+		case OpAnd:
+			if rightKnown && !right {
+				return false, true, nil
+			}
+			return true, leftKnown && rightKnown, nil
+		case OpOr:
+			if rightKnown && right {
+				return true, true, nil
+			}
+			return false, leftKnown && rightKnown, nil
+		case OpImplies:
+			if rightKnown && right {
+				return true, true, nil
+			}
+			return false, leftKnown && rightKnown, nil
+		case OpXor:
+			return left != right, leftKnown && rightKnown, nil
+		case OpIff:
+			return left == right, leftKnown && rightKnown, nil
+		}
+	}
+
+	return false, false, nil
+}
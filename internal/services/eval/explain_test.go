@@ -0,0 +1,101 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_Explain(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	t.Run("Tree is annotated with values and short-circuits", func(t *testing.T) {
+		prog, err := eval.Compile("a AND (b OR c)")
+		require.NoError(t, err)
+
+		explanation, err := evaluator.Explain(prog, eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(true), "c": eval.Bool(false)})
+		require.NoError(t, err)
+		require.True(t, explanation.Result)
+
+		root := explanation.Tree
+		require.Equal(t, "a AND (b OR c)", root.Expression)
+		require.Equal(t, "AND", root.Operator)
+		require.True(t, root.Evaluated)
+		require.True(t, root.Value)
+		require.False(t, root.ShortCircuited)
+		require.Len(t, root.Children, 2)
+
+		or := root.Children[1]
+		require.Equal(t, "b OR c", or.Expression)
+		require.Equal(t, 9, or.Offset)
+		require.True(t, or.ShortCircuited)
+		require.True(t, or.Children[0].Evaluated)
+		require.Equal(t, "b", or.Children[0].Variable)
+		require.False(t, or.Children[1].Evaluated)
+		require.Equal(t, "c", or.Children[1].Variable)
+	})
+
+	testCases := []struct {
+		name          string
+		expression    string
+		vars          eval.Bindings
+		result        bool
+		justification eval.Bindings
+	}{
+		{
+			name:          "False AND is justified by the false operand",
+			expression:    "a AND b AND c",
+			vars:          eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(false), "c": eval.Bool(true)},
+			justification: eval.Bindings{"b": eval.Bool(false)},
+		},
+		{
+			name:          "True OR is justified by a true operand",
+			expression:    "(a AND b) OR c",
+			vars:          eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(false), "c": eval.Bool(true)},
+			result:        true,
+			justification: eval.Bindings{"c": eval.Bool(true)},
+		},
+		{
+			name:          "True AND needs every operand",
+			expression:    "a AND NOT b",
+			vars:          eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(false)},
+			result:        true,
+			justification: eval.Bindings{"a": eval.Bool(true), "b": eval.Bool(false)},
+		},
+		{
+			name:          "Implication with a false premise",
+			expression:    "a IMPLIES (b XOR c)",
+			vars:          eval.Bindings{"a": eval.Bool(false), "b": eval.Bool(true), "c": eval.Bool(true)},
+			result:        true,
+			justification: eval.Bindings{"a": eval.Bool(false)},
+		},
+		{
+			name:          "Constant expression needs no variable",
+			expression:    "1 OR a",
+			vars:          eval.Bindings{"a": eval.Bool(false)},
+			result:        true,
+			justification: eval.Bindings{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prog, err := eval.Compile(tc.expression)
+			require.NoError(t, err)
+
+			explanation, err := evaluator.Explain(prog, tc.vars)
+			require.NoError(t, err)
+			require.Equal(t, tc.result, explanation.Result)
+			require.Equal(t, tc.justification, explanation.Justification)
+		})
+	}
+
+	t.Run("Unbound variable", func(t *testing.T) {
+		prog, err := eval.Compile("a OR b")
+		require.NoError(t, err)
+
+		_, err = evaluator.Explain(prog, eval.Bindings{"a": eval.Bool(false)})
+		require.ErrorIs(t, err, eval.ErrUnboundVariable)
+	})
+}
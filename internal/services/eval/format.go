@@ -0,0 +1,66 @@
+package eval
+
+import "strings"
+
+// Format returns the textual form of the tree using only the parentheses required by operator precedence
+// and associativity. Parsing the result yields the same tree.
+func Format(n Node) string {
+	var b strings.Builder
+	format(&b, n)
+	return b.String()
+}
+
+func format(b *strings.Builder, n Node) {
+	switch n := n.(type) {
+	case *Unary:
+		b.WriteString(n.Op.String())
+		b.WriteByte(' ')
+		formatOperand(b, n.X, isBinary(n.X))
+	case *Binary:
+		bop := binaryOperatorOf(n.Op)
+		formatOperand(b, n.Left, needsParens(n.Left, bop, bop.rightAssoc))
+		b.WriteByte(' ')
+		b.WriteString(n.Op.String())
+		b.WriteByte(' ')
+		formatOperand(b, n.Right, needsParens(n.Right, bop, !bop.rightAssoc))
+	default:
+		b.WriteString(n.String())
+	}
+}
+
+func formatOperand(b *strings.Builder, n Node, parens bool) {
+	if parens {
+		b.WriteByte('(')
+	}
+	format(b, n)
+	if parens {
+		b.WriteByte(')')
+	}
+}
+
+// needsParens reports whether the operand of a binary operation must be parenthesized. Operands with the
+// same precedence as their parent only need them on the side the operator does not associate to.
+func needsParens(operand Node, parent binaryOperator, againstAssoc bool) bool {
+	child, ok := operand.(*Binary)
+	if !ok {
+		return false
+	}
+
+	prec := binaryOperatorOf(child.Op).precedence
+	return prec < parent.precedence || (prec == parent.precedence && againstAssoc)
+}
+
+// binaryOperatorOf returns the parsing rules of the given binary operator.
+func binaryOperatorOf(op Operator) binaryOperator {
+	for _, bop := range binaryOperators {
+		if bop.op == op {
+			return bop
+		}
+	}
+	return binaryOperator{op: op}
+}
+
+func isBinary(n Node) bool {
+	_, ok := n.(*Binary)
+	return ok
+}
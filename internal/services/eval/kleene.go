@@ -0,0 +1,72 @@
+package eval
+
+import "sort"
+
+// Truth values of the three-valued logic of Kleene.
+const (
+	TruthTrue    Truth = "true"
+	TruthFalse   Truth = "false"
+	TruthUnknown Truth = "unknown"
+)
+
+type (
+	// Truth is the value of a program under the three-valued logic of Kleene.
+	Truth string
+
+	// KleeneResult is the result of a program evaluated with some variables unknown.
+	KleeneResult struct {
+		Result Truth `json:"result"`
+		// Deciding lists, sorted, the unknown variables the result still depends on when it is unknown: the ones
+		// that are not made irrelevant by the known values, such as y in x AND y when x is true.
+		Deciding []string `json:"deciding,omitempty"`
+	}
+)
+
+// evalKleene evaluates the program with Kleene semantics: variables without a value are unknown, AND is false
+// as soon as an operand is false, OR is true as soon as an operand is true, and any other operation with an
+// unknown operand is unknown. Variables with a value of the wrong type are not unknown but fail the evaluation.
+func evalKleene(prog *Program, vars Bindings) (KleeneResult, error) {
+	v, known, err := evalPartial(prog.Root(), vars)
+	if err != nil {
+		return KleeneResult{}, err
+	}
+	if known {
+		if v {
+			return KleeneResult{Result: TruthTrue}, nil
+		}
+		return KleeneResult{Result: TruthFalse}, nil
+	}
+
+	seen := make(map[string]struct{})
+	undecided(prog.Root(), vars, seen)
+
+	deciding := make([]string, 0, len(seen))
+	for name := range seen {
+		deciding = append(deciding, name)
+	}
+	sort.Strings(deciding)
+
+	return KleeneResult{Result: TruthUnknown, Deciding: deciding}, nil
+}
+
+// undecided adds to seen the unknown variables under n whose value can still change the value of n, skipping
+// the operands whose value does not matter given the known ones. The operands of an unknown node were all
+// evaluated without error, so errors are not expected here.
+func undecided(n Node, vars Bindings, seen map[string]struct{}) {
+	if _, known, err := evalPartial(n, vars); known || err != nil {
+		return
+	}
+
+	switch n := n.(type) {
+	case *Ident, *Compare:
+		name, _, _ := variableUse(n)
+		seen[name] = struct{}{}
+	case *Unary:
+		undecided(n.X, vars, seen)
+	case *Binary:
+		// a known operand that does not decide AND, OR or IMPLIES on its own is the neutral value of the
+		// operation, and both operands of XOR and IFF always matter, so every unknown operand is visited
+		undecided(n.Left, vars, seen)
+		undecided(n.Right, vars, seen)
+	}
+}
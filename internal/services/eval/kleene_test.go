@@ -0,0 +1,122 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_EvalKleene(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		expression string
+		vars       eval.Bindings
+		result     eval.Truth
+		deciding   []string
+		err        error
+	}{
+		{
+			name:       "Every value known",
+			expression: "(x AND y) OR z",
+			vars:       eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)},
+			result:     eval.TruthTrue,
+		},
+		{
+			name:       "False operand decides AND",
+			expression: "x AND y",
+			vars:       eval.Bindings{"x": eval.Bool(false)},
+			result:     eval.TruthFalse,
+		},
+		{
+			name:       "True operand decides OR",
+			expression: "y OR x",
+			vars:       eval.Bindings{"x": eval.Bool(true)},
+			result:     eval.TruthTrue,
+		},
+		{
+			name:       "False antecedent decides IMPLIES",
+			expression: "x IMPLIES y",
+			vars:       eval.Bindings{"x": eval.Bool(false)},
+			result:     eval.TruthTrue,
+		},
+		{
+			name:       "Nothing known",
+			expression: "(x AND y) OR z",
+			vars:       eval.Bindings{},
+			result:     eval.TruthUnknown,
+			deciding:   []string{"x", "y", "z"},
+		},
+		{
+			name:       "Decided operands are not deciding",
+			expression: "(x AND y) OR (z AND w)",
+			vars:       eval.Bindings{"x": eval.Bool(false), "z": eval.Bool(true)},
+			result:     eval.TruthUnknown,
+			deciding:   []string{"w"},
+		},
+		{
+			name:       "Unknown NOT stays unknown",
+			expression: "NOT (x OR y)",
+			vars:       eval.Bindings{"x": eval.Bool(false)},
+			result:     eval.TruthUnknown,
+			deciding:   []string{"y"},
+		},
+		{
+			name:       "XOR needs both operands",
+			expression: "x XOR (y AND z)",
+			vars:       eval.Bindings{"y": eval.Bool(true)},
+			result:     eval.TruthUnknown,
+			deciding:   []string{"x", "z"},
+		},
+		{
+			name:       "Tautology is unknown under Kleene semantics",
+			expression: "x OR NOT x",
+			vars:       eval.Bindings{},
+			result:     eval.TruthUnknown,
+			deciding:   []string{"x"},
+		},
+		{
+			name:       "Unused variables are ignored",
+			expression: "x AND y",
+			vars:       eval.Bindings{"x": eval.Bool(true), "w": eval.Bool(false)},
+			result:     eval.TruthUnknown,
+			deciding:   []string{"y"},
+		},
+		{
+			name:       "Value of the wrong type is not unknown",
+			expression: "y OR age > 18",
+			vars:       eval.Bindings{"age": eval.String("old")},
+			err:        eval.ErrInvalidValue,
+		},
+		{
+			name:       "Variable of the wrong type is not unknown",
+			expression: "x AND y",
+			vars:       eval.Bindings{"x": eval.Number(2)},
+			err:        eval.ErrInvalidValue,
+		},
+		{
+			name:       "Decided operands are not evaluated",
+			expression: "x AND age > 18",
+			vars:       eval.Bindings{"x": eval.Bool(false), "age": eval.String("old")},
+			result:     eval.TruthFalse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prog, err := eval.Compile(tc.expression)
+			require.NoError(t, err)
+
+			res, err := evaluator.EvalKleene(prog, tc.vars)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.result, res.Result)
+			require.Equal(t, tc.deciding, res.Deciding)
+		})
+	}
+}
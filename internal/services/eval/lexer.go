@@ -0,0 +1,169 @@
+package eval
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lex splits the given expression into tokens. Unrecognized input is returned as tokIllegal tokens,
+// so callers can report every problem at once. The returned slice always ends with a tokEOF token.
+func lex(exp string) []token {
+	tokens := make([]token, 0, len(exp)/2+1)
+	for i := 0; i < len(exp); {
+		r, size := utf8.DecodeRuneInString(exp[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", offset: i})
+			i += size
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", offset: i})
+			i += size
+		case isDigit(r), r == '-' && i+1 < len(exp) && isDigit(rune(exp[i+1])):
+			word := scanNumber(exp, i)
+			switch word {
+			case "1":
+				tokens = append(tokens, token{kind: tokTrue, text: word, offset: i})
+			case "0":
+				tokens = append(tokens, token{kind: tokFalse, text: word, offset: i})
+			default:
+				tokens = append(tokens, token{kind: tokNumber, text: word, offset: i})
+			}
+			i += len(word)
+		case r == '@':
+			word, ok := scanReference(exp, i)
+			if ok {
+				tokens = append(tokens, token{kind: tokRef, text: word, offset: i})
+			} else {
+				tokens = append(tokens, token{kind: tokIllegal, text: word, offset: i})
+			}
+			i += len(word)
+		case r == '"':
+			word, ok := scanString(exp, i)
+			if ok {
+				tokens = append(tokens, token{kind: tokString, text: word, offset: i})
+			} else {
+				tokens = append(tokens, token{kind: tokIllegal, text: word, offset: i})
+			}
+			i += len(word)
+		case isIdentStart(r):
+			word := scanWhile(exp, i, isIdentPart)
+			if kind, ok := keywords[word]; ok {
+				tokens = append(tokens, token{kind: kind, text: word, offset: i})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, text: word, offset: i})
+			}
+			i += len(word)
+		default:
+			tok, ok := lexSymbol(exp, i)
+			if !ok {
+				tok = token{kind: tokIllegal, text: exp[i : i+size], offset: i}
+			}
+			tokens = append(tokens, tok)
+			i += len(tok.text)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, offset: len(exp)})
+}
+
+// lexSymbol matches one of the symbolic operators at exp[start:].
+func lexSymbol(exp string, start int) (token, bool) {
+	for _, sym := range symbols {
+		if strings.HasPrefix(exp[start:], sym.text) {
+			return token{kind: sym.kind, text: sym.text, offset: start}, true
+		}
+	}
+
+	return token{}, false
+}
+
+// scanWhile returns the longest prefix of exp[start:] whose runes satisfy pred.
+func scanWhile(exp string, start int, pred func(rune) bool) string {
+	end := start
+	for end < len(exp) {
+		r, size := utf8.DecodeRuneInString(exp[end:])
+		if !pred(r) {
+			break
+		}
+		end += size
+	}
+
+	return exp[start:end]
+}
+
+// scanNumber returns the number at exp[start:]: an optional minus sign, digits and an optional fraction.
+func scanNumber(exp string, start int) string {
+	end := start
+	if exp[end] == '-' {
+		end++
+	}
+	end += len(scanWhile(exp, end, isDigit))
+	if end+1 < len(exp) && exp[end] == '.' && isDigit(rune(exp[end+1])) {
+		end += 1 + len(scanWhile(exp, end+1, isDigit))
+	}
+
+	return exp[start:end]
+}
+
+// scanString returns the double quoted string at exp[start:], quotes included, along with whether it is
+// closed. Backslashes escape the next character, following the Go syntax.
+func scanString(exp string, start int) (string, bool) {
+	for end := start + 1; end < len(exp); end++ {
+		switch exp[end] {
+		case '\\':
+			end++
+		case '"':
+			return exp[start : end+1], true
+		}
+	}
+
+	return exp[start:], false
+}
+
+// scanReference returns the reference at exp[start:], either @ followed by a name or @{ID}, along with whether it
+// is well-formed. The ID itself is checked by the parser.
+func scanReference(exp string, start int) (string, bool) {
+	rest := exp[start+1:]
+	if strings.HasPrefix(rest, "{") {
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return exp[start:], false
+		}
+		return exp[start : start+end+2], true
+	}
+
+	r, _ := utf8.DecodeRuneInString(rest)
+	if !isIdentStart(r) {
+		return "@", false
+	}
+	return "@" + scanWhile(exp, start+1, isIdentPart), true
+}
+
+// IsName reports whether s can name an expression, that is whether @s is a well-formed reference: s follows the
+// syntax of variable names.
+func IsName(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	if s == "" || !isIdentStart(r) {
+		return false
+	}
+
+	return scanWhile(s, size, isIdentPart) == s[size:]
+}
+
+// isIdentStart reports whether r can start a variable name: a letter or an underscore.
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// isIdentPart reports whether r can appear after the first character of a variable name:
+// a letter, a digit, an underscore or a dot.
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r) || r == '.'
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
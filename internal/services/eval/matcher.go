@@ -0,0 +1,351 @@
+package eval
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// Matcher is an in-memory index that finds the programs made true by a set of variable values without
+	// evaluating them one by one. Programs are grouped by owner, and the programs of an owner are only indexed
+	// once they have been loaded, so owners that never match cost nothing.
+	//
+	// The index is local to the process and only follows the changes it is told about. Changes made elsewhere,
+	// such as by another instance of the service, are picked up when the programs of the owner expire and are
+	// loaded again.
+	Matcher interface {
+		// Loaded reports whether the programs of the owner have been loaded and have not expired yet.
+		Loaded(owner string) bool
+		// Load replaces every indexed program of the owner with the given entries.
+		Load(owner string, entries []MatchEntry)
+		// Put adds or replaces an entry. Entries of owners that are not loaded are ignored.
+		Put(entry MatchEntry)
+		// Remove drops the entry with the given ID, if any.
+		Remove(id uuid.UUID)
+		// Unload drops every entry of the owner, whose programs are then loaded again before the next match.
+		Unload(owner string)
+		// Match returns the IDs of the entries of the owner, restricted to the given tag unless it is empty,
+		// whose programs are true when the variables missing from vars are unknown, as evaluated by EvalKleene:
+		// (x AND y) OR (x AND NOT y) is not matched by x alone. Programs that EvalKleene fails on, given a value
+		// of the wrong type, are not matched. IDs are sorted by the order of their entries.
+		Match(owner, tag string, vars Bindings) []uuid.UUID
+	}
+
+	// MatchEntry is a program indexed by a Matcher.
+	MatchEntry struct {
+		ID    uuid.UUID
+		Owner string
+		Tags  []string
+		// Order sorts the results of Match, such as the creation order of the expressions.
+		Order   int64
+		Program *Program
+	}
+
+	matcher struct {
+		mu      sync.RWMutex
+		maxAge  time.Duration
+		owners  map[string]*matchIndex
+		ownerOf map[uuid.UUID]string
+	}
+
+	// matchIndex is the counting index of the programs of an owner. Every program is converted to DNF and each
+	// of its terms, a conjunction of literals, is listed under every literal it holds. Given the variable
+	// values, a term is true once all of its literals are found, which only walks the lists of the given
+	// literals instead of every program. The programs of the true terms are candidates only, which are then
+	// evaluated like the fallback programs so that both give the same results.
+	matchIndex struct {
+		loadedAt time.Time
+		entries  map[uuid.UUID]*indexedEntry
+		postings map[matchLiteral]map[*matchTerm]struct{}
+		// compares holds the comparisons used by the terms, by variable, so that their value is computed
+		// once per match along with the number of terms using them.
+		compares map[string]map[string]*indexedCompare
+		// always holds the terms without literals, which are true whatever the values.
+		always map[*matchTerm]struct{}
+		// fallback holds the programs whose DNF exceeds MaxNormalFormClauses, which are evaluated one by one.
+		fallback map[uuid.UUID]*indexedEntry
+	}
+
+	// matchLiteral is an atom, a variable or a comparison, along with the value that makes it true.
+	matchLiteral struct {
+		name  string
+		value bool
+	}
+
+	// matchTerm is a term of the DNF of an entry.
+	matchTerm struct {
+		entry    *indexedEntry
+		literals []matchLiteral
+	}
+
+	indexedEntry struct {
+		MatchEntry
+		terms    []*matchTerm
+		compares []*Compare
+	}
+
+	indexedCompare struct {
+		node *Compare
+		refs int
+	}
+)
+
+// NewMatcher instantiates an empty Matcher whose owners expire maxAge after they were loaded. Owners never expire
+// when maxAge is not positive.
+func NewMatcher(maxAge time.Duration) Matcher {
+	return &matcher{
+		maxAge:  maxAge,
+		owners:  make(map[string]*matchIndex),
+		ownerOf: make(map[uuid.UUID]string),
+	}
+}
+
+func (m *matcher) Loaded(owner string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	idx, ok := m.owners[owner]
+	if !ok {
+		return false
+	}
+
+	return m.maxAge <= 0 || time.Since(idx.loadedAt) < m.maxAge
+}
+
+func (m *matcher) Load(owner string, entries []MatchEntry) {
+	idx := &matchIndex{
+		loadedAt: time.Now(),
+		entries:  make(map[uuid.UUID]*indexedEntry, len(entries)),
+		postings: make(map[matchLiteral]map[*matchTerm]struct{}),
+		compares: make(map[string]map[string]*indexedCompare),
+		always:   make(map[*matchTerm]struct{}),
+		fallback: make(map[uuid.UUID]*indexedEntry),
+	}
+	for _, entry := range entries {
+		idx.add(entry)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.owners[owner]; ok {
+		for id := range old.entries {
+			delete(m.ownerOf, id)
+		}
+	}
+	for id := range idx.entries {
+		m.ownerOf[id] = owner
+	}
+	m.owners[owner] = idx
+}
+
+func (m *matcher) Unload(owner string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if idx, ok := m.owners[owner]; ok {
+		for id := range idx.entries {
+			delete(m.ownerOf, id)
+		}
+		delete(m.owners, owner)
+	}
+}
+
+func (m *matcher) Put(entry MatchEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(entry.ID)
+
+	idx, ok := m.owners[entry.Owner]
+	if !ok {
+		return
+	}
+	idx.add(entry)
+	m.ownerOf[entry.ID] = entry.Owner
+}
+
+func (m *matcher) Remove(id uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(id)
+}
+
+func (m *matcher) remove(id uuid.UUID) {
+	owner, ok := m.ownerOf[id]
+	if !ok {
+		return
+	}
+	delete(m.ownerOf, id)
+
+	if idx, ok := m.owners[owner]; ok {
+		idx.remove(id)
+	}
+}
+
+func (m *matcher) Match(owner, tag string, vars Bindings) []uuid.UUID {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	idx, ok := m.owners[owner]
+	if !ok {
+		return []uuid.UUID{}
+	}
+
+	candidates := make(map[*indexedEntry]struct{})
+	for t := range idx.always {
+		candidates[t.entry] = struct{}{}
+	}
+
+	counts := make(map[*matchTerm]int)
+	hit := func(lit matchLiteral) {
+		for t := range idx.postings[lit] {
+			counts[t]++
+			if counts[t] == len(t.literals) {
+				candidates[t.entry] = struct{}{}
+			}
+		}
+	}
+	for name, value := range vars {
+		if b, ok := value.asBool(); ok {
+			hit(matchLiteral{name: name, value: b})
+		}
+		for key, cmp := range idx.compares[name] {
+			if b, err := evalCompare(cmp.node, value); err == nil {
+				hit(matchLiteral{name: key, value: b})
+			}
+		}
+	}
+
+	for _, e := range idx.fallback {
+		candidates[e] = struct{}{}
+	}
+
+	entries := make([]*indexedEntry, 0, len(candidates))
+	for e := range candidates {
+		if tag != "" && !hasTag(e.Tags, tag) {
+			continue
+		}
+		// programs given a value of the wrong type are not matched, like the comparisons skipped above
+		if v, known, err := evalPartial(e.Program.Root(), vars); err == nil && known && v {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Order != entries[j].Order {
+			return entries[i].Order < entries[j].Order
+		}
+		return entries[i].ID.String() < entries[j].ID.String()
+	})
+
+	ids := make([]uuid.UUID, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+
+	return ids
+}
+
+// add indexes the terms of the DNF of the entry, falling back to evaluating the program when its DNF is too large.
+func (idx *matchIndex) add(entry MatchEntry) {
+	e := &indexedEntry{MatchEntry: entry}
+	idx.entries[entry.ID] = e
+
+	nf, err := normalForm(entry.Program, NormalFormOptions{Form: FormDNF})
+	if err != nil {
+		idx.fallback[entry.ID] = e
+		return
+	}
+
+	for _, atom := range atomNodes(entry.Program.Root()) {
+		if cmp, ok := atom.(*Compare); ok {
+			e.compares = append(e.compares, cmp)
+			idx.addCompare(cmp)
+		}
+	}
+
+	for _, clause := range nf.Clauses {
+		t := &matchTerm{entry: e, literals: make([]matchLiteral, 0, len(clause))}
+		for _, lit := range clause {
+			t.literals = append(t.literals, matchLiteral{name: lit.Variable, value: !lit.Negated})
+		}
+		e.terms = append(e.terms, t)
+
+		if len(t.literals) == 0 {
+			idx.always[t] = struct{}{}
+			continue
+		}
+		for _, lit := range t.literals {
+			if idx.postings[lit] == nil {
+				idx.postings[lit] = make(map[*matchTerm]struct{})
+			}
+			idx.postings[lit][t] = struct{}{}
+		}
+	}
+}
+
+func (idx *matchIndex) remove(id uuid.UUID) {
+	e, ok := idx.entries[id]
+	if !ok {
+		return
+	}
+	delete(idx.entries, id)
+	delete(idx.fallback, id)
+
+	for _, cmp := range e.compares {
+		idx.removeCompare(cmp)
+	}
+	for _, t := range e.terms {
+		delete(idx.always, t)
+		for _, lit := range t.literals {
+			delete(idx.postings[lit], t)
+			if len(idx.postings[lit]) == 0 {
+				delete(idx.postings, lit)
+			}
+		}
+	}
+}
+
+// addCompare registers a comparison used by an entry, which Match then computes for the values of its variable.
+func (idx *matchIndex) addCompare(cmp *Compare) {
+	byKey, ok := idx.compares[cmp.Name]
+	if !ok {
+		byKey = make(map[string]*indexedCompare)
+		idx.compares[cmp.Name] = byKey
+	}
+
+	key := cmp.String()
+	if byKey[key] == nil {
+		byKey[key] = &indexedCompare{node: cmp}
+	}
+	byKey[key].refs++
+}
+
+func (idx *matchIndex) removeCompare(cmp *Compare) {
+	key := cmp.String()
+	c, ok := idx.compares[cmp.Name][key]
+	if !ok {
+		return
+	}
+
+	c.refs--
+	if c.refs == 0 {
+		delete(idx.compares[cmp.Name], key)
+		if len(idx.compares[cmp.Name]) == 0 {
+			delete(idx.compares, cmp.Name)
+		}
+	}
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
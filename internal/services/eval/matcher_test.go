@@ -0,0 +1,251 @@
+package eval_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	newEntry := func(t *testing.T, owner string, order int64, exp string, tags ...string) eval.MatchEntry {
+		prog, err := eval.Compile(exp)
+		require.NoError(t, err)
+
+		return eval.MatchEntry{
+			ID:      uuid.New(),
+			Owner:   owner,
+			Tags:    tags,
+			Order:   order,
+			Program: prog,
+		}
+	}
+
+	t.Run("Matches follow the given values", func(t *testing.T) {
+		decided := newEntry(t, "john", 1, "(x AND y) OR z")
+		undecided := newEntry(t, "john", 2, "x AND w")
+		falsified := newEntry(t, "john", 3, "NOT z")
+		tautology := newEntry(t, "john", 4, "1 OR w")
+		reordered := newEntry(t, "john", 5, "w OR x")
+
+		m := eval.NewMatcher(0)
+		require.False(t, m.Loaded("john"))
+		m.Load("john", []eval.MatchEntry{reordered, falsified, undecided, decided, tautology})
+		require.True(t, m.Loaded("john"))
+
+		got := m.Match("john", "", eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false), "z": eval.Bool(true)})
+		require.Equal(t, []uuid.UUID{decided.ID, tautology.ID, reordered.ID}, got)
+
+		require.Empty(t, m.Match("jane", "", eval.Bindings{"x": eval.Bool(true)}))
+	})
+
+	t.Run("Unknown variables are not decided by the other terms", func(t *testing.T) {
+		indexed := newEntry(t, "john", 1, "(x AND y) OR (x AND NOT y)")
+
+		// the same expression along with 13 disjunctions, whose DNF is too large to index, is evaluated instead
+		clauses := []string{"((x AND y) OR (x AND NOT y))"}
+		vars := eval.Bindings{"x": eval.Bool(true)}
+		for i := 0; i < 13; i++ {
+			a, b := "a"+strings.Repeat("x", i), "b"+strings.Repeat("x", i)
+			clauses = append(clauses, "("+a+" OR "+b+")")
+			vars[a] = eval.Bool(true)
+		}
+		evaluated := newEntry(t, "john", 2, strings.Join(clauses, " AND "))
+
+		m := eval.NewMatcher(0)
+		m.Load("john", []eval.MatchEntry{indexed, evaluated})
+
+		require.Empty(t, m.Match("john", "", vars))
+
+		vars["y"] = eval.Bool(false)
+		require.Equal(t, []uuid.UUID{indexed.ID, evaluated.ID}, m.Match("john", "", vars))
+	})
+
+	t.Run("Tags restrict the matches", func(t *testing.T) {
+		fraud := newEntry(t, "john", 1, "x", "fraud")
+		payments := newEntry(t, "john", 2, "x", "payments", "fraud")
+		untagged := newEntry(t, "john", 3, "x")
+
+		m := eval.NewMatcher(0)
+		m.Load("john", []eval.MatchEntry{fraud, payments, untagged})
+
+		require.Equal(t, []uuid.UUID{fraud.ID, payments.ID}, m.Match("john", "fraud", eval.Bindings{"x": eval.Bool(true)}))
+		require.Equal(t, []uuid.UUID{payments.ID}, m.Match("john", "payments", eval.Bindings{"x": eval.Bool(true)}))
+		require.Empty(t, m.Match("john", "other", eval.Bindings{"x": eval.Bool(true)}))
+	})
+
+	t.Run("Updates are incremental", func(t *testing.T) {
+		entry := newEntry(t, "john", 1, "x AND y")
+
+		m := eval.NewMatcher(0)
+		m.Put(entry)
+		require.False(t, m.Loaded("john"), "entries of owners that are not loaded are ignored")
+
+		m.Load("john", nil)
+		m.Put(entry)
+		require.Equal(t, []uuid.UUID{entry.ID}, m.Match("john", "", eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(true)}))
+
+		updated := entry
+		updated.Program = newEntry(t, "john", 1, "x AND NOT y").Program
+		m.Put(updated)
+		require.Empty(t, m.Match("john", "", eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(true)}))
+		require.Equal(t, []uuid.UUID{entry.ID}, m.Match("john", "", eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false)}))
+
+		m.Remove(entry.ID)
+		require.Empty(t, m.Match("john", "", eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(false)}))
+
+		m.Remove(uuid.New())
+
+		m.Put(entry)
+		m.Unload("john")
+		require.False(t, m.Loaded("john"))
+		require.Empty(t, m.Match("john", "", eval.Bindings{"x": eval.Bool(true), "y": eval.Bool(true)}))
+	})
+
+	t.Run("Owners expire after the max age", func(t *testing.T) {
+		entry := newEntry(t, "john", 1, "x")
+		m := eval.NewMatcher(10 * time.Millisecond)
+		m.Load("john", []eval.MatchEntry{entry})
+		require.True(t, m.Loaded("john"))
+
+		time.Sleep(20 * time.Millisecond)
+		require.False(t, m.Loaded("john"), "expired owners are loaded again before the next match")
+
+		m.Load("john", []eval.MatchEntry{entry})
+		require.True(t, m.Loaded("john"))
+	})
+
+	t.Run("Comparisons are matched on the given values", func(t *testing.T) {
+		adult := newEntry(t, "john", 1, "age >= 18")
+		local := newEntry(t, "john", 2, `country IN ("BR", "PT") AND NOT blocked`)
+		minor := newEntry(t, "john", 3, "age < 18 OR blocked")
+
+		m := eval.NewMatcher(0)
+		m.Load("john", []eval.MatchEntry{adult, local, minor})
+
+		got := m.Match("john", "", eval.Bindings{"age": eval.Number(21), "country": eval.String("PT"), "blocked": eval.Bool(false)})
+		require.Equal(t, []uuid.UUID{adult.ID, local.ID}, got)
+
+		got = m.Match("john", "", eval.Bindings{"age": eval.String("12"), "country": eval.List(eval.String("US"), eval.String("BR"))})
+		require.Equal(t, []uuid.UUID{minor.ID}, got)
+
+		m.Remove(adult.ID)
+		require.Empty(t, m.Match("john", "", eval.Bindings{"age": eval.Number(30)}))
+		require.Empty(t, m.Match("john", "", eval.Bindings{"age": eval.String("old")}))
+	})
+
+	t.Run("Programs with a DNF too large to index are evaluated", func(t *testing.T) {
+		// the DNF of this conjunction of 13 disjunctions has 2^13 terms
+		clauses := make([]string, 0, 13)
+		vars := eval.Bindings{}
+		for i := 0; i < 13; i++ {
+			a, b := "a"+strings.Repeat("x", i), "b"+strings.Repeat("x", i)
+			clauses = append(clauses, "("+a+" OR "+b+")")
+			vars[a] = eval.Bool(true)
+		}
+		large := newEntry(t, "john", 1, strings.Join(clauses, " AND "))
+
+		m := eval.NewMatcher(0)
+		m.Load("john", []eval.MatchEntry{large})
+		require.Equal(t, []uuid.UUID{large.ID}, m.Match("john", "", vars))
+
+		vars["a"] = eval.Bool(false)
+		require.Empty(t, m.Match("john", "", vars))
+	})
+
+	t.Run("Matches agree with evaluation", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(16))
+		names := []string{"a", "b", "c", "d"}
+		operators := []string{"AND", "OR", "XOR", "IFF", "IMPLIES"}
+
+		var gen func(depth int) string
+		gen = func(depth int) string {
+			if depth == 0 || rnd.Intn(3) == 0 {
+				name := names[rnd.Intn(len(names))]
+				if rnd.Intn(2) == 0 {
+					return "NOT " + name
+				}
+				return name
+			}
+			return "(" + gen(depth-1) + " " + operators[rnd.Intn(len(operators))] + " " + gen(depth-1) + ")"
+		}
+
+		entries := make([]eval.MatchEntry, 0, 200)
+		for i := 0; i < 200; i++ {
+			entries = append(entries, newEntry(t, "john", int64(i), gen(3)))
+		}
+
+		m := eval.NewMatcher(0)
+		m.Load("john", entries)
+		evaluator := eval.New(eval.Config{})
+
+		for i := 0; i < 100; i++ {
+			vars := eval.Bindings{}
+			for _, name := range names {
+				if rnd.Intn(4) > 0 {
+					vars[name] = eval.Bool(rnd.Intn(2) == 0)
+				}
+			}
+
+			// the matches are the programs true with the missing variables unknown, which are then true for
+			// every value of these variables
+			matched := make(map[uuid.UUID]bool)
+			for _, id := range m.Match("john", "", vars) {
+				matched[id] = true
+			}
+
+			for _, entry := range entries {
+				decided := true
+				for _, completion := range completions(vars, entry.Program.Variables()) {
+					res, err := entry.Program.Eval(completion)
+					require.NoError(t, err)
+					decided = decided && res
+				}
+
+				res, err := evaluator.EvalKleene(entry.Program, vars)
+				require.NoError(t, err)
+				kleene := res.Result == eval.TruthTrue
+				require.Equal(t, kleene, matched[entry.ID], "%s with %v", entry.Program.Root(), vars)
+				if matched[entry.ID] {
+					require.True(t, decided, "%s matched %v", entry.Program.Root(), vars)
+				}
+				if len(vars) == len(names) {
+					require.Equal(t, decided, matched[entry.ID], "%s with %v", entry.Program.Root(), vars)
+				}
+			}
+		}
+	})
+}
+
+// completions returns every assignment extending vars to the given names.
+func completions(vars eval.Bindings, names []string) []eval.Bindings {
+	all := []eval.Bindings{{}}
+	for name, v := range vars {
+		all[0][name] = v
+	}
+
+	for _, name := range names {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+
+		next := make([]eval.Bindings, 0, 2*len(all))
+		for _, b := range all {
+			for _, v := range []bool{false, true} {
+				c := make(eval.Bindings, len(b)+1)
+				for k, bv := range b {
+					c[k] = bv
+				}
+				c[name] = eval.Bool(v)
+				next = append(next, c)
+			}
+		}
+		all = next
+	}
+
+	return all
+}
@@ -0,0 +1,187 @@
+package eval
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// implicant is a product term over n variables. Bits set in mask are variables absent from the product,
+// the remaining bits of value hold the value each present variable must have. Bit n-1-i belongs to the
+// i-th variable, matching the row order of truth tables.
+type implicant struct {
+	value uint32
+	mask  uint32
+}
+
+// minimize returns a minimal sum of products equivalent to the program, computed with the Quine–McCluskey
+// method over its truth table.
+func minimize(prog *Program, maxVars int) (Node, error) {
+	table, err := truthTable(prog, maxVars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to minimize expression: %w", err)
+	}
+
+	var minterms []uint32
+	for i, row := range table.Rows {
+		if row.Result {
+			minterms = append(minterms, uint32(i))
+		}
+	}
+
+	switch len(minterms) {
+	case 0:
+		return &Literal{Value: false}, nil
+	case len(table.Rows):
+		return &Literal{Value: true}, nil
+	}
+
+	cover := selectCover(primeImplicants(minterms), minterms)
+	return sumOfProducts(cover, table.Variables, atomNodes(prog.Root())), nil
+}
+
+// primeImplicants repeatedly merges implicants that differ in a single variable. The ones that cannot be
+// merged any further are the prime implicants.
+func primeImplicants(minterms []uint32) []implicant {
+	current := make([]implicant, 0, len(minterms))
+	for _, m := range minterms {
+		current = append(current, implicant{value: m})
+	}
+
+	var primes []implicant
+	for len(current) > 0 {
+		merged := make(map[implicant]bool)
+		var next []implicant
+		seen := make(map[implicant]bool)
+
+		for i, a := range current {
+			for _, b := range current[i+1:] {
+				diff := a.value ^ b.value
+				if a.mask != b.mask || bits.OnesCount32(diff) != 1 {
+					continue
+				}
+
+				merged[a], merged[b] = true, true
+				c := implicant{value: a.value &^ diff, mask: a.mask | diff}
+				if !seen[c] {
+					seen[c] = true
+					next = append(next, c)
+				}
+			}
+		}
+
+		for _, a := range current {
+			if !merged[a] {
+				primes = append(primes, a)
+			}
+		}
+		current = next
+	}
+
+	return primes
+}
+
+// selectCover picks prime implicants covering every minterm: first the essential ones, which are the only
+// cover of some minterm, then greedily the ones covering most of the remaining minterms.
+func selectCover(primes []implicant, minterms []uint32) []implicant {
+	covers := func(p implicant, m uint32) bool {
+		return m&^p.mask == p.value
+	}
+
+	uncovered := make(map[uint32]bool, len(minterms))
+	for _, m := range minterms {
+		uncovered[m] = true
+	}
+
+	var cover []implicant
+	selected := make(map[implicant]bool)
+	take := func(p implicant) {
+		selected[p] = true
+		cover = append(cover, p)
+		for m := range uncovered {
+			if covers(p, m) {
+				delete(uncovered, m)
+			}
+		}
+	}
+
+	for _, m := range minterms {
+		if !uncovered[m] {
+			continue
+		}
+
+		var only implicant
+		count := 0
+		for _, p := range primes {
+			if covers(p, m) {
+				only = p
+				count++
+			}
+		}
+		if count == 1 && !selected[only] {
+			take(only)
+		}
+	}
+
+	for len(uncovered) > 0 {
+		best, bestCount := implicant{}, 0
+		for _, p := range primes {
+			if selected[p] {
+				continue
+			}
+
+			count := 0
+			for m := range uncovered {
+				if covers(p, m) {
+					count++
+				}
+			}
+			// prefer larger implicants, which have fewer literals, on ties
+			if count > bestCount || (count == bestCount && count > 0 && bits.OnesCount32(p.mask) > bits.OnesCount32(best.mask)) {
+				best, bestCount = p, count
+			}
+		}
+		take(best)
+	}
+
+	return cover
+}
+
+// sumOfProducts builds the disjunction of the given implicants over the given atoms. Products are ordered by
+// the variables they use, following the variable order, so that the result is deterministic.
+func sumOfProducts(cover []implicant, vars []string, atoms map[string]Node) Node {
+	sort.Slice(cover, func(i, j int) bool {
+		if cover[i].mask != cover[j].mask {
+			return ^cover[i].mask > ^cover[j].mask
+		}
+		return cover[i].value > cover[j].value
+	})
+
+	var sum Node
+	for _, p := range cover {
+		var product Node
+		for i, name := range vars {
+			bit := uint32(1) << (len(vars) - 1 - i)
+			if p.mask&bit != 0 {
+				continue
+			}
+
+			lit := atomNode(atoms, name)
+			if p.value&bit == 0 {
+				lit = &Unary{Op: OpNot, X: lit}
+			}
+			product = conjoin(product, lit, OpAnd)
+		}
+		sum = conjoin(sum, product, OpOr)
+	}
+
+	return sum
+}
+
+// conjoin appends n to the left-associative chain acc of op operations.
+func conjoin(acc, n Node, op Operator) Node {
+	if acc == nil {
+		return n
+	}
+	return &Binary{Op: op, Left: acc, Right: n}
+}
@@ -1,13 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/gmaschi/log-exp-eval/internal/services/eval (interfaces: Evaluator)
+// Source: github.com/gmaschi/log-exp-eval/internal/services/eval (interfaces: Evaluator,Compiler,Runner,Analyzer)
 
 // Package mockedeval is a generated GoMock package.
 package mockedeval
 
 import (
+	context "context"
 	reflect "reflect"
 
+	eval "github.com/gmaschi/log-exp-eval/internal/services/eval"
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // MockEvaluator is a mock of Evaluator interface.
@@ -33,18 +36,150 @@ func (m *MockEvaluator) EXPECT() *MockEvaluatorMockRecorder {
 	return m.recorder
 }
 
+// CacheStats mocks base method.
+func (m *MockEvaluator) CacheStats() eval.CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheStats")
+	ret0, _ := ret[0].(eval.CacheStats)
+	return ret0
+}
+
+// CacheStats indicates an expected call of CacheStats.
+func (mr *MockEvaluatorMockRecorder) CacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheStats", reflect.TypeOf((*MockEvaluator)(nil).CacheStats))
+}
+
+// Compile mocks base method.
+func (m *MockEvaluator) Compile(arg0 string) (*eval.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compile", arg0)
+	ret0, _ := ret[0].(*eval.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Compile indicates an expected call of Compile.
+func (mr *MockEvaluatorMockRecorder) Compile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compile", reflect.TypeOf((*MockEvaluator)(nil).Compile), arg0)
+}
+
+// CompileCached mocks base method.
+func (m *MockEvaluator) CompileCached(arg0 eval.CacheKey, arg1 string) (*eval.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompileCached", arg0, arg1)
+	ret0, _ := ret[0].(*eval.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompileCached indicates an expected call of CompileCached.
+func (mr *MockEvaluatorMockRecorder) CompileCached(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompileCached", reflect.TypeOf((*MockEvaluator)(nil).CompileCached), arg0, arg1)
+}
+
+// Equivalent mocks base method.
+func (m *MockEvaluator) Equivalent(arg0, arg1 *eval.Program) (eval.Equivalence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Equivalent", arg0, arg1)
+	ret0, _ := ret[0].(eval.Equivalence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Equivalent indicates an expected call of Equivalent.
+func (mr *MockEvaluatorMockRecorder) Equivalent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Equivalent", reflect.TypeOf((*MockEvaluator)(nil).Equivalent), arg0, arg1)
+}
+
+// Eval mocks base method.
+func (m *MockEvaluator) Eval(arg0 *eval.Program, arg1 eval.Bindings) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Eval", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Eval indicates an expected call of Eval.
+func (mr *MockEvaluatorMockRecorder) Eval(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Eval", reflect.TypeOf((*MockEvaluator)(nil).Eval), arg0, arg1)
+}
+
+// EvalBatch mocks base method.
+func (m *MockEvaluator) EvalBatch(arg0 context.Context, arg1 *eval.Program, arg2 []eval.Bindings) ([]eval.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvalBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]eval.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvalBatch indicates an expected call of EvalBatch.
+func (mr *MockEvaluatorMockRecorder) EvalBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalBatch", reflect.TypeOf((*MockEvaluator)(nil).EvalBatch), arg0, arg1, arg2)
+}
+
+// EvalKleene mocks base method.
+func (m *MockEvaluator) EvalKleene(arg0 *eval.Program, arg1 eval.Bindings) (eval.KleeneResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvalKleene", arg0, arg1)
+	ret0, _ := ret[0].(eval.KleeneResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvalKleene indicates an expected call of EvalKleene.
+func (mr *MockEvaluatorMockRecorder) EvalKleene(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalKleene", reflect.TypeOf((*MockEvaluator)(nil).EvalKleene), arg0, arg1)
+}
+
 // EvalLogicExp mocks base method.
-func (m *MockEvaluator) EvalLogicExp(arg0 string) bool {
+func (m *MockEvaluator) EvalLogicExp(arg0 string, arg1 eval.Bindings) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "EvalLogicExp", arg0)
+	ret := m.ctrl.Call(m, "EvalLogicExp", arg0, arg1)
 	ret0, _ := ret[0].(bool)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // EvalLogicExp indicates an expected call of EvalLogicExp.
-func (mr *MockEvaluatorMockRecorder) EvalLogicExp(arg0 interface{}) *gomock.Call {
+func (mr *MockEvaluatorMockRecorder) EvalLogicExp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalLogicExp", reflect.TypeOf((*MockEvaluator)(nil).EvalLogicExp), arg0, arg1)
+}
+
+// Explain mocks base method.
+func (m *MockEvaluator) Explain(arg0 *eval.Program, arg1 eval.Bindings) (eval.Explanation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Explain", arg0, arg1)
+	ret0, _ := ret[0].(eval.Explanation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Explain indicates an expected call of Explain.
+func (mr *MockEvaluatorMockRecorder) Explain(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalLogicExp", reflect.TypeOf((*MockEvaluator)(nil).EvalLogicExp), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Explain", reflect.TypeOf((*MockEvaluator)(nil).Explain), arg0, arg1)
+}
+
+// Invalidate mocks base method.
+func (m *MockEvaluator) Invalidate(arg0 uuid.UUID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Invalidate", arg0)
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockEvaluatorMockRecorder) Invalidate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockEvaluator)(nil).Invalidate), arg0)
 }
 
 // IsValidLogicExp mocks base method.
@@ -60,3 +195,380 @@ func (mr *MockEvaluatorMockRecorder) IsValidLogicExp(arg0 interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsValidLogicExp", reflect.TypeOf((*MockEvaluator)(nil).IsValidLogicExp), arg0)
 }
+
+// NormalForm mocks base method.
+func (m *MockEvaluator) NormalForm(arg0 *eval.Program, arg1 eval.NormalFormOptions) (eval.NormalForm, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NormalForm", arg0, arg1)
+	ret0, _ := ret[0].(eval.NormalForm)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NormalForm indicates an expected call of NormalForm.
+func (mr *MockEvaluatorMockRecorder) NormalForm(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NormalForm", reflect.TypeOf((*MockEvaluator)(nil).NormalForm), arg0, arg1)
+}
+
+// Satisfiable mocks base method.
+func (m *MockEvaluator) Satisfiable(arg0 *eval.Program) (eval.SatResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Satisfiable", arg0)
+	ret0, _ := ret[0].(eval.SatResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Satisfiable indicates an expected call of Satisfiable.
+func (mr *MockEvaluatorMockRecorder) Satisfiable(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Satisfiable", reflect.TypeOf((*MockEvaluator)(nil).Satisfiable), arg0)
+}
+
+// Simplify mocks base method.
+func (m *MockEvaluator) Simplify(arg0 *eval.Program, arg1 eval.SimplifyOptions) (*eval.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Simplify", arg0, arg1)
+	ret0, _ := ret[0].(*eval.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Simplify indicates an expected call of Simplify.
+func (mr *MockEvaluatorMockRecorder) Simplify(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Simplify", reflect.TypeOf((*MockEvaluator)(nil).Simplify), arg0, arg1)
+}
+
+// TruthTable mocks base method.
+func (m *MockEvaluator) TruthTable(arg0 *eval.Program) (eval.TruthTable, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TruthTable", arg0)
+	ret0, _ := ret[0].(eval.TruthTable)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TruthTable indicates an expected call of TruthTable.
+func (mr *MockEvaluatorMockRecorder) TruthTable(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TruthTable", reflect.TypeOf((*MockEvaluator)(nil).TruthTable), arg0)
+}
+
+// Validate mocks base method.
+func (m *MockEvaluator) Validate(arg0 string) []eval.Diagnostic {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", arg0)
+	ret0, _ := ret[0].([]eval.Diagnostic)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockEvaluatorMockRecorder) Validate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockEvaluator)(nil).Validate), arg0)
+}
+
+// MockCompiler is a mock of Compiler interface.
+type MockCompiler struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompilerMockRecorder
+}
+
+// MockCompilerMockRecorder is the mock recorder for MockCompiler.
+type MockCompilerMockRecorder struct {
+	mock *MockCompiler
+}
+
+// NewMockCompiler creates a new mock instance.
+func NewMockCompiler(ctrl *gomock.Controller) *MockCompiler {
+	mock := &MockCompiler{ctrl: ctrl}
+	mock.recorder = &MockCompilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompiler) EXPECT() *MockCompilerMockRecorder {
+	return m.recorder
+}
+
+// CacheStats mocks base method.
+func (m *MockCompiler) CacheStats() eval.CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheStats")
+	ret0, _ := ret[0].(eval.CacheStats)
+	return ret0
+}
+
+// CacheStats indicates an expected call of CacheStats.
+func (mr *MockCompilerMockRecorder) CacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheStats", reflect.TypeOf((*MockCompiler)(nil).CacheStats))
+}
+
+// Compile mocks base method.
+func (m *MockCompiler) Compile(arg0 string) (*eval.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compile", arg0)
+	ret0, _ := ret[0].(*eval.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Compile indicates an expected call of Compile.
+func (mr *MockCompilerMockRecorder) Compile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compile", reflect.TypeOf((*MockCompiler)(nil).Compile), arg0)
+}
+
+// CompileCached mocks base method.
+func (m *MockCompiler) CompileCached(arg0 eval.CacheKey, arg1 string) (*eval.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompileCached", arg0, arg1)
+	ret0, _ := ret[0].(*eval.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompileCached indicates an expected call of CompileCached.
+func (mr *MockCompilerMockRecorder) CompileCached(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompileCached", reflect.TypeOf((*MockCompiler)(nil).CompileCached), arg0, arg1)
+}
+
+// Invalidate mocks base method.
+func (m *MockCompiler) Invalidate(arg0 uuid.UUID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Invalidate", arg0)
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockCompilerMockRecorder) Invalidate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockCompiler)(nil).Invalidate), arg0)
+}
+
+// IsValidLogicExp mocks base method.
+func (m *MockCompiler) IsValidLogicExp(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsValidLogicExp", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsValidLogicExp indicates an expected call of IsValidLogicExp.
+func (mr *MockCompilerMockRecorder) IsValidLogicExp(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsValidLogicExp", reflect.TypeOf((*MockCompiler)(nil).IsValidLogicExp), arg0)
+}
+
+// Validate mocks base method.
+func (m *MockCompiler) Validate(arg0 string) []eval.Diagnostic {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", arg0)
+	ret0, _ := ret[0].([]eval.Diagnostic)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockCompilerMockRecorder) Validate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockCompiler)(nil).Validate), arg0)
+}
+
+// MockRunner is a mock of Runner interface.
+type MockRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockRunnerMockRecorder
+}
+
+// MockRunnerMockRecorder is the mock recorder for MockRunner.
+type MockRunnerMockRecorder struct {
+	mock *MockRunner
+}
+
+// NewMockRunner creates a new mock instance.
+func NewMockRunner(ctrl *gomock.Controller) *MockRunner {
+	mock := &MockRunner{ctrl: ctrl}
+	mock.recorder = &MockRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRunner) EXPECT() *MockRunnerMockRecorder {
+	return m.recorder
+}
+
+// Eval mocks base method.
+func (m *MockRunner) Eval(arg0 *eval.Program, arg1 eval.Bindings) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Eval", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Eval indicates an expected call of Eval.
+func (mr *MockRunnerMockRecorder) Eval(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Eval", reflect.TypeOf((*MockRunner)(nil).Eval), arg0, arg1)
+}
+
+// EvalBatch mocks base method.
+func (m *MockRunner) EvalBatch(arg0 context.Context, arg1 *eval.Program, arg2 []eval.Bindings) ([]eval.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvalBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]eval.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvalBatch indicates an expected call of EvalBatch.
+func (mr *MockRunnerMockRecorder) EvalBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalBatch", reflect.TypeOf((*MockRunner)(nil).EvalBatch), arg0, arg1, arg2)
+}
+
+// EvalKleene mocks base method.
+func (m *MockRunner) EvalKleene(arg0 *eval.Program, arg1 eval.Bindings) (eval.KleeneResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvalKleene", arg0, arg1)
+	ret0, _ := ret[0].(eval.KleeneResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvalKleene indicates an expected call of EvalKleene.
+func (mr *MockRunnerMockRecorder) EvalKleene(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalKleene", reflect.TypeOf((*MockRunner)(nil).EvalKleene), arg0, arg1)
+}
+
+// EvalLogicExp mocks base method.
+func (m *MockRunner) EvalLogicExp(arg0 string, arg1 eval.Bindings) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvalLogicExp", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvalLogicExp indicates an expected call of EvalLogicExp.
+func (mr *MockRunnerMockRecorder) EvalLogicExp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvalLogicExp", reflect.TypeOf((*MockRunner)(nil).EvalLogicExp), arg0, arg1)
+}
+
+// Explain mocks base method.
+func (m *MockRunner) Explain(arg0 *eval.Program, arg1 eval.Bindings) (eval.Explanation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Explain", arg0, arg1)
+	ret0, _ := ret[0].(eval.Explanation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Explain indicates an expected call of Explain.
+func (mr *MockRunnerMockRecorder) Explain(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Explain", reflect.TypeOf((*MockRunner)(nil).Explain), arg0, arg1)
+}
+
+// MockAnalyzer is a mock of Analyzer interface.
+type MockAnalyzer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnalyzerMockRecorder
+}
+
+// MockAnalyzerMockRecorder is the mock recorder for MockAnalyzer.
+type MockAnalyzerMockRecorder struct {
+	mock *MockAnalyzer
+}
+
+// NewMockAnalyzer creates a new mock instance.
+func NewMockAnalyzer(ctrl *gomock.Controller) *MockAnalyzer {
+	mock := &MockAnalyzer{ctrl: ctrl}
+	mock.recorder = &MockAnalyzerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnalyzer) EXPECT() *MockAnalyzerMockRecorder {
+	return m.recorder
+}
+
+// Equivalent mocks base method.
+func (m *MockAnalyzer) Equivalent(arg0, arg1 *eval.Program) (eval.Equivalence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Equivalent", arg0, arg1)
+	ret0, _ := ret[0].(eval.Equivalence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Equivalent indicates an expected call of Equivalent.
+func (mr *MockAnalyzerMockRecorder) Equivalent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Equivalent", reflect.TypeOf((*MockAnalyzer)(nil).Equivalent), arg0, arg1)
+}
+
+// NormalForm mocks base method.
+func (m *MockAnalyzer) NormalForm(arg0 *eval.Program, arg1 eval.NormalFormOptions) (eval.NormalForm, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NormalForm", arg0, arg1)
+	ret0, _ := ret[0].(eval.NormalForm)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NormalForm indicates an expected call of NormalForm.
+func (mr *MockAnalyzerMockRecorder) NormalForm(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NormalForm", reflect.TypeOf((*MockAnalyzer)(nil).NormalForm), arg0, arg1)
+}
+
+// Satisfiable mocks base method.
+func (m *MockAnalyzer) Satisfiable(arg0 *eval.Program) (eval.SatResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Satisfiable", arg0)
+	ret0, _ := ret[0].(eval.SatResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Satisfiable indicates an expected call of Satisfiable.
+func (mr *MockAnalyzerMockRecorder) Satisfiable(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Satisfiable", reflect.TypeOf((*MockAnalyzer)(nil).Satisfiable), arg0)
+}
+
+// Simplify mocks base method.
+func (m *MockAnalyzer) Simplify(arg0 *eval.Program, arg1 eval.SimplifyOptions) (*eval.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Simplify", arg0, arg1)
+	ret0, _ := ret[0].(*eval.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Simplify indicates an expected call of Simplify.
+func (mr *MockAnalyzerMockRecorder) Simplify(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Simplify", reflect.TypeOf((*MockAnalyzer)(nil).Simplify), arg0, arg1)
+}
+
+// TruthTable mocks base method.
+func (m *MockAnalyzer) TruthTable(arg0 *eval.Program) (eval.TruthTable, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TruthTable", arg0)
+	ret0, _ := ret[0].(eval.TruthTable)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TruthTable indicates an expected call of TruthTable.
+func (mr *MockAnalyzerMockRecorder) TruthTable(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TruthTable", reflect.TypeOf((*MockAnalyzer)(nil).TruthTable), arg0)
+}
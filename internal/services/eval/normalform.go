@@ -0,0 +1,336 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FormCNF is the conjunctive normal form: an AND of clauses, each an OR of literals.
+	FormCNF = "cnf"
+	// FormDNF is the disjunctive normal form: an OR of terms, each an AND of literals.
+	FormDNF = "dnf"
+
+	// MaxNormalFormClauses bounds the size of a normal form built by distribution, which can grow
+	// exponentially with the expression. The Tseitin encoding of a CNF is not bound by it.
+	MaxNormalFormClauses = 4096
+
+	// tseitinPrefix is the prefix of the auxiliary variables introduced by the Tseitin encoding.
+	tseitinPrefix = "_t"
+)
+
+var (
+	// ErrNormalFormTooLarge is returned when a normal form exceeds MaxNormalFormClauses.
+	ErrNormalFormTooLarge = errors.New("normal form too large")
+	// ErrUnsupportedForm is returned for unknown normal forms and for options a form does not support.
+	ErrUnsupportedForm = errors.New("unsupported normal form")
+)
+
+type (
+	// NormalFormOptions holds the settings of a normal form conversion.
+	NormalFormOptions struct {
+		// Form is either FormCNF or FormDNF.
+		Form string
+		// Tseitin builds an equisatisfiable CNF with auxiliary variables instead of an equivalent one,
+		// keeping the result linear in the size of the expression. Only valid for FormCNF.
+		Tseitin bool
+	}
+
+	// NormalFormLiteral is a possibly negated atom of a normal form: a variable or a comparison such as
+	// "age >= 18".
+	NormalFormLiteral struct {
+		Variable string `json:"variable"`
+		Negated  bool   `json:"negated"`
+	}
+
+	// NormalForm is an expression converted to CNF or DNF. Clauses holds the clauses of a CNF or the terms
+	// of a DNF. A CNF without clauses is true and a DNF without terms is false.
+	NormalForm struct {
+		Form       string                `json:"form"`
+		Expression string                `json:"expression"`
+		Clauses    [][]NormalFormLiteral `json:"clauses"`
+		// Auxiliary lists the variables introduced by the Tseitin encoding, if any.
+		Auxiliary []string `json:"auxiliary,omitempty"`
+	}
+)
+
+// normalForm converts the program to the requested normal form.
+func normalForm(prog *Program, opts NormalFormOptions) (NormalForm, error) {
+	switch {
+	case opts.Form != FormCNF && opts.Form != FormDNF:
+		return NormalForm{}, fmt.Errorf("%w: %q, expected %s or %s", ErrUnsupportedForm, opts.Form, FormCNF, FormDNF)
+	case opts.Tseitin && opts.Form != FormCNF:
+		return NormalForm{}, fmt.Errorf("%w: the Tseitin encoding only produces %s", ErrUnsupportedForm, FormCNF)
+	case opts.Tseitin:
+		return tseitinCNF(prog)
+	}
+
+	// a DNF of n is the negation of a CNF of NOT n, so both are built as CNF
+	clauses, err := cnfClauses(prog.Root(), opts.Form == FormDNF)
+	if err != nil {
+		return NormalForm{}, err
+	}
+
+	if opts.Form == FormDNF {
+		for _, c := range clauses {
+			for i := range c {
+				c[i].Negated = !c[i].Negated
+			}
+		}
+	}
+
+	return newNormalForm(opts.Form, clauses, atomNodes(prog.Root())), nil
+}
+
+// cnfClauses returns the clauses of an equivalent CNF of n, or of NOT n when negated is set. Negations are
+// pushed down to the variables while OR is distributed over AND.
+func cnfClauses(n Node, negated bool) ([][]NormalFormLiteral, error) {
+	switch n := n.(type) {
+	case *Literal:
+		if n.Value != negated {
+			return [][]NormalFormLiteral{}, nil
+		}
+		return [][]NormalFormLiteral{{}}, nil
+	case *Ident, *Compare, *Reference:
+		key, _ := atomKey(n)
+		return [][]NormalFormLiteral{{{Variable: key, Negated: negated}}}, nil
+	case *Unary:
+		if n.Op != OpNot {
+			return nil, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		return cnfClauses(n.X, !negated)
+	case *Binary:
+		not := func(x Node) Node { return &Unary{Op: OpNot, X: x} }
+		switch n.Op {
+		case OpImplies:
+			// a IMPLIES b is NOT a OR b
+			return cnfClauses(&Binary{Op: OpOr, Left: not(n.Left), Right: n.Right}, negated)
+		case OpXor:
+			// a XOR b is (a OR b) AND (NOT a OR NOT b)
+			return cnfClauses(&Binary{
+				Op:    OpAnd,
+				Left:  &Binary{Op: OpOr, Left: n.Left, Right: n.Right},
+				Right: &Binary{Op: OpOr, Left: not(n.Left), Right: not(n.Right)},
+			}, negated)
+		case OpIff:
+			// a IFF b is (NOT a OR b) AND (a OR NOT b)
+			return cnfClauses(&Binary{
+				Op:    OpAnd,
+				Left:  &Binary{Op: OpOr, Left: not(n.Left), Right: n.Right},
+				Right: &Binary{Op: OpOr, Left: n.Left, Right: not(n.Right)},
+			}, negated)
+		case OpAnd, OpOr:
+		default:
+			return nil, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+
+		l, err := cnfClauses(n.Left, negated)
+		if err != nil {
+			return nil, err
+		}
+		r, err := cnfClauses(n.Right, negated)
+		if err != nil {
+			return nil, err
+		}
+
+		// by De Morgan, a negated OR is an AND of the negated operands and vice versa
+		if (n.Op == OpAnd) != negated {
+			return limitClauses(append(l, r...))
+		}
+		return distribute(l, r)
+	default:
+		return nil, fmt.Errorf("unsupported node %T", n)
+	}
+}
+
+// distribute returns the clauses of the disjunction of two CNFs, which pairs every clause of one with every
+// clause of the other. Clauses holding a variable and its negation are always true and dropped.
+func distribute(l, r [][]NormalFormLiteral) ([][]NormalFormLiteral, error) {
+	if len(l)*len(r) > MaxNormalFormClauses {
+		return nil, fmt.Errorf("%w: more than %d clauses", ErrNormalFormTooLarge, MaxNormalFormClauses)
+	}
+
+	clauses := make([][]NormalFormLiteral, 0, len(l)*len(r))
+	for _, a := range l {
+		for _, b := range r {
+			if c, ok := mergeClauses(a, b); ok {
+				clauses = append(clauses, c)
+			}
+		}
+	}
+
+	return clauses, nil
+}
+
+func limitClauses(clauses [][]NormalFormLiteral) ([][]NormalFormLiteral, error) {
+	if len(clauses) > MaxNormalFormClauses {
+		return nil, fmt.Errorf("%w: more than %d clauses", ErrNormalFormTooLarge, MaxNormalFormClauses)
+	}
+	return clauses, nil
+}
+
+// mergeClauses returns the sorted, de-duplicated union of two clauses, or false when the union holds a
+// variable along with its negation.
+func mergeClauses(a, b []NormalFormLiteral) ([]NormalFormLiteral, bool) {
+	polarity := make(map[string]bool, len(a)+len(b))
+	merged := make([]NormalFormLiteral, 0, len(a)+len(b))
+	for _, lit := range append(append([]NormalFormLiteral{}, a...), b...) {
+		negated, seen := polarity[lit.Variable]
+		if !seen {
+			polarity[lit.Variable] = lit.Negated
+			merged = append(merged, lit)
+			continue
+		}
+		if negated != lit.Negated {
+			return nil, false
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Variable < merged[j].Variable
+	})
+	return merged, true
+}
+
+// tseitinCNF returns the Tseitin encoding of the program as a normal form, naming the auxiliary variables
+// with a prefix no program variable starts with.
+func tseitinCNF(prog *Program) (NormalForm, error) {
+	t := newTseitin(Atoms(prog.Root()))
+	out, err := t.encode(prog.Root())
+	if err != nil {
+		return NormalForm{}, err
+	}
+	t.add(out)
+
+	prefix := tseitinPrefix
+	for hasVariablePrefix(prog.Variables(), prefix) {
+		prefix = "_" + prefix
+	}
+
+	var auxiliary []string
+	names := make([]string, len(t.names))
+	for v, name := range t.names[1:] {
+		if name == "" {
+			name = prefix + strconv.Itoa(len(auxiliary)+1)
+			auxiliary = append(auxiliary, name)
+		}
+		names[v+1] = name
+	}
+
+	clauses := make([][]NormalFormLiteral, 0, len(t.clauses))
+	for _, c := range t.clauses {
+		lits := make([]NormalFormLiteral, 0, len(c))
+		for _, lit := range c {
+			lits = append(lits, NormalFormLiteral{Variable: names[abs(lit)], Negated: lit < 0})
+		}
+		clauses = append(clauses, lits)
+	}
+
+	nf := newNormalForm(FormCNF, clauses, atomNodes(prog.Root()))
+	nf.Auxiliary = auxiliary
+	return nf, nil
+}
+
+func hasVariablePrefix(vars []string, prefix string) bool {
+	for _, name := range vars {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newNormalForm drops repeated clauses, and clauses subsumed by a shorter one, and renders the remaining ones
+// as an expression with the given atoms.
+func newNormalForm(form string, clauses [][]NormalFormLiteral, atoms map[string]Node) NormalForm {
+	sets := make([]map[NormalFormLiteral]bool, len(clauses))
+	for i, c := range clauses {
+		sets[i] = make(map[NormalFormLiteral]bool, len(c))
+		for _, lit := range c {
+			sets[i][lit] = true
+		}
+	}
+
+	kept := make([][]NormalFormLiteral, 0, len(clauses))
+	seen := make(map[string]bool, len(clauses))
+	for i, c := range clauses {
+		key := clauseKey(c)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		subsumed := false
+		for _, other := range clauses {
+			if len(other) < len(c) && subsumes(other, sets[i]) {
+				subsumed = true
+				break
+			}
+		}
+		if !subsumed {
+			kept = append(kept, c)
+		}
+	}
+
+	return NormalForm{
+		Form:       form,
+		Expression: Format(normalFormNode(form, kept, atoms)),
+		Clauses:    kept,
+	}
+}
+
+// subsumes reports whether every literal of a is in the clause with the given literals, which makes that
+// clause redundant.
+func subsumes(a []NormalFormLiteral, lits map[NormalFormLiteral]bool) bool {
+	for _, lit := range a {
+		if !lits[lit] {
+			return false
+		}
+	}
+	return true
+}
+
+func clauseKey(c []NormalFormLiteral) string {
+	var b strings.Builder
+	for _, lit := range c {
+		if lit.Negated {
+			b.WriteByte('!')
+		}
+		b.WriteString(lit.Variable)
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// normalFormNode builds the abstract syntax tree of a normal form, resolving its literals with the given atoms.
+func normalFormNode(form string, clauses [][]NormalFormLiteral, atoms map[string]Node) Node {
+	outer, inner := OpAnd, OpOr
+	if form == FormDNF {
+		outer, inner = OpOr, OpAnd
+	}
+
+	// an empty CNF is true and an empty clause is false, the opposite holds for DNF
+	var root Node
+	for _, c := range clauses {
+		var clauseNode Node
+		for _, lit := range c {
+			n := atomNode(atoms, lit.Variable)
+			if lit.Negated {
+				n = &Unary{Op: OpNot, X: n}
+			}
+			clauseNode = conjoin(clauseNode, n, inner)
+		}
+		if clauseNode == nil {
+			clauseNode = &Literal{Value: form == FormDNF}
+		}
+		root = conjoin(root, clauseNode, outer)
+	}
+	if root == nil {
+		root = &Literal{Value: form == FormCNF}
+	}
+
+	return root
+}
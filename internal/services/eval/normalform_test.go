@@ -0,0 +1,154 @@
+package eval_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_NormalForm(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		expression string
+		form       string
+		expResult  string
+	}{
+		{
+			name:       "CNF distributes OR over AND",
+			expression: "a OR (b AND c)",
+			form:       eval.FormCNF,
+			expResult:  "(a OR b) AND (a OR c)",
+		},
+		{
+			name:       "DNF distributes AND over OR",
+			expression: "a AND (b OR c)",
+			form:       eval.FormDNF,
+			expResult:  "a AND b OR a AND c",
+		},
+		{
+			name:       "CNF of implication and negation",
+			expression: "NOT (a IMPLIES b)",
+			form:       eval.FormCNF,
+			expResult:  "a AND NOT b",
+		},
+		{
+			name:       "CNF of XOR",
+			expression: "a XOR b",
+			form:       eval.FormCNF,
+			expResult:  "(a OR b) AND (NOT a OR NOT b)",
+		},
+		{
+			name:       "DNF of IFF",
+			expression: "a IFF b",
+			form:       eval.FormDNF,
+			expResult:  "NOT a AND NOT b OR a AND b",
+		},
+		{
+			name:       "Subsumed clauses are dropped",
+			expression: "a AND (a OR b)",
+			form:       eval.FormCNF,
+			expResult:  "a",
+		},
+		{
+			name:       "CNF of a tautology",
+			expression: "a OR NOT a",
+			form:       eval.FormCNF,
+			expResult:  "1",
+		},
+		{
+			name:       "Comparisons are kept as atoms",
+			expression: `age >= 18 AND (country IN ("BR", "PT") OR admin)`,
+			form:       eval.FormDNF,
+			expResult:  `age >= 18 AND country IN ("BR", "PT") OR admin AND age >= 18`,
+		},
+		{
+			name:       "DNF of a contradiction",
+			expression: "a AND NOT a",
+			form:       eval.FormDNF,
+			expResult:  "0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prog, err := eval.Compile(tc.expression)
+			require.NoError(t, err)
+
+			nf, err := evaluator.NormalForm(prog, eval.NormalFormOptions{Form: tc.form})
+			require.NoError(t, err)
+			require.Equal(t, tc.form, nf.Form)
+			require.Equal(t, tc.expResult, nf.Expression)
+			require.Empty(t, nf.Auxiliary)
+
+			converted, err := eval.Compile(nf.Expression)
+			require.NoError(t, err)
+			equivalence, err := evaluator.Equivalent(prog, converted)
+			require.NoError(t, err)
+			require.True(t, equivalence.Equivalent)
+		})
+	}
+
+	t.Run("Clauses follow the expression", func(t *testing.T) {
+		prog, err := eval.Compile("a OR NOT b")
+		require.NoError(t, err)
+
+		nf, err := evaluator.NormalForm(prog, eval.NormalFormOptions{Form: eval.FormCNF})
+		require.NoError(t, err)
+		require.Equal(t, [][]eval.NormalFormLiteral{
+			{{Variable: "a"}, {Variable: "b", Negated: true}},
+		}, nf.Clauses)
+	})
+
+	t.Run("Tseitin encoding stays linear", func(t *testing.T) {
+		// the CNF of a chain of n XORs has 2^(n-1) clauses when built by distribution
+		vars := make([]string, 16)
+		for i := range vars {
+			vars[i] = fmt.Sprintf("v%d", i)
+		}
+		prog, err := eval.Compile(strings.Join(vars, " XOR "))
+		require.NoError(t, err)
+
+		_, err = evaluator.NormalForm(prog, eval.NormalFormOptions{Form: eval.FormCNF})
+		require.ErrorIs(t, err, eval.ErrNormalFormTooLarge)
+
+		nf, err := evaluator.NormalForm(prog, eval.NormalFormOptions{Form: eval.FormCNF, Tseitin: true})
+		require.NoError(t, err)
+		require.Len(t, nf.Auxiliary, len(vars)-1)
+		require.LessOrEqual(t, len(nf.Clauses), 4*(len(vars)-1)+1)
+
+		// the encoding is equisatisfiable and keeps the models of the expression
+		encoded, err := eval.Compile(nf.Expression)
+		require.NoError(t, err)
+		sat, err := evaluator.Satisfiable(encoded)
+		require.NoError(t, err)
+		require.True(t, sat.Satisfiable)
+		got, err := prog.EvalAssignment(sat.Witness)
+		require.NoError(t, err)
+		require.True(t, got)
+	})
+
+	t.Run("Tseitin auxiliary variables do not clash", func(t *testing.T) {
+		prog, err := eval.Compile("_t1 AND (b OR c)")
+		require.NoError(t, err)
+
+		nf, err := evaluator.NormalForm(prog, eval.NormalFormOptions{Form: eval.FormCNF, Tseitin: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"__t1", "__t2"}, nf.Auxiliary)
+	})
+
+	t.Run("Unsupported options", func(t *testing.T) {
+		prog, err := eval.Compile("a")
+		require.NoError(t, err)
+
+		_, err = evaluator.NormalForm(prog, eval.NormalFormOptions{Form: "anf"})
+		require.ErrorIs(t, err, eval.ErrUnsupportedForm)
+
+		_, err = evaluator.NormalForm(prog, eval.NormalFormOptions{Form: eval.FormDNF, Tseitin: true})
+		require.ErrorIs(t, err, eval.ErrUnsupportedForm)
+	})
+}
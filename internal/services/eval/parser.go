@@ -0,0 +1,310 @@
+package eval
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// parser is a Pratt parser over the tokens of a single expression.
+	parser struct {
+		tokens []token
+		pos    int
+	}
+
+	// binaryOperator describes how an infix token is parsed.
+	binaryOperator struct {
+		op         Operator
+		precedence int
+		rightAssoc bool
+	}
+)
+
+// binaryOperators holds the infix operators of the language. Higher precedence binds tighter.
+// NOT is a prefix operator and binds tighter than all of them. IMPLIES is right associative,
+// so "a IMPLIES b IMPLIES c" reads as "a IMPLIES (b IMPLIES c)"; every other operator is left
+// associative.
+var binaryOperators = map[tokenKind]binaryOperator{
+	tokIff:     {op: OpIff, precedence: 1},
+	tokImplies: {op: OpImplies, precedence: 2, rightAssoc: true},
+	tokOr:      {op: OpOr, precedence: 3},
+	tokXor:     {op: OpXor, precedence: 4},
+	tokAnd:     {op: OpAnd, precedence: 5},
+}
+
+// Parse parses the given logical expression into an abstract syntax tree and checks the types of its
+// variables and comparisons. Any failure is reported as a *SyntaxError.
+func Parse(exp string) (Node, error) {
+	root, err := parse(exp)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := typeErrors(root); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	return root, nil
+}
+
+// parse parses the given logical expression into an abstract syntax tree without checking types.
+func parse(exp string) (Node, error) {
+	p := &parser{tokens: lex(exp)}
+	if p.peek().kind == tokEOF {
+		return nil, newSyntaxError(0, CodeEmptyExpression, "empty expression")
+	}
+
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		if tok.kind == tokIllegal {
+			return nil, newSyntaxError(tok.offset, CodeUnknownToken, unknownTokenMessage(tok))
+		}
+		if tok.kind == tokRParen {
+			return nil, newSyntaxError(tok.offset, CodeUnbalancedParen, "unexpected ')' without matching '('")
+		}
+		return nil, newSyntaxError(tok.offset, CodeUnexpectedToken, fmt.Sprintf("expected operator, found %s %q", tok.kind, tok.text))
+	}
+
+	return root, nil
+}
+
+// parseExpr parses a chain of binary operations whose precedence is at least minPrecedence.
+func (p *parser) parseExpr(minPrecedence int) (Node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		bop, ok := binaryOperators[tok.kind]
+		if !ok || bop.precedence < minPrecedence {
+			return left, nil
+		}
+		p.next()
+
+		nextPrecedence := bop.precedence + 1
+		if bop.rightAssoc {
+			nextPrecedence = bop.precedence
+		}
+
+		right, err := p.parseExpr(nextPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: bop.op, Left: left, Right: right, Offset: tok.offset}
+	}
+}
+
+// parseOperand parses a literal, a variable, a negation or a parenthesized group.
+func (p *parser) parseOperand() (Node, error) {
+	prev := p.previous()
+	tok := p.next()
+	switch tok.kind {
+	case tokNot:
+		x, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: OpNot, X: x, Offset: tok.offset}, nil
+	case tokTrue, tokFalse:
+		return &Literal{Value: tok.kind == tokTrue, Offset: tok.offset}, nil
+	case tokIdent:
+		if p.peek().kind.isComparison() {
+			return p.parseComparison(tok)
+		}
+		return &Ident{Name: tok.text, Offset: tok.offset}, nil
+	case tokRef:
+		ref, err := referenceOf(tok)
+		if err != nil {
+			return nil, err
+		}
+		return ref, nil
+	case tokLParen:
+		if p.peek().kind == tokRParen {
+			return nil, newSyntaxError(tok.offset, CodeEmptyGroup, "empty parentheses")
+		}
+
+		inner, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek().kind != tokRParen {
+			closing := p.peek()
+			if closing.kind == tokEOF {
+				return nil, newSyntaxError(tok.offset, CodeUnbalancedParen, "'(' is never closed")
+			}
+			if closing.kind == tokIllegal {
+				return nil, newSyntaxError(closing.offset, CodeUnknownToken, unknownTokenMessage(closing))
+			}
+			return nil, newSyntaxError(closing.offset, CodeUnexpectedToken, fmt.Sprintf("expected operator or ')', found %s %q", closing.kind, closing.text))
+		}
+		p.next()
+
+		return inner, nil
+	}
+
+	if tok.kind == tokIllegal || tok.kind == tokNumber {
+		return nil, newSyntaxError(tok.offset, CodeUnknownToken, unknownTokenMessage(tok))
+	}
+	if tok.kind.isComparison() {
+		return nil, newSyntaxError(tok.offset, CodeDanglingOperator, fmt.Sprintf("operator %s is missing its left variable", tok.text))
+	}
+	if prev.kind.isOperator() {
+		return nil, newSyntaxError(prev.offset, CodeDanglingOperator, missingOperandMessage(prev))
+	}
+	if tok.kind.isBinaryOperator() {
+		return nil, newSyntaxError(tok.offset, CodeDanglingOperator, fmt.Sprintf("operator %s is missing its left operand", tok.text))
+	}
+	if tok.kind == tokRParen {
+		return nil, newSyntaxError(tok.offset, CodeUnbalancedParen, "unexpected ')' without matching '('")
+	}
+
+	return nil, newSyntaxError(tok.offset, CodeUnexpectedToken, fmt.Sprintf("expected operand, found %s", tok.kind))
+}
+
+// parseComparison parses the comparison of the given variable with a constant, or with a parenthesized list
+// of constants for IN.
+func (p *parser) parseComparison(ident token) (Node, error) {
+	opTok := p.next()
+	cmp := &Compare{Op: comparisonOperators[opTok.kind], Name: ident.text, Offset: ident.offset}
+
+	if cmp.Op != OpIn {
+		v, err := p.parseValue(opTok)
+		if err != nil {
+			return nil, err
+		}
+		cmp.Values = []Value{v}
+		return cmp, nil
+	}
+
+	open := p.next()
+	if open.kind != tokLParen {
+		return nil, newSyntaxError(open.offset, CodeUnexpectedToken, fmt.Sprintf("expected '(' after IN, found %s", describeToken(open)))
+	}
+	if p.peek().kind == tokRParen {
+		return nil, newSyntaxError(open.offset, CodeEmptyGroup, "empty list")
+	}
+
+	for {
+		v, err := p.parseValue(p.previous())
+		if err != nil {
+			return nil, err
+		}
+		cmp.Values = append(cmp.Values, v)
+
+		switch sep := p.next(); sep.kind {
+		case tokComma:
+		case tokRParen:
+			return cmp, nil
+		case tokEOF:
+			return nil, newSyntaxError(open.offset, CodeUnbalancedParen, "'(' is never closed")
+		default:
+			return nil, newSyntaxError(sep.offset, CodeUnexpectedToken, fmt.Sprintf("expected ',' or ')' in list, found %s", describeToken(sep)))
+		}
+	}
+}
+
+// parseValue parses the constant following the given token.
+func (p *parser) parseValue(after token) (Value, error) {
+	tok := p.next()
+	switch {
+	case tok.kind.isValue():
+		return valueOfToken(tok), nil
+	case tok.kind == tokIllegal:
+		return Value{}, newSyntaxError(tok.offset, CodeUnknownToken, unknownTokenMessage(tok))
+	case tok.kind == tokEOF || tok.kind == tokRParen || tok.kind.isOperator():
+		return Value{}, newSyntaxError(after.offset, CodeDanglingOperator, missingValueMessage(after))
+	default:
+		return Value{}, newSyntaxError(tok.offset, CodeUnexpectedToken, fmt.Sprintf("expected number or string, found %s", describeToken(tok)))
+	}
+}
+
+// referenceOf returns the reference of a tokRef token, checking the ID of @{ID} references.
+func referenceOf(tok token) (*Reference, *SyntaxError) {
+	if !strings.HasPrefix(tok.text, "@{") {
+		return &Reference{Name: tok.text[1:], Offset: tok.offset}, nil
+	}
+
+	raw := tok.text[2 : len(tok.text)-1]
+	id, err := uuid.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, newSyntaxError(tok.offset, CodeInvalidReference, fmt.Sprintf("invalid expression ID %q in reference %s", raw, tok.text))
+	}
+	return &Reference{ID: id, Offset: tok.offset}, nil
+}
+
+// valueOfToken returns the constant of a value token. The lexer only produces valid numbers and strings.
+func valueOfToken(tok token) Value {
+	if tok.kind == tokString {
+		s, _ := strconv.Unquote(tok.text)
+		return String(s)
+	}
+
+	n, _ := strconv.ParseFloat(tok.text, 64)
+	return Number(n)
+}
+
+// peek returns the current token without consuming it.
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token. The trailing tokEOF is never consumed.
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// previous returns the last consumed token, or tokEOF when nothing was consumed yet.
+func (p *parser) previous() token {
+	if p.pos == 0 {
+		return token{kind: tokEOF}
+	}
+	return p.tokens[p.pos-1]
+}
+
+// unknownTokenMessage describes an illegal token, or a number used as an operand.
+func unknownTokenMessage(tok token) string {
+	if tok.kind == tokNumber {
+		return fmt.Sprintf("unknown token %q: only 0 and 1 are valid literals", tok.text)
+	}
+	if tok.text[0] == '"' {
+		return fmt.Sprintf("string %s is never closed", tok.text)
+	}
+	if strings.HasPrefix(tok.text, "@{") {
+		return fmt.Sprintf("reference %s is never closed", tok.text)
+	}
+	if tok.text == "@" {
+		return "expected an expression name or {ID} after @"
+	}
+	return fmt.Sprintf("unknown token %q", tok.text)
+}
+
+// missingValueMessage describes a comparison operator, or a list, that is not followed by a constant.
+func missingValueMessage(after token) string {
+	if after.kind.isComparison() {
+		return fmt.Sprintf("operator %s is missing its value", after.text)
+	}
+	return fmt.Sprintf("expected number or string after %s", describeToken(after))
+}
+
+// describeToken describes a token in error messages.
+func describeToken(tok token) string {
+	switch tok.kind {
+	case tokEOF, tokLParen, tokRParen, tokComma:
+		return tok.kind.String()
+	}
+	return fmt.Sprintf("%s %q", tok.kind, tok.text)
+}
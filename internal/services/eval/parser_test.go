@@ -0,0 +1,135 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+		expTree    string
+	}{
+		{
+			name:       "Single literal",
+			expression: "1",
+			expTree:    "1",
+		},
+		{
+			name:       "AND has higher precedence than OR",
+			expression: "x OR y AND z",
+			expTree:    "(x OR (y AND z))",
+		},
+		{
+			name:       "Operators are left associative",
+			expression: "x AND y AND z",
+			expTree:    "((x AND y) AND z)",
+		},
+		{
+			name:       "Parentheses override precedence",
+			expression: "(x OR y) AND z",
+			expTree:    "((x OR y) AND z)",
+		},
+		{
+			name:       "Precedence from NOT down to IFF",
+			expression: "NOT a AND b XOR c OR d -> e <-> f",
+			expTree:    "(((((NOT a AND b) XOR c) OR d) IMPLIES e) IFF f)",
+		},
+		{
+			name:       "IMPLIES is right associative",
+			expression: "a -> b IMPLIES c",
+			expTree:    "(a IMPLIES (b IMPLIES c))",
+		},
+		{
+			name:       "IFF is left associative",
+			expression: "a <-> b IFF c",
+			expTree:    "((a IFF b) IFF c)",
+		},
+		{
+			name:       "Redundant parentheses are dropped",
+			expression: "((x))",
+			expTree:    "x",
+		},
+		{
+			name:       "Comparisons bind tighter than NOT",
+			expression: "NOT age >= 18 OR score < -1.5",
+			expTree:    "(NOT age >= 18 OR score < -1.5)",
+		},
+		{
+			name:       "IN takes a list of constants",
+			expression: `country IN ("BR","PT") AND name != "a \"b\""`,
+			expTree:    `(country IN ("BR", "PT") AND name != "a \"b\"")`,
+		},
+		{
+			name:       "References are operands",
+			expression: "NOT @adults OR @{b6a1f4b6-5c07-4b8e-9a43-2f1d8f3e8b0c}",
+			expTree:    "(NOT @adults OR @{b6a1f4b6-5c07-4b8e-9a43-2f1d8f3e8b0c})",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := eval.Parse(tc.expression)
+			require.NoError(t, err)
+			require.Equal(t, tc.expTree, root.String())
+		})
+	}
+}
+
+func TestParseErrorOffset(t *testing.T) {
+	_, err := eval.Parse("(x AND y) OR")
+
+	var syntaxErr *eval.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	require.Equal(t, eval.CodeDanglingOperator, syntaxErr.Code)
+	require.Equal(t, 10, syntaxErr.Offset)
+}
+
+func TestParseTypeErrors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+		expOffset  int
+		expMessage string
+	}{
+		{
+			name:       "Variable used as a boolean and as a number",
+			expression: "x AND x > 1",
+			expOffset:  6,
+			expMessage: "variable x is used as bool and as number",
+		},
+		{
+			name:       "Variable compared to a number and to a string",
+			expression: `x == "a" OR x == 1`,
+			expOffset:  12,
+			expMessage: "variable x is used as string and as number",
+		},
+		{
+			name:       "Ordering operator on a string",
+			expression: `x > "a"`,
+			expOffset:  0,
+			expMessage: `operator > compares numbers, found string "a"`,
+		},
+		{
+			name:       "List mixing numbers and strings",
+			expression: `x IN (1, "a")`,
+			expOffset:  0,
+			expMessage: "list of x mixes numbers and strings",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := eval.Parse(tc.expression)
+
+			var syntaxErr *eval.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			require.Equal(t, eval.CodeTypeMismatch, syntaxErr.Code)
+			require.Equal(t, tc.expOffset, syntaxErr.Offset)
+			require.Equal(t, tc.expMessage, syntaxErr.Message)
+		})
+	}
+}
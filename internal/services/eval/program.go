@@ -0,0 +1,118 @@
+package eval
+
+import "sort"
+
+type (
+	// Bindings maps variable names to their values.
+	Bindings map[string]Value
+
+	// Program is a parsed expression ready to be evaluated any number of times.
+	Program struct {
+		root  Node
+		vars  []string
+		types map[string]Type
+		refs  []*Reference
+	}
+)
+
+// Compile parses the given expression into a Program. Failures are reported as *SyntaxError.
+func Compile(exp string) (*Program, error) {
+	root, err := Parse(exp)
+	if err != nil {
+		return nil, err
+	}
+
+	return newProgram(root), nil
+}
+
+// newProgram wraps an abstract syntax tree into a Program.
+func newProgram(root Node) *Program {
+	return &Program{
+		root:  root,
+		vars:  Variables(root),
+		types: variableTypes(root),
+		refs:  references(root),
+	}
+}
+
+// Root returns the root node of the program's abstract syntax tree.
+func (p *Program) Root() Node {
+	return p.root
+}
+
+// EvalAssignment evaluates the program with its atoms, the variables used on their own and the comparisons, set
+// to the given values, such as the witness of a satisfiability check.
+func (p *Program) EvalAssignment(assign Assignment) (bool, error) {
+	return evalAssignment(p.root, assign)
+}
+
+// Variables returns the sorted names of the variables used by the program.
+func (p *Program) Variables() []string {
+	return p.vars
+}
+
+// References returns the references of the program to other expressions, once each, in order of appearance.
+// Linked programs have none.
+func (p *Program) References() []*Reference {
+	return p.refs
+}
+
+// Type returns the type the program uses the variable as: TypeBool for variables used on their own and the
+// type of the constants for compared ones. Unknown variables are TypeBool.
+func (p *Program) Type(name string) Type {
+	return p.types[name]
+}
+
+// Missing returns the sorted names of the program's variables that have no value in vars.
+func (p *Program) Missing(vars Bindings) []string {
+	missing := make([]string, 0)
+	for _, name := range p.vars {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
+
+// Eval evaluates the program with the given variable values. An error wrapping ErrUnboundVariable is
+// returned when a variable needed to decide the result has no value.
+func (p *Program) Eval(vars Bindings) (bool, error) {
+	return evalNode(p.root, vars)
+}
+
+// Variables returns the sorted, de-duplicated names of the variables referenced under the given node, on their
+// own or in comparisons.
+func Variables(root Node) []string {
+	seen := make(map[string]struct{})
+	walk(root, func(n Node) {
+		if name, _, ok := variableUse(n); ok {
+			seen[name] = struct{}{}
+		}
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// walk calls fn for every node of the tree in depth-first pre-order.
+func walk(n Node, fn func(Node)) {
+	fn(n)
+	switch n := n.(type) {
+	case *Unary:
+		walk(n.X, fn)
+	case *Binary:
+		walk(n.Left, fn)
+		walk(n.Right, fn)
+	}
+}
+
+// String returns the fully parenthesized form of the program.
+func (p *Program) String() string {
+	return p.root.String()
+}
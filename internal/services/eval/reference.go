@@ -0,0 +1,144 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxReferenceDepth bounds the length of the chains of references followed by Link.
+const maxReferenceDepth = 32
+
+var (
+	// ErrUnresolvedReference is returned when a reference is evaluated before the program is linked.
+	ErrUnresolvedReference = errors.New("unresolved reference")
+	// ErrReferenceCycle is returned when the references of a program lead back to an expression being linked.
+	ErrReferenceCycle = errors.New("reference cycle")
+	// ErrInvalidReference is returned when linking a program fails for any other reason than a cycle or the
+	// resolver, such as references nested too deep or expressions using a variable with different types.
+	ErrInvalidReference = errors.New("invalid reference")
+)
+
+type (
+	// Resolver returns the ID and the program of the expression the given reference points to.
+	Resolver func(ref *Reference) (uuid.UUID, *Program, error)
+
+	// linker replaces the references of a tree with the trees of the expressions they point to.
+	linker struct {
+		resolve Resolver
+		// linked holds the linked tree of every expression resolved so far, so that an expression referenced
+		// several times is resolved once.
+		linked map[uuid.UUID]Node
+	}
+)
+
+// Link returns the program with every reference replaced by the expression it points to, which is linked in turn.
+// The given ID identifies the program itself, so that references back to it are reported as cycles. Programs
+// without references are returned as is. Errors of the resolver are returned wrapped with the failing reference.
+func Link(prog *Program, id uuid.UUID, resolve Resolver) (*Program, error) {
+	if len(prog.refs) == 0 {
+		return prog, nil
+	}
+
+	l := &linker{resolve: resolve, linked: make(map[uuid.UUID]Node)}
+	root, err := l.link(prog.root, []uuid.UUID{id}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := typeErrors(root); len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReference, errs[0].Message)
+	}
+
+	return newProgram(root), nil
+}
+
+// link returns n with its references replaced. path holds the IDs of the expressions being linked, from the
+// program down to the one n belongs to, and chain the references followed to reach it.
+func (l *linker) link(n Node, path []uuid.UUID, chain []string) (Node, error) {
+	switch n := n.(type) {
+	case *Reference:
+		chain = append(chain[:len(chain):len(chain)], n.String())
+		if len(chain) > maxReferenceDepth {
+			return nil, fmt.Errorf("%w: references are nested deeper than %d", ErrInvalidReference, maxReferenceDepth)
+		}
+
+		// references by ID are checked before being resolved, which spares resolving the expression being
+		// linked, whose stored version may be stale
+		if n.ID != uuid.Nil && onPath(path, n.ID) {
+			return nil, fmt.Errorf("%w: %s", ErrReferenceCycle, strings.Join(chain, " -> "))
+		}
+		if linked, ok := l.linked[n.ID]; ok && n.ID != uuid.Nil {
+			return linked, nil
+		}
+
+		id, prog, err := l.resolve(n)
+		if err != nil {
+			return nil, fmt.Errorf("reference %s: %w", n, err)
+		}
+		if onPath(path, id) {
+			return nil, fmt.Errorf("%w: %s", ErrReferenceCycle, strings.Join(chain, " -> "))
+		}
+		if linked, ok := l.linked[id]; ok {
+			return linked, nil
+		}
+		linked, err := l.link(prog.root, append(path[:len(path):len(path)], id), chain)
+		if err != nil {
+			return nil, err
+		}
+		l.linked[id] = linked
+
+		return linked, nil
+	case *Unary:
+		x, err := l.link(n.X, path, chain)
+		if err != nil || x == n.X {
+			return n, err
+		}
+		return &Unary{Op: n.Op, X: x, Offset: n.Offset}, nil
+	case *Binary:
+		left, err := l.link(n.Left, path, chain)
+		if err != nil {
+			return nil, err
+		}
+		right, err := l.link(n.Right, path, chain)
+		if err != nil {
+			return nil, err
+		}
+		if left == n.Left && right == n.Right {
+			return n, nil
+		}
+		return &Binary{Op: n.Op, Left: left, Right: right, Offset: n.Offset}, nil
+	default:
+		return n, nil
+	}
+}
+
+// onPath reports whether the expression of the given ID is being linked.
+func onPath(path []uuid.UUID, id uuid.UUID) bool {
+	for _, seen := range path {
+		if seen == id {
+			return true
+		}
+	}
+	return false
+}
+
+// references returns the references of the tree, once each, in order of appearance.
+func references(root Node) []*Reference {
+	var refs []*Reference
+	seen := make(map[string]struct{})
+	walk(root, func(n Node) {
+		ref, ok := n.(*Reference)
+		if !ok {
+			return
+		}
+		if _, dup := seen[ref.String()]; !dup {
+			seen[ref.String()] = struct{}{}
+			refs = append(refs, ref)
+		}
+	})
+
+	return refs
+}
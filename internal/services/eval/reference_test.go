@@ -0,0 +1,146 @@
+package eval_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLink(t *testing.T) {
+	adults, locals, self := uuid.New(), uuid.New(), uuid.New()
+	sources := map[uuid.UUID]string{
+		adults: "age >= 18",
+		locals: `country IN ("BR", "PT") AND @{` + adults.String() + `}`,
+	}
+	names := map[string]uuid.UUID{"adults": adults, "locals": locals}
+
+	// resolver resolves references against sources, with extra expressions for a single test case
+	resolver := func(t *testing.T, extra map[uuid.UUID]string) eval.Resolver {
+		return func(ref *eval.Reference) (uuid.UUID, *eval.Program, error) {
+			id := ref.ID
+			if ref.Name != "" {
+				id = names[ref.Name]
+			}
+
+			source, ok := extra[id]
+			if !ok {
+				source, ok = sources[id]
+			}
+			if !ok {
+				return uuid.Nil, nil, errors.New("no such expression")
+			}
+
+			prog, err := eval.Compile(source)
+			require.NoError(t, err)
+			return id, prog, nil
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		expression string
+		extra      map[uuid.UUID]string
+		expTree    string
+		expErr     error
+		expMessage string
+	}{
+		{
+			name:       "Programs without references are unchanged",
+			expression: "x AND y",
+			expTree:    "(x AND y)",
+		},
+		{
+			name:       "References are replaced by their expressions",
+			expression: "@adults OR admin",
+			expTree:    "(age >= 18 OR admin)",
+		},
+		{
+			name:       "Nested references are linked",
+			expression: "NOT blocked AND @{" + locals.String() + "}",
+			expTree:    `(NOT blocked AND (country IN ("BR", "PT") AND age >= 18))`,
+		},
+		{
+			name:       "Expressions referenced twice are linked once",
+			expression: "@locals OR @adults AND @adults",
+			expTree:    `((country IN ("BR", "PT") AND age >= 18) OR (age >= 18 AND age >= 18))`,
+		},
+		{
+			name:       "References back to the program are cycles",
+			expression: "x OR @{" + adults.String() + "}",
+			extra:      map[uuid.UUID]string{adults: "@{" + self.String() + "}"},
+			expErr:     eval.ErrReferenceCycle,
+			expMessage: "reference cycle: @{" + adults.String() + "} -> @{" + self.String() + "}",
+		},
+		{
+			name:       "Cycles between referenced expressions are detected",
+			expression: "@locals",
+			extra:      map[uuid.UUID]string{adults: "@locals AND x"},
+			expErr:     eval.ErrReferenceCycle,
+			expMessage: "reference cycle: @locals -> @{" + adults.String() + "} -> @locals",
+		},
+		{
+			name:       "Variables used with conflicting types are rejected",
+			expression: "age AND @adults",
+			expErr:     eval.ErrInvalidReference,
+			expMessage: "invalid reference: variable age is used as bool and as number",
+		},
+		{
+			name:       "Errors of the resolver name the reference",
+			expression: "x AND @missing",
+			expMessage: "reference @missing: no such expression",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prog, err := eval.Compile(tc.expression)
+			require.NoError(t, err)
+
+			linked, err := eval.Link(prog, self, resolver(t, tc.extra))
+			if tc.expMessage != "" {
+				require.EqualError(t, err, tc.expMessage)
+				if tc.expErr != nil {
+					require.True(t, errors.Is(err, tc.expErr))
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expTree, linked.Root().String())
+			require.Empty(t, linked.References())
+		})
+	}
+}
+
+func TestProgram_References(t *testing.T) {
+	id := uuid.New()
+	prog, err := eval.Compile("@a OR (@{" + id.String() + "} AND @a) OR x")
+	require.NoError(t, err)
+
+	refs := prog.References()
+	require.Len(t, refs, 2)
+	require.Equal(t, "a", refs[0].Name)
+	require.Equal(t, id, refs[1].ID)
+
+	// references are not variables, and programs must be linked before evaluation
+	require.Equal(t, []string{"x"}, prog.Variables())
+	_, err = prog.Eval(eval.Bindings{"x": eval.Bool(false)})
+	require.True(t, errors.Is(err, eval.ErrUnresolvedReference))
+}
+
+func TestIsName(t *testing.T) {
+	for _, name := range []string{"adults", "_draft", "rules.fraud_2", "Ünïcode"} {
+		require.True(t, eval.IsName(name), name)
+
+		prog, err := eval.Compile("@" + name)
+		require.NoError(t, err)
+		require.Equal(t, name, prog.References()[0].Name)
+	}
+
+	for _, name := range []string{"", "2fa", "adult-users", "a b", ".hidden", "@adults"} {
+		require.False(t, eval.IsName(name), name)
+	}
+}
@@ -0,0 +1,175 @@
+package eval
+
+type (
+	// SatResult describes the satisfiability of an expression.
+	SatResult struct {
+		// Satisfiable reports whether some assignment makes the expression true.
+		Satisfiable bool `json:"satisfiable"`
+		// Tautology reports whether every assignment makes the expression true.
+		Tautology bool `json:"tautology"`
+		// Contradiction reports whether no assignment makes the expression true.
+		Contradiction bool `json:"contradiction"`
+		// Witness is an assignment making the expression true, nil for contradictions.
+		Witness Assignment `json:"witness,omitempty"`
+		// Counterexample is an assignment making the expression false, nil for tautologies.
+		Counterexample Assignment `json:"counterexample,omitempty"`
+	}
+
+	// dpll is a Davis–Putnam–Logemann–Loveland solver over a set of clauses. assign holds the value of
+	// every variable: 1 for true, -1 for false and 0 while unassigned.
+	dpll struct {
+		clauses []clause
+		assign  []int8
+	}
+)
+
+// satisfiable checks whether some assignment makes root true and whether some makes it false, using a
+// DPLL search over the Tseitin encoding of the tree. Comparisons of a same variable are related by the
+// theory clauses, so only the assignments some values of the variables produce are considered, and
+// age > 30 AND age < 18 is a contradiction.
+func satisfiable(root Node) (SatResult, error) {
+	t := newTseitin(Atoms(root))
+	out, err := t.encode(root)
+	if err != nil {
+		return SatResult{}, err
+	}
+	t.theory(atomNodes(root))
+
+	witness, sat := solve(t, out)
+	counterexample, falsifiable := solve(t, -out)
+
+	return SatResult{
+		Satisfiable:    sat,
+		Tautology:      !falsifiable,
+		Contradiction:  !sat,
+		Witness:        witness,
+		Counterexample: counterexample,
+	}, nil
+}
+
+// solve searches for an assignment of the encoded clauses with the given literal set to true, returning
+// the values of the atoms when one exists.
+func solve(t *tseitin, lit int) (Assignment, bool) {
+	clauses := make([]clause, 0, len(t.clauses)+1)
+	clauses = append(clauses, t.clauses...)
+	clauses = append(clauses, clause{lit})
+
+	s := &dpll{
+		clauses: clauses,
+		assign:  make([]int8, t.numVars()+1),
+	}
+	if !s.search() {
+		return nil, false
+	}
+
+	return t.assignment(s.assign), true
+}
+
+// search assigns the remaining variables, undoing its own assignments when the clauses cannot be satisfied.
+func (s *dpll) search() bool {
+	trail, ok := s.propagate()
+	if ok {
+		v := s.branchVar()
+		if v == 0 {
+			return true
+		}
+
+		for _, value := range [2]int8{1, -1} {
+			s.assign[v] = value
+			if s.search() {
+				return true
+			}
+		}
+		s.assign[v] = 0
+	}
+
+	for _, v := range trail {
+		s.assign[v] = 0
+	}
+	return false
+}
+
+// propagate repeatedly assigns the last open literal of unit clauses. It returns the variables it assigned
+// and false when a clause has all of its literals false.
+func (s *dpll) propagate() ([]int, bool) {
+	var trail []int
+	for changed := true; changed; {
+		changed = false
+		for _, c := range s.clauses {
+			open, unit := 0, 0
+			satisfied := false
+			for _, lit := range c {
+				switch s.value(lit) {
+				case 1:
+					satisfied = true
+				case 0:
+					open++
+					unit = lit
+				}
+				if satisfied {
+					break
+				}
+			}
+
+			switch {
+			case satisfied:
+			case open == 0:
+				return trail, false
+			case open == 1:
+				v := abs(unit)
+				s.assign[v] = sign(unit)
+				trail = append(trail, v)
+				changed = true
+			}
+		}
+	}
+
+	return trail, true
+}
+
+// branchVar returns an unassigned variable of the first clause that is not satisfied yet, or 0 when every
+// clause is satisfied.
+func (s *dpll) branchVar() int {
+	for _, c := range s.clauses {
+		candidate := 0
+		satisfied := false
+		for _, lit := range c {
+			switch s.value(lit) {
+			case 1:
+				satisfied = true
+			case 0:
+				candidate = abs(lit)
+			}
+			if satisfied {
+				break
+			}
+		}
+		if !satisfied && candidate != 0 {
+			return candidate
+		}
+	}
+
+	return 0
+}
+
+// value returns the value of a literal under the current assignment: 1, -1 or 0 when unassigned.
+func (s *dpll) value(lit int) int8 {
+	if lit < 0 {
+		return -s.assign[-lit]
+	}
+	return s.assign[lit]
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int8 {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,228 @@
+package eval_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_Satisfiable(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name          string
+		expression    string
+		satisfiable   bool
+		tautology     bool
+		contradiction bool
+	}{
+		{
+			name:        "Contingent expression",
+			expression:  "(x OR y) AND NOT z",
+			satisfiable: true,
+		},
+		{
+			name:          "Contradiction",
+			expression:    "x AND NOT x",
+			contradiction: true,
+		},
+		{
+			name:        "Tautology",
+			expression:  "x OR NOT x",
+			satisfiable: true,
+			tautology:   true,
+		},
+		{
+			name:        "Tautology with implication",
+			expression:  "(a AND (a IMPLIES b)) IMPLIES b",
+			satisfiable: true,
+			tautology:   true,
+		},
+		{
+			name:          "Contradiction with XOR and IFF",
+			expression:    "(a XOR b) AND (a IFF b)",
+			contradiction: true,
+		},
+		{
+			name:        "Constant true",
+			expression:  "1 OR 0",
+			satisfiable: true,
+			tautology:   true,
+		},
+		{
+			name:          "Constant false",
+			expression:    "NOT 1",
+			contradiction: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prog, err := eval.Compile(tc.expression)
+			require.NoError(t, err)
+
+			res, err := evaluator.Satisfiable(prog)
+			require.NoError(t, err)
+			require.Equal(t, tc.satisfiable, res.Satisfiable)
+			require.Equal(t, tc.tautology, res.Tautology)
+			require.Equal(t, tc.contradiction, res.Contradiction)
+
+			if tc.satisfiable {
+				require.Len(t, res.Witness, len(prog.Variables()))
+				got, err := prog.EvalAssignment(res.Witness)
+				require.NoError(t, err)
+				require.True(t, got)
+			} else {
+				require.Nil(t, res.Witness)
+			}
+
+			if !tc.tautology {
+				require.Len(t, res.Counterexample, len(prog.Variables()))
+				got, err := prog.EvalAssignment(res.Counterexample)
+				require.NoError(t, err)
+				require.False(t, got)
+			} else {
+				require.Nil(t, res.Counterexample)
+			}
+		})
+	}
+}
+
+func TestEvaluator_SatisfiableComparisons(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name          string
+		expression    string
+		satisfiable   bool
+		tautology     bool
+		contradiction bool
+	}{
+		{
+			name:          "Disjoint ranges",
+			expression:    "age > 30 AND age < 18",
+			contradiction: true,
+		},
+		{
+			name:        "Overlapping ranges",
+			expression:  "age > 18 AND age <= 30",
+			satisfiable: true,
+		},
+		{
+			name:        "Range and its complement",
+			expression:  "age >= 18 OR age < 18",
+			satisfiable: true,
+			tautology:   true,
+		},
+		{
+			name:          "Different equalities",
+			expression:    `country == "BR" AND country == "PT"`,
+			contradiction: true,
+		},
+		{
+			name:          "Equality outside of a list",
+			expression:    `country IN ("BR", "PT") AND country == "US"`,
+			contradiction: true,
+		},
+		{
+			name:        "Equality and inequality of different constants",
+			expression:  `country != "BR" AND country != "PT"`,
+			satisfiable: true,
+		},
+		{
+			name:        "Comparisons of different variables",
+			expression:  "age > 30 AND score < 18",
+			satisfiable: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prog, err := eval.Compile(tc.expression)
+			require.NoError(t, err)
+
+			res, err := evaluator.Satisfiable(prog)
+			require.NoError(t, err)
+			require.Equal(t, tc.satisfiable, res.Satisfiable)
+			require.Equal(t, tc.tautology, res.Tautology)
+			require.Equal(t, tc.contradiction, res.Contradiction)
+
+			if tc.satisfiable {
+				got, err := prog.EvalAssignment(res.Witness)
+				require.NoError(t, err)
+				require.True(t, got)
+			}
+			if !tc.tautology {
+				got, err := prog.EvalAssignment(res.Counterexample)
+				require.NoError(t, err)
+				require.False(t, got)
+			}
+		})
+	}
+
+	t.Run("Witness is consistent", func(t *testing.T) {
+		prog, err := eval.Compile("age > 30 OR age < 18")
+		require.NoError(t, err)
+
+		res, err := evaluator.Satisfiable(prog)
+		require.NoError(t, err)
+		require.False(t, res.Witness["age > 30"] && res.Witness["age < 18"])
+		require.False(t, res.Counterexample["age > 30"])
+		require.False(t, res.Counterexample["age < 18"])
+	})
+}
+
+func TestEvaluator_SatisfiableAgreesWithTruthTable(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	expressions := []string{
+		"(a OR b) AND (NOT a OR c) AND (NOT b OR NOT c)",
+		"(a XOR b XOR c) IFF (a AND b AND c)",
+		"(a IMPLIES b) AND (b IMPLIES c) AND a AND NOT c",
+		"NOT (a AND b) IFF (NOT a OR NOT b)",
+	}
+
+	for _, exp := range expressions {
+		t.Run(exp, func(t *testing.T) {
+			prog, err := eval.Compile(exp)
+			require.NoError(t, err)
+
+			table, err := evaluator.TruthTable(prog)
+			require.NoError(t, err)
+			trueRows := 0
+			for _, row := range table.Rows {
+				if row.Result {
+					trueRows++
+				}
+			}
+
+			res, err := evaluator.Satisfiable(prog)
+			require.NoError(t, err)
+			require.Equal(t, trueRows > 0, res.Satisfiable)
+			require.Equal(t, trueRows == len(table.Rows), res.Tautology)
+		})
+	}
+}
+
+func TestEvaluator_SatisfiableLargeExpression(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	// a chain of 40 implications starting at true and ending at false has no model, and its truth table
+	// would have 2^40 rows
+	var b strings.Builder
+	b.WriteString("v0")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, " AND (v%d IMPLIES v%d)", i, i+1)
+	}
+	b.WriteString(" AND NOT v40")
+
+	prog, err := eval.Compile(b.String())
+	require.NoError(t, err)
+
+	res, err := evaluator.Satisfiable(prog)
+	require.NoError(t, err)
+	require.True(t, res.Contradiction)
+}
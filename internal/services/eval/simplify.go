@@ -0,0 +1,188 @@
+package eval
+
+// maxSimplifyPasses bounds the number of rewrite passes. Every rewrite shrinks the tree, so the fixpoint is
+// usually reached in a couple of passes.
+const maxSimplifyPasses = 32
+
+// SimplifyOptions holds the settings of a simplification.
+type SimplifyOptions struct {
+	// Minimize additionally computes a minimal sum of products with the Quine–McCluskey method and keeps it
+	// when it is smaller than the rewritten expression. It is bound by the truth table variable limit.
+	Minimize bool
+}
+
+// simplify rewrites the program with algebraic identities: constants, idempotence, complements, absorption,
+// double negation and De Morgan's laws. Rewritten nodes have no meaningful offset.
+func simplify(prog *Program, opts SimplifyOptions, maxVars int) (*Program, error) {
+	root := prog.Root()
+	for i := 0; i < maxSimplifyPasses; i++ {
+		next := simplifyNode(root)
+		if next.String() == root.String() {
+			break
+		}
+		root = next
+	}
+
+	if opts.Minimize {
+		minimal, err := minimize(newProgram(root), maxVars)
+		if err != nil {
+			return nil, err
+		}
+		if nodeCount(minimal) < nodeCount(root) {
+			root = minimal
+		}
+	}
+
+	return newProgram(root), nil
+}
+
+// simplifyNode applies the rewrite rules bottom-up.
+func simplifyNode(n Node) Node {
+	switch n := n.(type) {
+	case *Unary:
+		return simplifyNot(simplifyNode(n.X))
+	case *Binary:
+		return simplifyBinary(n.Op, simplifyNode(n.Left), simplifyNode(n.Right))
+	default:
+		return n
+	}
+}
+
+// simplifyNot simplifies the negation of an already simplified node.
+func simplifyNot(x Node) Node {
+	switch x := x.(type) {
+	case *Literal:
+		return &Literal{Value: !x.Value}
+	case *Unary:
+		// double negation
+		return x.X
+	case *Binary:
+		switch x.Op {
+		case OpXor:
+			return &Binary{Op: OpIff, Left: x.Left, Right: x.Right}
+		case OpIff:
+			return &Binary{Op: OpXor, Left: x.Left, Right: x.Right}
+		case OpAnd, OpOr:
+			// De Morgan, only when it does not add negations
+			if isNegation(x.Left) || isNegation(x.Right) {
+				return simplifyBinary(dual(x.Op), simplifyNot(x.Left), simplifyNot(x.Right))
+			}
+		}
+	}
+
+	return &Unary{Op: OpNot, X: x}
+}
+
+// simplifyBinary simplifies a binary operation over already simplified operands.
+func simplifyBinary(op Operator, l, r Node) Node {
+	lv, lConst := literalValue(l)
+	rv, rConst := literalValue(r)
+
+	switch op {
+	case OpAnd, OpOr:
+		// the absorbing element of AND is 0 and the one of OR is 1
+		absorbing := op == OpOr
+		switch {
+		case lConst && lv == absorbing, rConst && rv == absorbing:
+			return &Literal{Value: absorbing}
+		case lConst:
+			return r
+		case rConst:
+			return l
+		case equalNodes(l, r):
+			return l
+		case complements(l, r):
+			return &Literal{Value: absorbing}
+		case absorbs(l, r, dual(op)):
+			return l
+		case absorbs(r, l, dual(op)):
+			return r
+		}
+	case OpXor, OpIff:
+		// XOR with 0 and IFF with 1 keep the other operand, the opposite constant negates it
+		identity := op == OpIff
+		switch {
+		case lConst && rConst:
+			return &Literal{Value: (lv == rv) == identity}
+		case lConst:
+			return keepOrNegate(r, lv == identity)
+		case rConst:
+			return keepOrNegate(l, rv == identity)
+		case equalNodes(l, r):
+			return &Literal{Value: identity}
+		case complements(l, r):
+			return &Literal{Value: !identity}
+		}
+	case OpImplies:
+		switch {
+		case lConst && !lv, rConst && rv:
+			return &Literal{Value: true}
+		case lConst:
+			return r
+		case rConst:
+			return simplifyNot(l)
+		case equalNodes(l, r):
+			return &Literal{Value: true}
+		}
+	}
+
+	return &Binary{Op: op, Left: l, Right: r}
+}
+
+// absorbs reports whether x absorbs y, that is y is an op operation with x as one of its operands:
+// x AND (x OR z) is x, and so is x OR (x AND z).
+func absorbs(x, y Node, op Operator) bool {
+	b, ok := y.(*Binary)
+	return ok && b.Op == op && (equalNodes(x, b.Left) || equalNodes(x, b.Right))
+}
+
+func keepOrNegate(n Node, keep bool) Node {
+	if keep {
+		return n
+	}
+	return simplifyNot(n)
+}
+
+// complements reports whether one node is the negation of the other.
+func complements(a, b Node) bool {
+	if u, ok := a.(*Unary); ok && u.Op == OpNot && equalNodes(u.X, b) {
+		return true
+	}
+	if u, ok := b.(*Unary); ok && u.Op == OpNot && equalNodes(u.X, a) {
+		return true
+	}
+	return false
+}
+
+// dual returns the De Morgan dual of AND and OR.
+func dual(op Operator) Operator {
+	if op == OpAnd {
+		return OpOr
+	}
+	return OpAnd
+}
+
+func isNegation(n Node) bool {
+	u, ok := n.(*Unary)
+	return ok && u.Op == OpNot
+}
+
+func literalValue(n Node) (bool, bool) {
+	lit, ok := n.(*Literal)
+	if !ok {
+		return false, false
+	}
+	return lit.Value, true
+}
+
+// equalNodes reports whether two trees are structurally equal.
+func equalNodes(a, b Node) bool {
+	return a.String() == b.String()
+}
+
+// nodeCount returns the number of nodes of the tree.
+func nodeCount(n Node) int {
+	count := 0
+	walk(n, func(Node) { count++ })
+	return count
+}
@@ -0,0 +1,114 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_Simplify(t *testing.T) {
+	evaluator := eval.New(eval.Config{})
+
+	testCases := []struct {
+		name       string
+		expression string
+		minimize   bool
+		expResult  string
+	}{
+		{
+			name:       "Idempotence and absorption",
+			expression: "((x OR x) AND (x OR y))",
+			expResult:  "x",
+		},
+		{
+			name:       "Identity and annihilation",
+			expression: "(x AND 1) OR (y AND 0)",
+			expResult:  "x",
+		},
+		{
+			name:       "Double negation",
+			expression: "NOT NOT NOT x",
+			expResult:  "NOT x",
+		},
+		{
+			name:       "De Morgan removes negations",
+			expression: "NOT (NOT a AND NOT b)",
+			expResult:  "a OR b",
+		},
+		{
+			name:       "De Morgan does not add negations",
+			expression: "NOT (a AND b)",
+			expResult:  "NOT (a AND b)",
+		},
+		{
+			name:       "Complements",
+			expression: "(a AND NOT a) OR (b IFF NOT b) OR c",
+			expResult:  "c",
+		},
+		{
+			name:       "Constants with XOR, IFF and IMPLIES",
+			expression: "(a XOR 1) AND (1 IMPLIES b) AND (c IFF 1)",
+			expResult:  "NOT a AND b AND c",
+		},
+		{
+			name:       "Negated XOR becomes IFF",
+			expression: "NOT (a XOR b)",
+			expResult:  "a IFF b",
+		},
+		{
+			name:       "Parentheses are kept only where needed",
+			expression: "((a OR b) AND c) OR (d IMPLIES (e IMPLIES f))",
+			expResult:  "(a OR b) AND c OR (d IMPLIES e IMPLIES f)",
+		},
+		{
+			name:       "Minimization finds a smaller sum of products",
+			expression: "(a AND b) OR (a AND NOT b) OR (NOT a AND b)",
+			minimize:   true,
+			expResult:  "a OR b",
+		},
+		{
+			name:       "Minimization keeps the smaller rewritten form",
+			expression: "a XOR b XOR c",
+			minimize:   true,
+			expResult:  "a XOR b XOR c",
+		},
+		{
+			name:       "Minimization of a tautology",
+			expression: "(a IMPLIES b) OR (b IMPLIES a)",
+			minimize:   true,
+			expResult:  "1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prog, err := eval.Compile(tc.expression)
+			require.NoError(t, err)
+
+			simplified, err := evaluator.Simplify(prog, eval.SimplifyOptions{Minimize: tc.minimize})
+			require.NoError(t, err)
+			require.Equal(t, tc.expResult, eval.Format(simplified.Root()))
+
+			equivalence, err := evaluator.Equivalent(prog, simplified)
+			require.NoError(t, err)
+			require.True(t, equivalence.Equivalent)
+
+			reparsed, err := eval.Compile(eval.Format(simplified.Root()))
+			require.NoError(t, err)
+			require.Equal(t, simplified.String(), reparsed.String())
+		})
+	}
+
+	t.Run("Minimization is bound by the variable limit", func(t *testing.T) {
+		limited := eval.New(eval.Config{MaxTruthTableVariables: 2})
+		prog, err := eval.Compile("a AND b AND c")
+		require.NoError(t, err)
+
+		_, err = limited.Simplify(prog, eval.SimplifyOptions{Minimize: true})
+		require.ErrorIs(t, err, eval.ErrTooManyVariables)
+
+		_, err = limited.Simplify(prog, eval.SimplifyOptions{})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,119 @@
+package eval
+
+import "sort"
+
+// theory adds the clauses relating the comparisons of a same variable, so that the atoms of every solution are
+// the results of a single value of each variable: age > 30 and age < 18 can no longer both be true. The values
+// a variable can take are split into regions where none of its comparisons changes result, which are the
+// constants it is compared to and, for numbers, the intervals around them or, for strings, any other string.
+// One selector per region implies the results of the comparisons on that region, and one selector must hold.
+func (t *tseitin) theory(nodes map[string]Node) {
+	groups := make(map[string][]*Compare)
+	var names []string
+	for _, n := range nodes {
+		cmp, ok := n.(*Compare)
+		if !ok {
+			continue
+		}
+
+		group := cmp.Name + "\x00" + cmp.Values[0].Type().String()
+		if _, ok := groups[group]; !ok {
+			names = append(names, group)
+		}
+		groups[group] = append(groups[group], cmp)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmps := groups[name]
+		if len(cmps) < 2 {
+			// a single comparison can be both true and false, there is nothing to relate it to
+			continue
+		}
+		sort.Slice(cmps, func(i, j int) bool {
+			return cmps[i].String() < cmps[j].String()
+		})
+
+		t.relate(cmps)
+	}
+}
+
+// relate constrains the atoms of the given comparisons of one variable to the results of one of its regions.
+func (t *tseitin) relate(cmps []*Compare) {
+	atoms := make([]int, len(cmps))
+	for i, cmp := range cmps {
+		atoms[i] = t.numbers[cmp.String()]
+	}
+
+	seen := make(map[string]bool)
+	var selectors []int
+	for _, v := range regions(cmps) {
+		results := make([]int, len(cmps))
+		key := make([]byte, len(cmps))
+		valid := true
+		for i, cmp := range cmps {
+			ok, err := evalCompare(cmp, v)
+			if err != nil {
+				valid = false
+				break
+			}
+
+			results[i], key[i] = atoms[i], '1'
+			if !ok {
+				results[i], key[i] = -atoms[i], '0'
+			}
+		}
+		if !valid || seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+
+		s := t.newVar("")
+		for _, lit := range results {
+			t.add(-s, lit)
+		}
+		selectors = append(selectors, s)
+	}
+
+	t.add(selectors...)
+}
+
+// regions returns one value of every region of the variable of the given comparisons: each constant and, for
+// numbers, a value below, between and above them or, for strings, a value different from all of them.
+func regions(cmps []*Compare) []Value {
+	typ := cmps[0].Values[0].Type()
+	var constants []Value
+	seen := make(map[string]bool)
+	for _, cmp := range cmps {
+		for _, c := range cmp.Values {
+			if !seen[c.String()] {
+				seen[c.String()] = true
+				constants = append(constants, c)
+			}
+		}
+	}
+
+	switch typ {
+	case TypeNumber:
+		sort.Slice(constants, func(i, j int) bool {
+			return constants[i].n < constants[j].n
+		})
+
+		values := []Value{Number(constants[0].n - 1)}
+		for i, c := range constants {
+			values = append(values, c)
+			if i+1 < len(constants) {
+				values = append(values, Number(c.n+(constants[i+1].n-c.n)/2))
+			}
+		}
+		return append(values, Number(constants[len(constants)-1].n+1))
+	case TypeString:
+		other := ""
+		for seen[String(other).String()] {
+			other += "?"
+		}
+		return append(constants, String(other))
+	default:
+		return []Value{Bool(true), Bool(false)}
+	}
+}
@@ -0,0 +1,137 @@
+package eval
+
+type (
+	// tokenKind identifies the lexical class of a token.
+	tokenKind int
+
+	// token is a lexical unit of an expression along with its byte offset in the source.
+	token struct {
+		kind   tokenKind
+		text   string
+		offset int
+	}
+)
+
+const (
+	tokEOF tokenKind = iota
+	tokIllegal
+	tokTrue
+	tokFalse
+	tokIdent
+	tokNot
+	tokAnd
+	tokXor
+	tokOr
+	tokImplies
+	tokIff
+	tokLParen
+	tokRParen
+	tokComma
+	tokNumber
+	tokString
+	tokEq
+	tokNe
+	tokLt
+	tokLe
+	tokGt
+	tokGe
+	tokIn
+	tokRef
+)
+
+// keywords maps the reserved words of the expression language to their token kinds.
+var keywords = map[string]tokenKind{
+	"NOT":     tokNot,
+	"AND":     tokAnd,
+	"XOR":     tokXor,
+	"OR":      tokOr,
+	"IMPLIES": tokImplies,
+	"IFF":     tokIff,
+	"IN":      tokIn,
+}
+
+// symbols maps the symbolic aliases of the operators to their token kinds. Longer symbols come first
+// so that "<->" is not lexed as "<" followed by "->", and "<-" is an incomplete "<->" rather than "<"
+// followed by a negative number, which needs a space as in "x < -1".
+var symbols = []struct {
+	text string
+	kind tokenKind
+}{
+	{text: "<->", kind: tokIff},
+	{text: "<-", kind: tokIllegal},
+	{text: "->", kind: tokImplies},
+	{text: "==", kind: tokEq},
+	{text: "!=", kind: tokNe},
+	{text: "<=", kind: tokLe},
+	{text: ">=", kind: tokGe},
+	{text: "<", kind: tokLt},
+	{text: ">", kind: tokGt},
+	{text: "!", kind: tokNot},
+	{text: ",", kind: tokComma},
+}
+
+// comparisonOperators maps the comparison tokens to their operators.
+var comparisonOperators = map[tokenKind]Operator{
+	tokEq: OpEq,
+	tokNe: OpNe,
+	tokLt: OpLt,
+	tokLe: OpLe,
+	tokGt: OpGt,
+	tokGe: OpGe,
+	tokIn: OpIn,
+}
+
+// String returns a human-readable description of the token kind, used in error messages.
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of expression"
+	case tokIllegal:
+		return "unknown token"
+	case tokTrue, tokFalse:
+		return "literal"
+	case tokIdent:
+		return "variable"
+	case tokNot, tokAnd, tokXor, tokOr, tokImplies, tokIff:
+		return "operator"
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	case tokComma:
+		return "','"
+	case tokNumber:
+		return "number"
+	case tokString:
+		return "string"
+	case tokEq, tokNe, tokLt, tokLe, tokGt, tokGe, tokIn:
+		return "comparison operator"
+	case tokRef:
+		return "reference"
+	default:
+		return "unknown token"
+	}
+}
+
+// isBinaryOperator reports whether the token kind is an infix operator.
+func (k tokenKind) isBinaryOperator() bool {
+	_, ok := binaryOperators[k]
+	return ok
+}
+
+// isComparison reports whether the token kind is a comparison operator.
+func (k tokenKind) isComparison() bool {
+	_, ok := comparisonOperators[k]
+	return ok
+}
+
+// isValue reports whether the token kind is a constant that can be compared to a variable. The literals 0
+// and 1 are numbers in comparisons.
+func (k tokenKind) isValue() bool {
+	return k == tokNumber || k == tokString || k == tokTrue || k == tokFalse
+}
+
+// isOperator reports whether the token kind is a prefix or an infix operator.
+func (k tokenKind) isOperator() bool {
+	return k == tokNot || k.isBinaryOperator()
+}
@@ -0,0 +1,61 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxTruthTableVariables is the variable limit used when none is configured. A table has
+// 2^n rows for n variables, so the limit bounds the work of a single request.
+const DefaultMaxTruthTableVariables = 12
+
+// ErrTooManyVariables is returned when an expression has more variables than an operation allows.
+var ErrTooManyVariables = errors.New("too many variables")
+
+type (
+	// TruthTable holds the result of an expression for every assignment of its variables. Comparisons are
+	// columns of their own, such as "age >= 18", like variables used on their own.
+	TruthTable struct {
+		Variables []string        `json:"variables"`
+		Rows      []TruthTableRow `json:"rows"`
+	}
+
+	// TruthTableRow is a single assignment of a truth table. Values follows the order of the table's
+	// Variables.
+	TruthTableRow struct {
+		Values []bool `json:"values"`
+		Result bool   `json:"result"`
+	}
+)
+
+// truthTable enumerates every assignment of the program's variables in binary counting order, with the
+// first variable as the most significant bit.
+func truthTable(prog *Program, maxVars int) (TruthTable, error) {
+	vars := Atoms(prog.Root())
+	if len(vars) > maxVars {
+		return TruthTable{}, fmt.Errorf("%w: expression has %d variables, truth tables are limited to %d", ErrTooManyVariables, len(vars), maxVars)
+	}
+
+	rowCount := 1 << len(vars)
+	table := TruthTable{
+		Variables: vars,
+		Rows:      make([]TruthTableRow, 0, rowCount),
+	}
+
+	assign := make(Assignment, len(vars))
+	for i := 0; i < rowCount; i++ {
+		values := make([]bool, len(vars))
+		for j, name := range vars {
+			values[j] = i&(1<<(len(vars)-1-j)) != 0
+			assign[name] = values[j]
+		}
+
+		result, err := evalAssignment(prog.Root(), assign)
+		if err != nil {
+			return TruthTable{}, err
+		}
+		table.Rows = append(table.Rows, TruthTableRow{Values: values, Result: result})
+	}
+
+	return table, nil
+}
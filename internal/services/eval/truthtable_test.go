@@ -0,0 +1,64 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/gmaschi/log-exp-eval/internal/services/eval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_TruthTable(t *testing.T) {
+	evaluator := eval.New(eval.Config{MaxTruthTableVariables: 3})
+
+	t.Run("Rows enumerate every assignment", func(t *testing.T) {
+		prog, err := eval.Compile("y IMPLIES x")
+		require.NoError(t, err)
+
+		table, err := evaluator.TruthTable(prog)
+		require.NoError(t, err)
+		require.Equal(t, eval.TruthTable{
+			Variables: []string{"x", "y"},
+			Rows: []eval.TruthTableRow{
+				{Values: []bool{false, false}, Result: true},
+				{Values: []bool{false, true}, Result: false},
+				{Values: []bool{true, false}, Result: true},
+				{Values: []bool{true, true}, Result: true},
+			},
+		}, table)
+	})
+
+	t.Run("Constant expression has a single row", func(t *testing.T) {
+		prog, err := eval.Compile("1 AND NOT 0")
+		require.NoError(t, err)
+
+		table, err := evaluator.TruthTable(prog)
+		require.NoError(t, err)
+		require.Empty(t, table.Variables)
+		require.Equal(t, []eval.TruthTableRow{{Values: []bool{}, Result: true}}, table.Rows)
+	})
+
+	t.Run("Comparisons are columns of their own", func(t *testing.T) {
+		prog, err := eval.Compile("age >= 18 AND NOT age > 65")
+		require.NoError(t, err)
+
+		table, err := evaluator.TruthTable(prog)
+		require.NoError(t, err)
+		require.Equal(t, eval.TruthTable{
+			Variables: []string{"age > 65", "age >= 18"},
+			Rows: []eval.TruthTableRow{
+				{Values: []bool{false, false}, Result: false},
+				{Values: []bool{false, true}, Result: true},
+				{Values: []bool{true, false}, Result: false},
+				{Values: []bool{true, true}, Result: false},
+			},
+		}, table)
+	})
+
+	t.Run("Variable limit is enforced", func(t *testing.T) {
+		prog, err := eval.Compile("a AND b AND c AND d")
+		require.NoError(t, err)
+
+		_, err = evaluator.TruthTable(prog)
+		require.ErrorIs(t, err, eval.ErrTooManyVariables)
+	})
+}
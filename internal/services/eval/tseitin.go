@@ -0,0 +1,144 @@
+package eval
+
+import "fmt"
+
+type (
+	// clause is a disjunction of literals. A literal is a positive or negative variable number, following
+	// the DIMACS convention.
+	clause []int
+
+	// tseitin encodes an abstract syntax tree into an equisatisfiable set of clauses by introducing one
+	// auxiliary variable per gate. The encoding grows linearly with the size of the tree.
+	tseitin struct {
+		clauses []clause
+		// numbers maps the atoms of the expression, its variables and comparisons, to their variable numbers.
+		numbers map[string]int
+		// names holds the atom of every variable number, empty for auxiliary variables.
+		names []string
+	}
+)
+
+// newTseitin returns an encoder with the given atoms numbered from 1, in order.
+func newTseitin(vars []string) *tseitin {
+	t := &tseitin{
+		numbers: make(map[string]int, len(vars)),
+		names:   make([]string, 1, len(vars)+1),
+	}
+	for _, name := range vars {
+		t.numbers[name] = t.newVar(name)
+	}
+
+	return t
+}
+
+// newVar allocates the next variable number.
+func (t *tseitin) newVar(name string) int {
+	t.names = append(t.names, name)
+	return len(t.names) - 1
+}
+
+// numVars returns the number of allocated variables, including auxiliary ones.
+func (t *tseitin) numVars() int {
+	return len(t.names) - 1
+}
+
+// encode adds the clauses defining the value of n and returns the literal holding it.
+func (t *tseitin) encode(n Node) (int, error) {
+	switch n := n.(type) {
+	case *Literal:
+		v := t.newVar("")
+		if n.Value {
+			t.add(v)
+		} else {
+			t.add(-v)
+		}
+		return v, nil
+	case *Ident, *Compare, *Reference:
+		key, _ := atomKey(n)
+		v, ok := t.numbers[key]
+		if !ok {
+			v = t.newVar(key)
+			t.numbers[key] = v
+		}
+		return v, nil
+	case *Unary:
+		x, err := t.encode(n.X)
+		if err != nil {
+			return 0, err
+		}
+
+		if n.Op != OpNot {
+			return 0, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		return -x, nil
+	case *Binary:
+		a, err := t.encode(n.Left)
+		if err != nil {
+			return 0, err
+		}
+		b, err := t.encode(n.Right)
+		if err != nil {
+			return 0, err
+		}
+
+		switch n.Op {
+		case OpAnd:
+			return t.and(a, b), nil
+		case OpOr:
+			return t.or(a, b), nil
+		case OpImplies:
+			return t.or(-a, b), nil
+		case OpXor:
+			return t.xor(a, b), nil
+		case OpIff:
+			return -t.xor(a, b), nil
+		default:
+			return 0, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported node %T", n)
+	}
+}
+
+// and returns a gate g with g <-> (a AND b).
+func (t *tseitin) and(a, b int) int {
+	g := t.newVar("")
+	t.add(-g, a)
+	t.add(-g, b)
+	t.add(g, -a, -b)
+	return g
+}
+
+// or returns a gate g with g <-> (a OR b).
+func (t *tseitin) or(a, b int) int {
+	g := t.newVar("")
+	t.add(g, -a)
+	t.add(g, -b)
+	t.add(-g, a, b)
+	return g
+}
+
+// xor returns a gate g with g <-> (a XOR b).
+func (t *tseitin) xor(a, b int) int {
+	g := t.newVar("")
+	t.add(-g, a, b)
+	t.add(-g, -a, -b)
+	t.add(g, -a, b)
+	t.add(g, a, -b)
+	return g
+}
+
+func (t *tseitin) add(lits ...int) {
+	t.clauses = append(t.clauses, lits)
+}
+
+// assignment returns the values of the atoms in a solver assignment. Atoms the solver left unassigned are
+// reported as false.
+func (t *tseitin) assignment(assign []int8) Assignment {
+	atoms := make(Assignment, len(t.numbers))
+	for key, v := range t.numbers {
+		atoms[key] = assign[v] > 0
+	}
+
+	return atoms
+}
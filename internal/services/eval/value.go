@@ -0,0 +1,220 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Types of values and variables. Variables used on their own are booleans, and variables compared to
+// constants take the type of the constants. Lists are only accepted by IN.
+const (
+	TypeBool Type = iota
+	TypeNumber
+	TypeString
+	TypeList
+)
+
+type (
+	// Type is the type of a value or of a variable.
+	Type int
+
+	// Value is a typed variable value or constant: a boolean, a number, a string or a list of numbers or
+	// strings.
+	Value struct {
+		typ  Type
+		b    bool
+		n    float64
+		s    string
+		list []Value
+	}
+)
+
+// String returns the name of the type.
+func (t Type) String() string {
+	switch t {
+	case TypeBool:
+		return "bool"
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeList:
+		return "list"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// Bool returns a boolean value.
+func Bool(b bool) Value {
+	return Value{typ: TypeBool, b: b}
+}
+
+// Number returns a numeric value.
+func Number(n float64) Value {
+	return Value{typ: TypeNumber, n: n}
+}
+
+// String returns a string value.
+func String(s string) Value {
+	return Value{typ: TypeString, s: s}
+}
+
+// List returns a list of the given values.
+func List(values ...Value) Value {
+	return Value{typ: TypeList, list: values}
+}
+
+// Type returns the type of the value.
+func (v Value) Type() Type {
+	return v.typ
+}
+
+// Interface returns the value as a bool, a float64, a string or a []interface{}.
+func (v Value) Interface() interface{} {
+	switch v.typ {
+	case TypeNumber:
+		return v.n
+	case TypeString:
+		return v.s
+	case TypeList:
+		list := make([]interface{}, 0, len(v.list))
+		for _, item := range v.list {
+			list = append(list, item.Interface())
+		}
+		return list
+	default:
+		return v.b
+	}
+}
+
+// MarshalJSON encodes the value as the matching JSON type.
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Interface())
+}
+
+// String returns the value as written in expressions: 1 or 0 for booleans, numbers without exponent, quoted
+// strings and parenthesized lists.
+func (v Value) String() string {
+	switch v.typ {
+	case TypeNumber:
+		return strconv.FormatFloat(v.n, 'f', -1, 64)
+	case TypeString:
+		return strconv.Quote(v.s)
+	case TypeList:
+		items := make([]string, 0, len(v.list))
+		for _, item := range v.list {
+			items = append(items, item.String())
+		}
+		return "(" + strings.Join(items, ", ") + ")"
+	default:
+		if v.b {
+			return "1"
+		}
+		return "0"
+	}
+}
+
+// ValueOf converts a decoded JSON value into a Value. Lists may only hold numbers and strings.
+func ValueOf(raw interface{}) (Value, bool) {
+	switch raw := raw.(type) {
+	case bool:
+		return Bool(raw), true
+	case float64:
+		return Number(raw), true
+	case int:
+		return Number(float64(raw)), true
+	case string:
+		return String(raw), true
+	case []interface{}:
+		list := make([]Value, 0, len(raw))
+		for _, item := range raw {
+			v, ok := ValueOf(item)
+			if !ok || (v.typ != TypeNumber && v.typ != TypeString) {
+				return Value{}, false
+			}
+			list = append(list, v)
+		}
+		return List(list...), true
+	default:
+		return Value{}, false
+	}
+}
+
+// coerce converts the value to the given variable type. Booleans also accept the numbers 0 and 1 and the
+// strings "true", "false", "0" and "1", and numbers accept numeric strings. Numbers and strings also accept
+// lists of them, which IN compares element by element.
+func coerce(v Value, t Type) (Value, bool) {
+	switch t {
+	case TypeBool:
+		b, ok := v.asBool()
+		return Bool(b), ok
+	case TypeNumber, TypeString:
+		if v.typ != TypeList {
+			return coerceScalar(v, t)
+		}
+
+		list := make([]Value, 0, len(v.list))
+		for _, item := range v.list {
+			c, ok := coerceScalar(item, t)
+			if !ok {
+				return Value{}, false
+			}
+			list = append(list, c)
+		}
+		return List(list...), true
+	default:
+		return Value{}, false
+	}
+}
+
+func coerceScalar(v Value, t Type) (Value, bool) {
+	if t == TypeNumber {
+		n, ok := v.asNumber()
+		return Number(n), ok
+	}
+
+	s, ok := v.asString()
+	return String(s), ok
+}
+
+// asBool returns the value as a boolean, accepting the same values as coerce.
+func (v Value) asBool() (bool, bool) {
+	switch v.typ {
+	case TypeBool:
+		return v.b, true
+	case TypeNumber:
+		if v.n == 0 || v.n == 1 {
+			return v.n == 1, true
+		}
+	case TypeString:
+		switch strings.ToLower(v.s) {
+		case "true", "1":
+			return true, true
+		case "false", "0":
+			return false, true
+		}
+	}
+
+	return false, false
+}
+
+// asNumber returns the value as a number, parsing numeric strings.
+func (v Value) asNumber() (float64, bool) {
+	switch v.typ {
+	case TypeNumber:
+		return v.n, true
+	case TypeString:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v.s), 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
+
+// asString returns the value of a string.
+func (v Value) asString() (string, bool) {
+	return v.s, v.typ == TypeString
+}
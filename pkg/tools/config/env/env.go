@@ -1,41 +1,162 @@
 package env
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	dbDriverKey         = "DB_DRIVER"
-	dbSourceKey         = "DB_SOURCE"
-	serverAddressKey    = "SERVER_ADDRESS"
-	dbPortKey           = "POSTGRES_PORT"
-	dbHostKey           = "DB_HOST"
-	postgresUserKey     = "POSTGRES_USER"
-	postgresPasswordKey = "POSTGRES_PASSWORD"
-	postgresDBKey       = "POSTGRES_DB"
+	dbDriverKey          = "DB_DRIVER"
+	dbSourceKey          = "DB_SOURCE"
+	serverAddressKey     = "SERVER_ADDRESS"
+	dbPortKey            = "POSTGRES_PORT"
+	dbHostKey            = "DB_HOST"
+	postgresUserKey      = "POSTGRES_USER"
+	postgresPasswordKey  = "POSTGRES_PASSWORD"
+	postgresDBKey        = "POSTGRES_DB"
+	evalCacheSizeKey     = "EVAL_CACHE_SIZE"
+	truthTableMaxVarsKey = "TRUTH_TABLE_MAX_VARIABLES"
+	evalBatchMaxSizeKey  = "EVAL_BATCH_MAX_SIZE"
+	evalBatchTimeoutKey  = "EVAL_BATCH_TIMEOUT"
+	evalBatchMaxBytesKey = "EVAL_BATCH_MAX_BYTES"
+	purgeRetentionKey    = "PURGE_RETENTION"
+	purgeIntervalKey     = "PURGE_INTERVAL"
+	matchIndexTTLKey     = "MATCH_INDEX_TTL"
+	evalLinkTTLKey       = "EVAL_LINK_TTL"
+	adminUserIDsKey      = "ADMIN_USER_IDS"
 )
 
 type Config struct {
-	DbDriver         string `json:"DB_DRIVER"`
-	DbSource         string `json:"DB_SOURCE"`
-	ServerAddress    string `json:"SERVER_ADDRESS"`
-	DBPort           string `json:"POSTGRES_PORT"`
-	DBHost           string `json:"DB_HOST"`
-	PostgresUser     string `json:"POSTGRES_USER"`
-	PostgresPassword string `json:"POSTGRES_PASSWORD"`
-	PostgresDB       string `json:"POSTGRES_DB"`
+	DbDriver          string        `json:"DB_DRIVER"`
+	DbSource          string        `json:"DB_SOURCE"`
+	ServerAddress     string        `json:"SERVER_ADDRESS"`
+	DBPort            string        `json:"POSTGRES_PORT"`
+	DBHost            string        `json:"DB_HOST"`
+	PostgresUser      string        `json:"POSTGRES_USER"`
+	PostgresPassword  string        `json:"POSTGRES_PASSWORD"`
+	PostgresDB        string        `json:"POSTGRES_DB"`
+	EvalCacheSize     int           `json:"EVAL_CACHE_SIZE"`
+	TruthTableMaxVars int           `json:"TRUTH_TABLE_MAX_VARIABLES"`
+	EvalBatchMaxSize  int           `json:"EVAL_BATCH_MAX_SIZE"`
+	EvalBatchTimeout  time.Duration `json:"EVAL_BATCH_TIMEOUT"`
+	EvalBatchMaxBytes int           `json:"EVAL_BATCH_MAX_BYTES"`
+	PurgeRetention    time.Duration `json:"PURGE_RETENTION"`
+	PurgeInterval     time.Duration `json:"PURGE_INTERVAL"`
+	MatchIndexTTL     time.Duration `json:"MATCH_INDEX_TTL"`
+	EvalLinkTTL       time.Duration `json:"EVAL_LINK_TTL"`
+	AdminUserIDs      []string      `json:"ADMIN_USER_IDS"`
 }
 
 // NewConfig returns the config struct loaded with the environment variables.
 func NewConfig() (Config, error) {
+	evalCacheSize, err := getInt(evalCacheSizeKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	truthTableMaxVars, err := getInt(truthTableMaxVarsKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	evalBatchMaxSize, err := getInt(evalBatchMaxSizeKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	evalBatchTimeout, err := getDuration(evalBatchTimeoutKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	evalBatchMaxBytes, err := getInt(evalBatchMaxBytesKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	purgeRetention, err := getDuration(purgeRetentionKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	purgeInterval, err := getDuration(purgeIntervalKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	matchIndexTTL, err := getDuration(matchIndexTTLKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	evalLinkTTL, err := getDuration(evalLinkTTLKey)
+	if err != nil {
+		return Config{}, err
+	}
+
 	return Config{
-		DbDriver:         os.Getenv(dbDriverKey),
-		DbSource:         os.Getenv(dbSourceKey),
-		ServerAddress:    os.Getenv(serverAddressKey),
-		DBPort:           os.Getenv(dbPortKey),
-		DBHost:           os.Getenv(dbHostKey),
-		PostgresUser:     os.Getenv(postgresUserKey),
-		PostgresPassword: os.Getenv(postgresPasswordKey),
-		PostgresDB:       os.Getenv(postgresDBKey),
+		DbDriver:          os.Getenv(dbDriverKey),
+		DbSource:          os.Getenv(dbSourceKey),
+		ServerAddress:     os.Getenv(serverAddressKey),
+		DBPort:            os.Getenv(dbPortKey),
+		DBHost:            os.Getenv(dbHostKey),
+		PostgresUser:      os.Getenv(postgresUserKey),
+		PostgresPassword:  os.Getenv(postgresPasswordKey),
+		PostgresDB:        os.Getenv(postgresDBKey),
+		EvalCacheSize:     evalCacheSize,
+		TruthTableMaxVars: truthTableMaxVars,
+		EvalBatchMaxSize:  evalBatchMaxSize,
+		EvalBatchTimeout:  evalBatchTimeout,
+		EvalBatchMaxBytes: evalBatchMaxBytes,
+		PurgeRetention:    purgeRetention,
+		PurgeInterval:     purgeInterval,
+		MatchIndexTTL:     matchIndexTTL,
+		EvalLinkTTL:       evalLinkTTL,
+		AdminUserIDs:      getList(adminUserIDsKey),
 	}, nil
 }
+
+// getInt reads an integer environment variable. Unset variables are read as 0.
+func getInt(key string) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q: %v", key, raw, err)
+	}
+
+	return v, nil
+}
+
+// getList reads a comma-separated environment variable, skipping empty items. Unset variables are read as nil.
+func getList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// getDuration reads a duration environment variable, such as 5s or 1m30s. Unset variables are read as 0.
+func getDuration(key string) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return 0, nil
+	}
+
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q: %v", key, raw, err)
+	}
+
+	return v, nil
+}
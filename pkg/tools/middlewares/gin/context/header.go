@@ -6,13 +6,23 @@ import (
 )
 
 const (
-	paginationPageHeader  = "x-pagination-page"
-	paginationPagesHeader = "x-pagination-pages"
-	paginationItemsHeader = "x-pagination-items"
+	paginationPageHeader   = "x-pagination-page"
+	paginationPagesHeader  = "x-pagination-pages"
+	paginationItemsHeader  = "x-pagination-items"
+	paginationCursorHeader = "x-pagination-next-cursor"
 )
 
+// SetPaginationHeader sets the headers of the pagination data that is known, leaving the others out.
 func SetPaginationHeader(ctx *gin.Context, pgData pagination.Pagination) {
-	ctx.Header(paginationPageHeader, pgData.Page)
-	ctx.Header(paginationPagesHeader, pgData.Pages)
-	ctx.Header(paginationItemsHeader, pgData.Items)
+	headers := map[string]string{
+		paginationPageHeader:   pgData.Page,
+		paginationPagesHeader:  pgData.Pages,
+		paginationItemsHeader:  pgData.Items,
+		paginationCursorHeader: pgData.NextCursor,
+	}
+	for k, v := range headers {
+		if v != "" {
+			ctx.Header(k, v)
+		}
+	}
 }
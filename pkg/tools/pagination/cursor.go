@@ -0,0 +1,82 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	orderAscending  = "asc"
+	orderDescending = "desc"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at the last item of a page. The next page starts right after it in the order the cursor was made
+// for: Key is the value the items are sorted by, as formatted by the caller, and RowID breaks ties between items
+// with the same key.
+type Cursor struct {
+	Sort       string
+	Descending bool
+	Key        string
+	RowID      int64
+}
+
+// Encode returns the opaque representation of the cursor handed to clients.
+func (c Cursor) Encode() string {
+	order := orderAscending
+	if c.Descending {
+		order = orderDescending
+	}
+
+	// the key goes last since it may contain the separator
+	raw := strings.Join([]string{c.Sort, order, strconv.FormatInt(c.RowID, 10), c.Key}, ":")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor returned by Cursor.Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 4)
+	if len(parts) != 4 || parts[0] == "" {
+		return Cursor{}, errInvalidCursor
+	}
+
+	var descending bool
+	switch parts[1] {
+	case orderAscending:
+	case orderDescending:
+		descending = true
+	default:
+		return Cursor{}, errInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+
+	return Cursor{Sort: parts[0], Descending: descending, Key: parts[3], RowID: id}, nil
+}
+
+// TimeKey formats a time as the key of a cursor, to the microsecond.
+func TimeKey(t time.Time) string {
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
+
+// ParseTimeKey parses a key formatted by TimeKey.
+func ParseTimeKey(key string) (time.Time, error) {
+	us, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return time.Time{}, errInvalidCursor
+	}
+
+	return time.UnixMicro(us).UTC(), nil
+}
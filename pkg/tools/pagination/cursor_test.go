@@ -0,0 +1,50 @@
+package pagination_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/gmaschi/log-exp-eval/pkg/tools/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2023, 2, 1, 10, 30, 15, 123456000, time.UTC)
+	cursors := []pagination.Cursor{
+		{Sort: "created_at", Key: pagination.TimeKey(createdAt), RowID: 42},
+		{Sort: "name", Descending: true, Key: "fraud:high", RowID: 7},
+		{Sort: "name", Key: "", RowID: 3},
+	}
+
+	for _, cursor := range cursors {
+		decoded, err := pagination.DecodeCursor(cursor.Encode())
+		require.NoError(t, err)
+		require.Equal(t, cursor, decoded)
+	}
+
+	key, err := pagination.ParseTimeKey(cursors[0].Key)
+	require.NoError(t, err)
+	require.Equal(t, createdAt, key)
+
+	_, err = pagination.ParseTimeKey("yesterday")
+	require.Error(t, err)
+
+	testCases := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "Not base64", cursor: "not a cursor"},
+		{name: "Missing key", cursor: base64.RawURLEncoding.EncodeToString([]byte("created_at:asc:42"))},
+		{name: "Missing sort", cursor: base64.RawURLEncoding.EncodeToString([]byte(":asc:42:1675247415123456"))},
+		{name: "Invalid order", cursor: base64.RawURLEncoding.EncodeToString([]byte("created_at:up:42:1675247415123456"))},
+		{name: "Invalid row ID", cursor: base64.RawURLEncoding.EncodeToString([]byte("created_at:asc:x:1675247415123456"))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pagination.DecodeCursor(tc.cursor)
+			require.Error(t, err)
+		})
+	}
+}
@@ -6,9 +6,10 @@ import (
 )
 
 type Pagination struct {
-	Page  string
-	Pages string
-	Items string
+	Page       string
+	Pages      string
+	Items      string
+	NextCursor string
 }
 
 func GetData(totalItems int, page, pageSize int32) Pagination {
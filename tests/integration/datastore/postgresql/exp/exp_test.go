@@ -38,7 +38,7 @@ func TestListExpressions(t *testing.T) {
 			createRandomExpression(t)
 		}
 
-		exps, err := testStore.ListExpressions(context.Background())
+		exps, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{})
 		require.NoError(t, err)
 		require.NotEmpty(t, exps)
 		require.GreaterOrEqual(t, len(exps), n)
@@ -57,12 +57,12 @@ func TestListExpressions(t *testing.T) {
 		limit := 5
 		offset := 3
 
-		listPagArgs := expstore.ListPaginatedExpressionsParams{
+		listPagArgs := expstore.ListExpressionsParams{
 			Limit:  int32(limit),
 			Offset: int32(offset),
 		}
 
-		exps, err := testStore.ListPaginatedExpressions(context.Background(), listPagArgs)
+		exps, err := testStore.ListExpressions(context.Background(), listPagArgs)
 		require.NoError(t, err)
 		require.NotEmpty(t, exps)
 		require.Len(t, exps, limit)
@@ -73,6 +73,223 @@ func TestListExpressions(t *testing.T) {
 	})
 }
 
+func TestListFilteredExpressions(t *testing.T) {
+	username := "user-" + uuid.NewString()
+	base := time.Now().Add(-time.Hour)
+	specs := []struct {
+		expression string
+		name       string
+		variables  []string
+	}{
+		{expression: `age >= 18 AND country = "BR"`, name: "adults", variables: []string{"age", "country"}},
+		{expression: "fraud_score > 0.9 OR blocked", name: "fraud", variables: []string{"blocked", "fraud_score"}},
+		{expression: "x AND y", name: "basic", variables: []string{"x", "y"}},
+	}
+	exps := make([]expstore.Expressions, 0, len(specs))
+	for i, spec := range specs {
+		createdAt := base.Add(time.Duration(i) * 10 * time.Minute)
+		exp, err := testStore.CreateExpression(context.Background(), expstore.CreateExpressionParams{
+			ExpressionID: uuid.New(),
+			Expression:   spec.expression,
+			Username:     username,
+			CreatedAt:    createdAt,
+			UpdatedAt:    createdAt,
+			Tags:         []string{},
+			Name:         spec.name,
+			Variables:    spec.variables,
+		})
+		require.NoError(t, err)
+		exps = append(exps, exp)
+	}
+	createRandomExpression(t)
+
+	ids := func(exps []expstore.Expressions) []uuid.UUID {
+		res := make([]uuid.UUID, 0, len(exps))
+		for _, exp := range exps {
+			res = append(res, exp.ExpressionID)
+		}
+		return res
+	}
+
+	testCases := []struct {
+		name string
+		args expstore.ListFilters
+		want []expstore.Expressions
+	}{
+		{
+			name: "Owner",
+			args: expstore.ListFilters{},
+			want: exps,
+		},
+		{
+			name: "Creation date range",
+			args: expstore.ListFilters{
+				CreatedAfter:  sql.NullTime{Time: base.Add(5 * time.Minute), Valid: true},
+				CreatedBefore: sql.NullTime{Time: base.Add(20 * time.Minute), Valid: true},
+			},
+			want: exps[1:2],
+		},
+		{
+			name: "Update date lower bound is inclusive",
+			args: expstore.ListFilters{UpdatedAfter: sql.NullTime{Time: exps[2].UpdatedAt, Valid: true}},
+			want: exps[2:],
+		},
+		{
+			name: "Text contained, ignoring case",
+			args: expstore.ListFilters{Contains: "COUNTRY ="},
+			want: exps[:1],
+		},
+		{
+			name: "Escaped wildcards are matched literally",
+			args: expstore.ListFilters{Contains: `score \_`},
+			want: []expstore.Expressions{},
+		},
+		{
+			name: "Full-text search",
+			args: expstore.ListFilters{Search: "blocked"},
+			want: exps[1:2],
+		},
+		{
+			name: "Used variable",
+			args: expstore.ListFilters{Variable: "age"},
+			want: exps[:1],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.args.Username = username
+			gotExps, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{ListFilters: tc.args})
+			require.NoError(t, err)
+			require.Equal(t, ids(tc.want), ids(gotExps))
+
+			count, err := testStore.CountExpressions(context.Background(), tc.args)
+			require.NoError(t, err)
+			require.Equal(t, int64(len(tc.want)), count)
+		})
+	}
+
+	t.Run("Sorted by name", func(t *testing.T) {
+		gotExps, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{
+			ListFilters: expstore.ListFilters{Username: username},
+			Sort:        expstore.SortName,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids([]expstore.Expressions{exps[0], exps[2], exps[1]}), ids(gotExps))
+	})
+
+	t.Run("Sorted by creation date, descending", func(t *testing.T) {
+		gotExps, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{
+			ListFilters: expstore.ListFilters{Username: username},
+			Descending:  true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids([]expstore.Expressions{exps[2], exps[1], exps[0]}), ids(gotExps))
+	})
+
+	t.Run("Sorted by update date, descending", func(t *testing.T) {
+		gotExps, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{
+			ListFilters: expstore.ListFilters{Username: username},
+			Sort:        expstore.SortUpdatedAt,
+			Descending:  true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids([]expstore.Expressions{exps[2], exps[1], exps[0]}), ids(gotExps))
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		gotExps, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{
+			ListFilters: expstore.ListFilters{Username: username},
+			Descending:  true,
+			Limit:       2,
+			Offset:      1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids([]expstore.Expressions{exps[1], exps[0]}), ids(gotExps))
+	})
+}
+
+func TestListExpressionsByCursor(t *testing.T) {
+	username := "user-" + uuid.NewString()
+	exps := []expstore.Expressions{
+		createExpressionFor(t, username, []string{}),
+		createExpressionFor(t, username, []string{}),
+		createExpressionFor(t, username, []string{}),
+	}
+	createRandomExpression(t)
+
+	ids := func(exps []expstore.Expressions) []uuid.UUID {
+		res := make([]uuid.UUID, 0, len(exps))
+		for _, exp := range exps {
+			res = append(res, exp.ExpressionID)
+		}
+		return res
+	}
+
+	t.Run("Ascending", func(t *testing.T) {
+		firstPage, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{
+			ListFilters: expstore.ListFilters{Username: username},
+			Limit:       2,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids(exps[:2]), ids(firstPage))
+
+		last := firstPage[len(firstPage)-1]
+		secondPage, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{
+			ListFilters: expstore.ListFilters{Username: username},
+			After:       &expstore.ListKey{Value: last.CreatedAt, RowID: last.RowID},
+			Limit:       2,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids(exps[2:]), ids(secondPage))
+	})
+
+	t.Run("Descending", func(t *testing.T) {
+		gotExps, err := testStore.ListExpressions(context.Background(), expstore.ListExpressionsParams{
+			ListFilters: expstore.ListFilters{Username: username},
+			Descending:  true,
+			After:       &expstore.ListKey{Value: exps[2].CreatedAt, RowID: exps[2].RowID},
+			Limit:       5,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids([]expstore.Expressions{exps[1], exps[0]}), ids(gotExps))
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		count, err := testStore.CountExpressions(context.Background(), expstore.ListFilters{Username: username})
+		require.NoError(t, err)
+		require.Equal(t, int64(len(exps)), count)
+	})
+}
+
+func TestListExpressionsByUsername(t *testing.T) {
+	username := "user-" + uuid.NewString()
+	tagged := createExpressionFor(t, username, []string{"fraud", "payments"})
+	untagged := createExpressionFor(t, username, []string{})
+	createRandomExpression(t)
+
+	t.Run("List the expressions of a user", func(t *testing.T) {
+		exps, err := testStore.ListExpressionsByUsername(context.Background(), expstore.ListExpressionsByUsernameParams{
+			Username: username,
+		})
+		require.NoError(t, err)
+		require.Len(t, exps, 2)
+		require.Equal(t, tagged.ExpressionID, exps[0].ExpressionID)
+		require.Equal(t, untagged.ExpressionID, exps[1].ExpressionID)
+	})
+
+	t.Run("List the expressions of a user with a tag", func(t *testing.T) {
+		exps, err := testStore.ListExpressionsByUsername(context.Background(), expstore.ListExpressionsByUsernameParams{
+			Username: username,
+			Tag:      "payments",
+		})
+		require.NoError(t, err)
+		require.Len(t, exps, 1)
+		require.Equal(t, tagged.ExpressionID, exps[0].ExpressionID)
+		require.Equal(t, tagged.Tags, exps[0].Tags)
+	})
+}
+
 func TestUpdateExpression(t *testing.T) {
 	t.Run("Update expression", func(t *testing.T) {
 		exp := createRandomExpression(t)
@@ -80,6 +297,8 @@ func TestUpdateExpression(t *testing.T) {
 		updateArgs := expstore.UpdateExpressionParams{
 			ExpressionID: exp.ExpressionID,
 			Expression:   "(h OR j)",
+			Tags:         []string{"updated"},
+			Variables:    []string{"h", "j"},
 			UpdatedAt:    time.Now(),
 		}
 
@@ -88,9 +307,91 @@ func TestUpdateExpression(t *testing.T) {
 		require.NotEmpty(t, updatedExp)
 		require.Equal(t, updateArgs.ExpressionID, updatedExp.ExpressionID)
 		require.Equal(t, updateArgs.Expression, updatedExp.Expression)
+		require.Equal(t, updateArgs.Tags, updatedExp.Tags)
 		require.Equal(t, exp.Username, updatedExp.Username)
 		require.WithinDuration(t, exp.CreatedAt, updatedExp.CreatedAt, time.Second)
 		require.WithinDuration(t, updateArgs.UpdatedAt, updatedExp.UpdatedAt, time.Second)
+		require.Equal(t, exp.Version+1, updatedExp.Version)
+	})
+
+	t.Run("Update expression with expected version", func(t *testing.T) {
+		exp := createRandomExpression(t)
+		require.Equal(t, int32(1), exp.Version)
+
+		updateArgs := expstore.UpdateExpressionParams{
+			ExpressionID:    exp.ExpressionID,
+			Expression:      "(h OR j)",
+			Tags:            []string{},
+			Variables:       []string{"h", "j"},
+			UpdatedAt:       time.Now(),
+			ExpectedVersion: exp.Version,
+		}
+
+		updatedExp, err := testStore.UpdateExpression(context.Background(), updateArgs)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), updatedExp.Version)
+
+		// the expression no longer has the expected version
+		_, err = testStore.UpdateExpression(context.Background(), updateArgs)
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+
+		gotExp, err := testStore.GetExpressionByID(context.Background(), exp.ExpressionID)
+		require.NoError(t, err)
+		require.Equal(t, updatedExp, gotExp)
+	})
+}
+
+func TestExpressionVersions(t *testing.T) {
+	t.Run("Create and updates record versions", func(t *testing.T) {
+		expID, err := uuid.NewRandom()
+		require.NoError(t, err)
+
+		now := time.Now()
+		exp, err := testStore.CreateExpressionTx(context.Background(), expstore.CreateExpressionParams{
+			ExpressionID: expID,
+			Expression:   "x AND y",
+			Username:     "some-username",
+			CreatedAt:    now,
+			UpdatedAt:    now,
+			Tags:         []string{},
+			Variables:    []string{"x", "y"},
+		})
+		require.NoError(t, err)
+
+		updateArgs := expstore.UpdateExpressionTxParams{
+			UpdateExpressionParams: expstore.UpdateExpressionParams{
+				ExpressionID: exp.ExpressionID,
+				Expression:   "x OR y",
+				Tags:         []string{},
+				Variables:    []string{"x", "y"},
+				UpdatedAt:    time.Now(),
+			},
+			Author: "other-username",
+		}
+		_, err = testStore.UpdateExpressionTx(context.Background(), updateArgs)
+		require.NoError(t, err)
+
+		// updates that keep the text do not record a version
+		updateArgs.Tags = []string{"retagged"}
+		_, err = testStore.UpdateExpressionTx(context.Background(), updateArgs)
+		require.NoError(t, err)
+
+		versions, err := testStore.ListExpressionVersions(context.Background(), exp.ExpressionID)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.Equal(t, int32(1), versions[0].Version)
+		require.Equal(t, "x AND y", versions[0].Expression)
+		require.Equal(t, "some-username", versions[0].Author)
+		require.Equal(t, int32(2), versions[1].Version)
+		require.Equal(t, "x OR y", versions[1].Expression)
+		require.Equal(t, "other-username", versions[1].Author)
+
+		version, err := testStore.GetExpressionVersion(context.Background(), expstore.GetExpressionVersionParams{
+			ExpressionID: exp.ExpressionID,
+			Version:      1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, versions[0], version)
 	})
 }
 
@@ -98,18 +399,91 @@ func TestDeleteExpression(t *testing.T) {
 	t.Run("Delete expression by ID", func(t *testing.T) {
 		exp := createRandomExpression(t)
 
-		err := testStore.DeleteExpressionByID(context.Background(), exp.ExpressionID)
+		// a stale version does not delete the expression
+		deleted, err := testStore.DeleteExpressionByID(context.Background(), expstore.DeleteExpressionByIDParams{
+			ExpressionID:    exp.ExpressionID,
+			ExpectedVersion: exp.Version + 1,
+		})
+		require.NoError(t, err)
+		require.Zero(t, deleted)
+
+		deleted, err = testStore.DeleteExpressionByID(context.Background(), expstore.DeleteExpressionByIDParams{
+			ExpressionID:    exp.ExpressionID,
+			ExpectedVersion: exp.Version,
+		})
 		require.NoError(t, err)
+		require.EqualValues(t, 1, deleted)
 
 		deletedExp, err := testStore.GetExpressionByID(context.Background(), exp.ExpressionID)
 		require.EqualError(t, err, sql.ErrNoRows.Error())
 		require.Empty(t, deletedExp)
+
+		deletedExp, err = testStore.GetDeletedExpressionByID(context.Background(), exp.ExpressionID)
+		require.NoError(t, err)
+		require.Equal(t, exp.ExpressionID, deletedExp.ExpressionID)
+		require.True(t, deletedExp.DeletedAt.Valid)
+	})
+
+	t.Run("Restore deleted expression", func(t *testing.T) {
+		exp := createRandomExpression(t)
+
+		_, err := testStore.DeleteExpressionByID(context.Background(), expstore.DeleteExpressionByIDParams{ExpressionID: exp.ExpressionID})
+		require.NoError(t, err)
+
+		// the expected version must be the current one when given
+		_, err = testStore.RestoreExpressionByID(context.Background(), expstore.RestoreExpressionByIDParams{
+			ExpressionID:    exp.ExpressionID,
+			ExpectedVersion: exp.Version + 1,
+		})
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+
+		restoredExp, err := testStore.RestoreExpressionByID(context.Background(), expstore.RestoreExpressionByIDParams{ExpressionID: exp.ExpressionID})
+		require.NoError(t, err)
+		require.False(t, restoredExp.DeletedAt.Valid)
+
+		gotExp, err := testStore.GetExpressionByID(context.Background(), exp.ExpressionID)
+		require.NoError(t, err)
+		require.Equal(t, restoredExp, gotExp)
+
+		// expressions that are not deleted cannot be restored
+		_, err = testStore.RestoreExpressionByID(context.Background(), expstore.RestoreExpressionByIDParams{ExpressionID: exp.ExpressionID})
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+	})
+
+	t.Run("Purge deleted expressions", func(t *testing.T) {
+		deleted := createRandomExpression(t)
+		kept := createRandomExpression(t)
+
+		_, err := testStore.DeleteExpressionByID(context.Background(), expstore.DeleteExpressionByIDParams{ExpressionID: deleted.ExpressionID})
+		require.NoError(t, err)
+
+		// expressions deleted after the cutoff are kept
+		_, err = testStore.PurgeDeletedExpressions(context.Background(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		_, err = testStore.GetDeletedExpressionByID(context.Background(), deleted.ExpressionID)
+		require.NoError(t, err)
+
+		purged, err := testStore.PurgeDeletedExpressions(context.Background(), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, purged, int64(1))
+
+		_, err = testStore.GetDeletedExpressionByID(context.Background(), deleted.ExpressionID)
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+
+		_, err = testStore.GetExpressionByID(context.Background(), kept.ExpressionID)
+		require.NoError(t, err)
 	})
 }
 
 func createRandomExpression(t *testing.T) expstore.Expressions {
 	t.Helper()
 
+	return createExpressionFor(t, "some-username", []string{})
+}
+
+func createExpressionFor(t *testing.T, username string, tags []string) expstore.Expressions {
+	t.Helper()
+
 	expID, err := uuid.NewRandom()
 	require.NoError(t, err)
 	require.NotEmpty(t, expID)
@@ -118,9 +492,11 @@ func createRandomExpression(t *testing.T) expstore.Expressions {
 	createExpArgs := expstore.CreateExpressionParams{
 		ExpressionID: expID,
 		Expression:   "(x AND y) AND k",
-		Username:     "some-username",
+		Username:     username,
 		CreatedAt:    now,
 		UpdatedAt:    now,
+		Tags:         tags,
+		Variables:    []string{"k", "x", "y"},
 	}
 
 	exp, err := testStore.CreateExpression(context.Background(), createExpArgs)
@@ -129,6 +505,7 @@ func createRandomExpression(t *testing.T) expstore.Expressions {
 	require.Equal(t, createExpArgs.ExpressionID, exp.ExpressionID)
 	require.Equal(t, createExpArgs.Expression, exp.Expression)
 	require.Equal(t, createExpArgs.Username, exp.Username)
+	require.Equal(t, createExpArgs.Tags, exp.Tags)
 	require.WithinDuration(t, createExpArgs.CreatedAt, exp.CreatedAt, time.Second)
 	require.WithinDuration(t, createExpArgs.UpdatedAt, exp.UpdatedAt, time.Second)
 